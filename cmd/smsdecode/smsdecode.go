@@ -7,10 +7,12 @@ package main
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/warthog618/sms/encoding/tpdu"
@@ -20,6 +22,10 @@ import (
 func main() {
 	pm := flag.Bool("p", false, "PDU is prefixed with SCA (PDU mode)")
 	orig := flag.Bool("o", false, "PDU is mobile originated")
+	js := flag.Bool("j", false, "dump the PDU in JSON format")
+	dis := flag.Bool("d", false, "dump the PDU as an annotated hex dump")
+	lenient := flag.Bool("l", false, "decode leniently, reporting deviations as warnings")
+	auto := flag.Bool("a", false, "detect the direction of the PDU, rather than assuming MT")
 	drn := tpdu.MT
 	flag.Usage = usage
 	flag.Parse()
@@ -42,9 +48,19 @@ func main() {
 			log.Fatal(err)
 		}
 		tb = ntb
-		spew.Dump(smsc)
+		if *dis {
+			smscf := tpdu.Field{
+				Name:   "smsc",
+				Length: len(b) - len(tb),
+				Raw:    b[:len(b)-len(tb)],
+				Value:  tpdu.Address(*smsc).Number(),
+			}
+			dissect([]tpdu.Field{smscf}, 0, 0)
+		} else {
+			dump(smsc, *js)
+		}
 	}
-	td, err := tpdu.NewDecoder(
+	opts := []tpdu.DecoderOption{
 		tpdu.RegisterCommandDecoder,
 		tpdu.RegisterDeliverDecoder,
 		tpdu.RegisterDeliverReportDecoder,
@@ -52,18 +68,126 @@ func main() {
 		tpdu.RegisterSubmitDecoder,
 		tpdu.RegisterSubmitReportDecoder,
 		tpdu.RegisterStatusReportDecoder,
-	)
+	}
+	if *lenient {
+		opts = append(opts, tpdu.LenientDecoding)
+	}
+	td, err := tpdu.NewDecoder(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var tp tpdu.PDU
+	var warnings []tpdu.Warning
+	if *auto {
+		best, alts, derr := td.DecodeAnyDirection(tb)
+		log.Printf("direction: %v (confidence %.2f)\n", best.Direction, best.Confidence)
+		for _, a := range alts {
+			log.Printf("alternative: %v (confidence %.2f)\n", a.Direction, a.Confidence)
+		}
+		drn = best.Direction
+		tp, warnings, err = best.TPDU, best.Warnings, derr
+	} else {
+		tp, warnings, err = td.DecodeWithWarnings(tb, drn)
+	}
+	if *dis {
+		fields, err := tpdu.Dissect(tb, drn)
+		dissect(fields, len(b)-len(tb), 0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for _, w := range warnings {
+		log.Println("warning:", w)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	tp, err := td.Decode(tb, drn)
+	dump(tp, *js)
+}
+
+func dump(v interface{}, js bool) {
+	if !js {
+		spew.Dump(v)
+		return
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		log.Fatal(err)
 	}
-	spew.Dump(tp)
+	fmt.Println(string(b))
+}
+
+// dissect prints the fields as an annotated hex dump, with the offsets of the
+// fields shifted by base.
+func dissect(fields []tpdu.Field, base, depth int) {
+	const perLine = 8
+	indent := strings.Repeat("  ", depth)
+	for _, f := range fields {
+		desc := indent + f.Name
+		if f.Value != "" {
+			desc += ": " + f.Value
+		}
+		if f.Err != nil {
+			desc += fmt.Sprintf(" [%v]", f.Err)
+		}
+		if f.Bits != nil {
+			fmt.Printf("%04x  %-23s  %s\n", base+f.Offset, bitPattern(f), desc)
+		} else {
+			raw := f.Raw
+			off := base + f.Offset
+			for {
+				n := len(raw)
+				if n > perLine {
+					n = perLine
+				}
+				fmt.Printf("%04x  %-23s  %s\n", off, hexOctets(raw[:n]), desc)
+				raw = raw[n:]
+				off += n
+				desc = ""
+				if len(raw) == 0 {
+					break
+				}
+			}
+		}
+		dissect(f.Children, base, depth+1)
+	}
+}
+
+// bitPattern returns the bits of the field within its octet, with bits
+// outside the field shown as '.'.
+func bitPattern(f tpdu.Field) string {
+	var b byte
+	if len(f.Raw) > 0 {
+		b = f.Raw[0]
+	}
+	m := f.Bits.Mask()
+	p := make([]byte, 0, 9)
+	for i := 7; i >= 0; i-- {
+		switch {
+		case m&(1<<uint(i)) == 0:
+			p = append(p, '.')
+		case b&(1<<uint(i)) != 0:
+			p = append(p, '1')
+		default:
+			p = append(p, '0')
+		}
+		if i == 4 {
+			p = append(p, ' ')
+		}
+	}
+	return string(p)
+}
+
+func hexOctets(b []byte) string {
+	s := make([]string, len(b))
+	for i, o := range b {
+		s[i] = fmt.Sprintf("%02x", o)
+	}
+	return strings.Join(s, " ")
 }
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: smsdecode [-p] [-o] <sms>\n")
+	fmt.Fprintf(os.Stderr, "Usage: smsdecode [-p] [-o] [-a] [-j] [-d] [-l] <sms>\n")
 	flag.PrintDefaults()
 }
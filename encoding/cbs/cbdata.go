@@ -0,0 +1,75 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs
+
+import (
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// cbDataPageLength is the length of a page in CB-Data, being the content
+// followed by the length of the content.
+const cbDataPageLength = ContentLength + 1
+
+// UnmarshalCBData unmarshals the CB-Data of a UMTS, LTE or NR CBS message,
+// as defined in 3GPP TS 23.041 Section 9.4.2.2.5, into pages.
+//
+// The serial number, message identifier and DCS are carried outside the
+// CB-Data, so must be provided.
+// The Content of each page is limited to the CBS-Message-Information-Length
+// of the page.
+func UnmarshalCBData(sn SerialNumber, mid MessageID, dcs tpdu.CBSDCS, src []byte) ([]*Page, error) {
+	if len(src) < 1 {
+		return nil, ErrUnderflow
+	}
+	n := int(src[0])
+	if n < 1 || n > MaxPages {
+		return nil, ErrInvalidPageParameter
+	}
+	l := 1 + n*cbDataPageLength
+	if len(src) < l {
+		return nil, ErrUnderflow
+	}
+	if len(src) > l {
+		return nil, ErrOverlength
+	}
+	pages := make([]*Page, n)
+	for i := range pages {
+		b := src[1+i*cbDataPageLength:]
+		cl := int(b[ContentLength])
+		if cl > ContentLength {
+			return nil, ErrOverlength
+		}
+		pages[i] = &Page{
+			SerialNumber: sn,
+			MessageID:    mid,
+			DCS:          dcs,
+			Page:         i + 1,
+			Pages:        n,
+			Content:      append([]byte(nil), b[:cl]...),
+		}
+	}
+	return pages, nil
+}
+
+// MarshalCBData marshals the content of the pages, which must be ordered by
+// page number, into CB-Data.
+// Page content shorter than ContentLength is padded with zeroes.
+func MarshalCBData(pages []*Page) ([]byte, error) {
+	if len(pages) < 1 || len(pages) > MaxPages {
+		return nil, ErrInvalidPageParameter
+	}
+	b := make([]byte, 1+len(pages)*cbDataPageLength)
+	b[0] = byte(len(pages))
+	for i, p := range pages {
+		if len(p.Content) > ContentLength {
+			return nil, ErrOverlength
+		}
+		pb := b[1+i*cbDataPageLength:]
+		copy(pb, p.Content)
+		pb[ContentLength] = byte(len(p.Content))
+	}
+	return b, nil
+}
@@ -0,0 +1,94 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestUnmarshalCBData(t *testing.T) {
+	sn := cbs.SerialNumber(0x4011)
+	mid := cbs.MessageID(0x1112)
+	dcs := tpdu.CBSDCS(0x01)
+	page := func(content []byte) []byte {
+		b := make([]byte, cbs.ContentLength+1)
+		copy(b, content)
+		b[cbs.ContentLength] = byte(len(content))
+		return b
+	}
+	full := bytes.Repeat([]byte{0x41}, cbs.ContentLength)
+	two := append([]byte{2}, page(full)...)
+	two = append(two, page([]byte{0x42, 0x43})...)
+	bad := append([]byte{1}, page(nil)...)
+	bad[cbs.ContentLength+1] = cbs.ContentLength + 1
+	patterns := []struct {
+		name string
+		in   []byte
+		out  []*cbs.Page
+		err  error
+	}{
+		{"empty", nil, nil, cbs.ErrUnderflow},
+		{"zero pages", []byte{0}, nil, cbs.ErrInvalidPageParameter},
+		{"too many pages", []byte{16}, nil, cbs.ErrInvalidPageParameter},
+		{"short", append([]byte{1}, page(nil)[1:]...), nil, cbs.ErrUnderflow},
+		{"long", append(append([]byte{1}, page(nil)...), 0), nil, cbs.ErrOverlength},
+		{"content overlength", bad, nil, cbs.ErrOverlength},
+		{"one", append([]byte{1}, page([]byte{0x41})...),
+			[]*cbs.Page{
+				{SerialNumber: sn, MessageID: mid, DCS: dcs, Page: 1, Pages: 1,
+					Content: []byte{0x41}},
+			},
+			nil},
+		{"two", two,
+			[]*cbs.Page{
+				{SerialNumber: sn, MessageID: mid, DCS: dcs, Page: 1, Pages: 2,
+					Content: full},
+				{SerialNumber: sn, MessageID: mid, DCS: dcs, Page: 2, Pages: 2,
+					Content: []byte{0x42, 0x43}},
+			},
+			nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pages, err := cbs.UnmarshalCBData(sn, mid, dcs, p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, pages)
+			if err != nil {
+				return
+			}
+			b, err := cbs.MarshalCBData(pages)
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestMarshalCBData(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []*cbs.Page
+		err  error
+	}{
+		{"none", nil, cbs.ErrInvalidPageParameter},
+		{"too many", make([]*cbs.Page, 16), cbs.ErrInvalidPageParameter},
+		{"overlength", []*cbs.Page{{Content: make([]byte, cbs.ContentLength+1)}},
+			cbs.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := cbs.MarshalCBData(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, b)
+		}
+		t.Run(p.name, f)
+	}
+}
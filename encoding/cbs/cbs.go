@@ -0,0 +1,187 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package cbs provides encoding and decoding of Cell Broadcast Service
+// messages as described in 3GPP TS 23.041.
+//
+// A CBS message is broadcast as one or more 88 octet pages, each containing
+// the serial number and message identifier of the message, the CBS Data
+// Coding Scheme, the page parameter and 82 octets of content.
+//
+// The content is encoded as per the CBS DCS, as defined in 3GPP TS 23.038
+// Section 5, using the gsm7 and ucs2 packages.
+package cbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+const (
+	// PageLength is the length of a CBS page.
+	PageLength = 88
+
+	// ContentLength is the length of the content of a CBS page.
+	ContentLength = 82
+
+	// MaxPages is the maximum number of pages in a CBS message.
+	MaxPages = 15
+
+	headerLength = PageLength - ContentLength
+)
+
+// GeographicalScope indicates the area over which the message code is
+// unique, and the display mode of the message.
+type GeographicalScope int
+
+const (
+	// GSCellImmediate indicates a cell wide scope, with the message to be
+	// displayed immediately.
+	GSCellImmediate GeographicalScope = iota
+	// GSPLMN indicates a PLMN wide scope.
+	GSPLMN
+	// GSLocationArea indicates a Location Area, Service Area or Tracking
+	// Area wide scope.
+	GSLocationArea
+	// GSCell indicates a cell wide scope.
+	GSCell
+)
+
+var gsNames = map[GeographicalScope]string{
+	GSCellImmediate: "cellImmediate",
+	GSPLMN:          "plmn",
+	GSLocationArea:  "locationArea",
+	GSCell:          "cell",
+}
+
+func (g GeographicalScope) String() string {
+	if s, ok := gsNames[g]; ok {
+		return s
+	}
+	return fmt.Sprintf("GeographicalScope(%d)", int(g))
+}
+
+// SerialNumber identifies a particular CBS message, as defined in
+// 3GPP TS 23.041 Section 9.4.1.2.1.
+//
+// The SerialNumber contains the geographical scope, message code and update
+// number of the message.
+type SerialNumber uint16
+
+// NewSerialNumber creates a SerialNumber from its components.
+// The message code is limited to 10 bits, and the update number to 4 bits.
+func NewSerialNumber(gs GeographicalScope, code, update int) SerialNumber {
+	return SerialNumber(uint16(gs&0x3)<<14 | uint16(code&0x3ff)<<4 | uint16(update&0xf))
+}
+
+// GeographicalScope returns the geographical scope of the message.
+func (s SerialNumber) GeographicalScope() GeographicalScope {
+	return GeographicalScope(s >> 14)
+}
+
+// MessageCode returns the message code of the message.
+func (s SerialNumber) MessageCode() int {
+	return int(s>>4) & 0x3ff
+}
+
+// UpdateNumber returns the update number of the message.
+// The update number changes when the content of the message changes.
+func (s SerialNumber) UpdateNumber() int {
+	return int(s) & 0xf
+}
+
+// MessageID identifies the source and type of a CBS message, as defined in
+// 3GPP TS 23.041 Section 9.4.1.2.2.
+type MessageID uint16
+
+// Page is a single page of a CBS message, as defined in 3GPP TS 23.041
+// Section 9.4.1.2.
+type Page struct {
+	SerialNumber SerialNumber
+	MessageID    MessageID
+	DCS          tpdu.CBSDCS
+
+	// Page is the number of the page within the message, starting at 1.
+	Page int
+
+	// Pages is the number of pages in the message.
+	Pages int
+
+	// Content is the content of the page, encoded as per the DCS.
+	// For GSM7 the content is the packed septets.
+	Content []byte
+}
+
+// MarshalBinary marshals a CBS page.
+// Content shorter than ContentLength is padded with zeroes.
+func (p *Page) MarshalBinary() ([]byte, error) {
+	if p.Pages < 1 || p.Pages > MaxPages || p.Page < 1 || p.Page > p.Pages {
+		return nil, ErrInvalidPageParameter
+	}
+	if len(p.Content) > ContentLength {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, PageLength)
+	binary.BigEndian.PutUint16(b, uint16(p.SerialNumber))
+	binary.BigEndian.PutUint16(b[2:], uint16(p.MessageID))
+	b[4] = byte(p.DCS)
+	b[5] = byte(p.Page<<4 | p.Pages)
+	copy(b[headerLength:], p.Content)
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals a CBS page.
+// A page parameter of 0 is treated as page 1 of 1, as per 3GPP TS 23.041.
+func (p *Page) UnmarshalBinary(src []byte) error {
+	if len(src) < PageLength {
+		return ErrUnderflow
+	}
+	if len(src) > PageLength {
+		return ErrOverlength
+	}
+	page := int(src[5] >> 4)
+	pages := int(src[5] & 0xf)
+	if page == 0 && pages == 0 {
+		page, pages = 1, 1
+	}
+	if page < 1 || page > pages {
+		return ErrInvalidPageParameter
+	}
+	p.SerialNumber = SerialNumber(binary.BigEndian.Uint16(src))
+	p.MessageID = MessageID(binary.BigEndian.Uint16(src[2:]))
+	p.DCS = tpdu.CBSDCS(src[4])
+	p.Page = page
+	p.Pages = pages
+	p.Content = append([]byte(nil), src[headerLength:]...)
+	return nil
+}
+
+var (
+	// ErrCompressed indicates the content is compressed, which is not
+	// supported.
+	ErrCompressed = errors.New("cbs: compressed content not supported")
+
+	// ErrInvalidLanguagePrefix indicates the content does not start with a
+	// valid language prefix.
+	ErrInvalidLanguagePrefix = errors.New("cbs: invalid language prefix")
+
+	// ErrInvalidPageParameter indicates the page number is not within the
+	// number of pages, or the number of pages is outside the range 1-15.
+	ErrInvalidPageParameter = errors.New("cbs: invalid page parameter")
+
+	// ErrOverlength indicates the binary provided is too long to be a page,
+	// or the message is too long to be encoded in 15 pages.
+	ErrOverlength = errors.New("cbs: overlength")
+
+	// ErrUnderflow indicates the binary provided is too short to be a page.
+	ErrUnderflow = errors.New("cbs: underflow")
+
+	// ErrUnsupportedLanguage indicates the language cannot be indicated in
+	// the alphabet selected for the message.
+	ErrUnsupportedLanguage = errors.New("cbs: unsupported language")
+)
@@ -0,0 +1,169 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestSerialNumber(t *testing.T) {
+	patterns := []struct {
+		name   string
+		gs     cbs.GeographicalScope
+		code   int
+		update int
+		out    cbs.SerialNumber
+	}{
+		{"zero", cbs.GSCellImmediate, 0, 0, 0x0000},
+		{"plmn", cbs.GSPLMN, 5, 1, 0x4051},
+		{"max", cbs.GSCell, 0x3ff, 0xf, 0xffff},
+		{"overflow", cbs.GSLocationArea, 0x401, 0x12, 0x8012},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			sn := cbs.NewSerialNumber(p.gs, p.code, p.update)
+			assert.Equal(t, p.out, sn)
+			assert.Equal(t, p.gs, sn.GeographicalScope())
+			assert.Equal(t, p.code&0x3ff, sn.MessageCode())
+			assert.Equal(t, p.update&0xf, sn.UpdateNumber())
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestGeographicalScopeString(t *testing.T) {
+	patterns := []struct {
+		in  cbs.GeographicalScope
+		out string
+	}{
+		{cbs.GSCellImmediate, "cellImmediate"},
+		{cbs.GSPLMN, "plmn"},
+		{cbs.GSLocationArea, "locationArea"},
+		{cbs.GSCell, "cell"},
+		{cbs.GeographicalScope(5), "GeographicalScope(5)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
+
+func page(hdr []byte, content []byte) []byte {
+	b := make([]byte, cbs.PageLength)
+	copy(b, hdr)
+	copy(b[6:], content)
+	return b
+}
+
+func TestPageMarshalBinary(t *testing.T) {
+	content := bytes.Repeat([]byte{0xab}, cbs.ContentLength)
+	patterns := []struct {
+		name string
+		in   cbs.Page
+		out  []byte
+		err  error
+	}{
+		{"single",
+			cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x0f, Page: 1, Pages: 1,
+				Content: content},
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x0f, 0x11}, content),
+			nil},
+		{"short content",
+			cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x01, Page: 2, Pages: 3,
+				Content: []byte{1, 2, 3}},
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x01, 0x23}, []byte{1, 2, 3}),
+			nil},
+		{"overlength",
+			cbs.Page{Page: 1, Pages: 1, Content: make([]byte, cbs.ContentLength+1)},
+			nil,
+			cbs.ErrOverlength},
+		{"zero pages",
+			cbs.Page{},
+			nil,
+			cbs.ErrInvalidPageParameter},
+		{"page beyond pages",
+			cbs.Page{Page: 3, Pages: 2},
+			nil,
+			cbs.ErrInvalidPageParameter},
+		{"too many pages",
+			cbs.Page{Page: 1, Pages: 16},
+			nil,
+			cbs.ErrInvalidPageParameter},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPageUnmarshalBinary(t *testing.T) {
+	content := bytes.Repeat([]byte{0xab}, cbs.ContentLength)
+	patterns := []struct {
+		name string
+		in   []byte
+		out  cbs.Page
+		err  error
+	}{
+		{"single",
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x0f, 0x11}, content),
+			cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x0f, Page: 1, Pages: 1,
+				Content: content},
+			nil},
+		{"multi",
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x01, 0x23}, content),
+			cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x01, Page: 2, Pages: 3,
+				Content: content},
+			nil},
+		{"zero page parameter",
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x0f, 0x00}, content),
+			cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x0f, Page: 1, Pages: 1,
+				Content: content},
+			nil},
+		{"invalid page parameter",
+			page([]byte{0x40, 0x51, 0x11, 0x12, 0x0f, 0x32}, content),
+			cbs.Page{},
+			cbs.ErrInvalidPageParameter},
+		{"underflow",
+			make([]byte, cbs.PageLength-1),
+			cbs.Page{},
+			cbs.ErrUnderflow},
+		{"overlength",
+			make([]byte, cbs.PageLength+1),
+			cbs.Page{},
+			cbs.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pg := cbs.Page{}
+			err := pg.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, pg)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPageRoundTrip(t *testing.T) {
+	in := cbs.Page{SerialNumber: 0x4051, MessageID: 0x1112, DCS: tpdu.CBSDCS(0x48),
+		Page: 4, Pages: 15, Content: bytes.Repeat([]byte{0x12}, cbs.ContentLength)}
+	b, err := in.MarshalBinary()
+	assert.Nil(t, err)
+	out := cbs.Page{}
+	err = out.UnmarshalBinary(b)
+	assert.Nil(t, err)
+	assert.Equal(t, in, out)
+}
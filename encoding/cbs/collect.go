@@ -0,0 +1,69 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs
+
+import (
+	"sync"
+)
+
+// Collector buffers the pages of multi-page CBS messages until all the pages
+// of a message are available.
+//
+// As CBS messages are broadcast repeatedly, pages that duplicate those
+// already collected are ignored.  A page with a different update number
+// from those already collected replaces the collected pages, as the message
+// content has changed.
+//
+// Incomplete messages are retained until completed or replaced.
+type Collector struct {
+	sync.Mutex // covers pipes
+	pipes      map[uint32]*pipe
+}
+
+// NewCollector creates a Collector.
+func NewCollector() *Collector {
+	return &Collector{pipes: make(map[uint32]*pipe)}
+}
+
+// Collect adds a page to the collection.
+// If all the pages of the message are available then they are returned,
+// ordered by page number.
+func (c *Collector) Collect(p *Page) ([]*Page, error) {
+	if p.Pages < 1 || p.Pages > MaxPages || p.Page < 1 || p.Page > p.Pages {
+		return nil, ErrInvalidPageParameter
+	}
+	if p.Pages == 1 {
+		// short circuit single page - no need for a pipe
+		return []*Page{p}, nil
+	}
+	// the key excludes the update number, so updates replace the pipe.
+	key := uint32(p.MessageID)<<16 | uint32(p.SerialNumber&^0xf)
+	c.Lock()
+	defer c.Unlock()
+	pp, ok := c.pipes[key]
+	if !ok || pp.update != p.SerialNumber.UpdateNumber() || len(pp.pages) != p.Pages {
+		pp = &pipe{update: p.SerialNumber.UpdateNumber(), pages: make([]*Page, p.Pages)}
+		c.pipes[key] = pp
+	}
+	if pp.pages[p.Page-1] != nil {
+		return nil, nil
+	}
+	pp.pages[p.Page-1] = p
+	pp.count++
+	if pp.count < p.Pages {
+		return nil, nil
+	}
+	delete(c.pipes, key)
+	return pp.pages, nil
+}
+
+// pipe is a buffer that contains the pages of a CBS message until the
+// complete set is available.
+type pipe struct {
+	update int
+	pages  []*Page
+	count  int
+}
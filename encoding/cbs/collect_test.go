@@ -0,0 +1,61 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+)
+
+func TestCollect(t *testing.T) {
+	sn := cbs.NewSerialNumber(cbs.GSPLMN, 5, 1)
+	p1 := &cbs.Page{SerialNumber: sn, MessageID: 0x1112, Page: 1, Pages: 3}
+	p2 := &cbs.Page{SerialNumber: sn, MessageID: 0x1112, Page: 2, Pages: 3}
+	p3 := &cbs.Page{SerialNumber: sn, MessageID: 0x1112, Page: 3, Pages: 3}
+	u1 := &cbs.Page{SerialNumber: sn + 1, MessageID: 0x1112, Page: 1, Pages: 3}
+	o2 := &cbs.Page{SerialNumber: sn, MessageID: 0x1113, Page: 2, Pages: 3}
+	single := &cbs.Page{SerialNumber: sn, MessageID: 0x1112, Page: 1, Pages: 1}
+	patterns := []struct {
+		name string
+		in   []*cbs.Page
+		out  []*cbs.Page
+	}{
+		{"single", []*cbs.Page{single}, []*cbs.Page{single}},
+		{"ordered", []*cbs.Page{p1, p2, p3}, []*cbs.Page{p1, p2, p3}},
+		{"reordered", []*cbs.Page{p3, p1, p2}, []*cbs.Page{p1, p2, p3}},
+		{"duplicate", []*cbs.Page{p1, p1, p2, p2, p3}, []*cbs.Page{p1, p2, p3}},
+		{"interleaved", []*cbs.Page{p1, o2, p2, p3}, []*cbs.Page{p1, p2, p3}},
+		{"updated", []*cbs.Page{p2, p3, u1, p1, p2, p3}, []*cbs.Page{p1, p2, p3}},
+		{"incomplete", []*cbs.Page{p1, p2}, nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := cbs.NewCollector()
+			var out []*cbs.Page
+			for _, pg := range p.in {
+				pages, err := c.Collect(pg)
+				assert.Nil(t, err)
+				if pages != nil {
+					assert.Nil(t, out, "completed more than once")
+					out = pages
+				}
+			}
+			assert.Equal(t, p.out, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCollectInvalid(t *testing.T) {
+	c := cbs.NewCollector()
+	for _, pg := range []*cbs.Page{{}, {Page: 2, Pages: 1}, {Page: 1, Pages: 16}} {
+		pages, err := c.Collect(pg)
+		assert.Equal(t, cbs.ErrInvalidPageParameter, err)
+		assert.Nil(t, pages)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs
+
+import (
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+const (
+	// cr is the character separating a GSM7 language prefix from the text,
+	// and used to pad text content.
+	cr = 0x0d
+
+	// langOctets is the number of octets containing the language prefix of
+	// UCS2 content.
+	langOctets = 2
+)
+
+// LanguageCoding returns the CBS DCS, and any language prefix, for content
+// in the language lang, an ISO 639-1 code, encoded using the alphabet,
+// which must be either Alpha7Bit or AlphaUCS2.
+//
+// The CBS DCS is shared with USSD strings, so this also applies to them.
+//
+// If the language has a CBS language group, and the content is GSM7, then
+// the language is indicated by the DCS and there is no prefix.
+// Otherwise the language is indicated by a prefix which must be placed at
+// the start of the content.
+// For GSM7 the prefix is the unpacked septets of the language followed by a
+// CR, and for UCS2 the prefix is the language packed into 2 octets.
+//
+// An empty lang indicates the language is unspecified.
+func LanguageCoding(lang string, alpha tpdu.Alphabet) (tpdu.CBSDCS, []byte, error) {
+	var l []byte
+	if lang != "" {
+		var err error
+		l, err = encodeLanguage(lang)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	if alpha == tpdu.Alpha7Bit {
+		dc := tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: lang}
+		if dcs, err := dc.CBSDCS(); err == nil {
+			return dcs, nil, nil
+		}
+		return 0x10, append(l, cr), nil
+	}
+	dc := tpdu.DataCoding{Group: tpdu.GroupGeneral, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}
+	var prefix []byte
+	if l != nil {
+		dc.Group = tpdu.GroupLanguagePrefixed
+		prefix = gsm7.Pack7Bit(l, 0)
+	}
+	dcs, err := dc.CBSDCS()
+	if err != nil {
+		return 0, nil, err
+	}
+	return dcs, prefix, nil
+}
+
+// LanguagePrefix decodes the language prefix at the start of the content of
+// a message coded with a DCS from the GroupLanguagePrefixed coding group.
+//
+// For GSM7 the content must be unpacked septets, while for UCS2 the content
+// is the raw octets.
+//
+// Returns the language and the length of the prefix, which must be skipped
+// to reach the text.
+func LanguagePrefix(alpha tpdu.Alphabet, c []byte) (string, int, error) {
+	d := gsm7.NewDecoder()
+	if alpha == tpdu.AlphaUCS2 {
+		if len(c) < langOctets {
+			return "", 0, ErrUnderflow
+		}
+		l, err := d.Decode(gsm7.Unpack7Bit(c[:langOctets], 0)[:2])
+		if err != nil {
+			return "", 0, ErrInvalidLanguagePrefix
+		}
+		return string(l), langOctets, nil
+	}
+	if len(c) < 3 || c[2] != cr {
+		return "", 0, ErrInvalidLanguagePrefix
+	}
+	l, err := d.Decode(c[:2])
+	if err != nil {
+		return "", 0, ErrInvalidLanguagePrefix
+	}
+	return string(l), 3, nil
+}
+
+// encodeLanguage returns the language as two GSM7 septets.
+func encodeLanguage(lang string) ([]byte, error) {
+	e := gsm7.NewEncoder().WithExtCharset(nil)
+	l, err := e.Encode([]byte(lang))
+	if err != nil || len(l) != 2 {
+		return nil, ErrUnsupportedLanguage
+	}
+	return l, nil
+}
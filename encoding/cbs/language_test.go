@@ -0,0 +1,67 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestLanguageCoding(t *testing.T) {
+	patterns := []struct {
+		name   string
+		lang   string
+		alpha  tpdu.Alphabet
+		dcs    tpdu.CBSDCS
+		prefix []byte
+		err    error
+	}{
+		{"gsm7", "", tpdu.Alpha7Bit, 0x0f, nil, nil},
+		{"gsm7 language", "en", tpdu.Alpha7Bit, 0x01, nil, nil},
+		{"gsm7 prefixed", "ja", tpdu.Alpha7Bit, 0x10, []byte{'j', 'a', 0x0d}, nil},
+		{"ucs2", "", tpdu.AlphaUCS2, 0x48, nil, nil},
+		{"ucs2 prefixed", "ru", tpdu.AlphaUCS2, 0x11, []byte{0xf2, 0x3a}, nil},
+		{"language length", "eng", tpdu.Alpha7Bit, 0, nil, cbs.ErrUnsupportedLanguage},
+		{"language charset", "ру", tpdu.AlphaUCS2, 0, nil, cbs.ErrUnsupportedLanguage},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			dcs, prefix, err := cbs.LanguageCoding(p.lang, p.alpha)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.dcs, dcs)
+			assert.Equal(t, p.prefix, prefix)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestLanguagePrefix(t *testing.T) {
+	patterns := []struct {
+		name  string
+		alpha tpdu.Alphabet
+		in    []byte
+		lang  string
+		n     int
+		err   error
+	}{
+		{"gsm7", tpdu.Alpha7Bit, []byte{'j', 'a', 0x0d, 'H', 'i'}, "ja", 3, nil},
+		{"gsm7 missing cr", tpdu.Alpha7Bit, []byte{'H', 'i'}, "", 0, cbs.ErrInvalidLanguagePrefix},
+		{"ucs2", tpdu.AlphaUCS2, []byte{0xf2, 0x3a, 0x04, 0x1f}, "ru", 2, nil},
+		{"ucs2 underflow", tpdu.AlphaUCS2, []byte{0xf2}, "", 0, cbs.ErrUnderflow},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			lang, n, err := cbs.LanguagePrefix(p.alpha, p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.lang, lang)
+			assert.Equal(t, p.n, n)
+		}
+		t.Run(p.name, f)
+	}
+}
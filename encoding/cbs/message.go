@@ -0,0 +1,260 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs
+
+import (
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/encoding/ucs2"
+)
+
+// pageSeptets is the number of GSM7 septets in the content of a page.
+const pageSeptets = ContentLength * 8 / 7
+
+// Message is a CBS message, reassembled from its pages.
+type Message struct {
+	SerialNumber SerialNumber
+	MessageID    MessageID
+	DCS          tpdu.CBSDCS
+
+	// Language is the ISO 639-1 code for the language of the message,
+	// as indicated by either the DCS or the start of the content.
+	// An empty Language indicates the language is unspecified.
+	Language string
+
+	// UDH is the user data header from the first page, if the DCS indicates
+	// the pages contain a UDH.
+	UDH tpdu.UserDataHeader
+
+	// Text is the content of the message.
+	// For GSM7 and UCS2 the content is converted to UTF-8, with any padding
+	// removed.
+	// For 8bit the content is the raw octets of the pages.
+	Text []byte
+}
+
+// Decode reassembles the message from the pages provided, which must be
+// ordered by page number, and converts the content to UTF-8.
+func Decode(pages []*Page) (*Message, error) {
+	if len(pages) == 0 {
+		return nil, ErrUnderflow
+	}
+	p := pages[0]
+	m := Message{SerialNumber: p.SerialNumber, MessageID: p.MessageID, DCS: p.DCS}
+	dc := p.DCS.DataCoding()
+	if dc.Compressed {
+		return nil, ErrCompressed
+	}
+	m.Language = dc.Language
+	d := gsm7.NewDecoder()
+	for i, p := range pages {
+		c := p.Content
+		fillBits := 0
+		if dc.Group == tpdu.GroupUDH {
+			var udh tpdu.UserDataHeader
+			l, err := udh.UnmarshalBinary(c)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				m.UDH = udh
+			}
+			c = c[l:]
+			if dangling := l % 7; dangling != 0 {
+				fillBits = 7 - dangling
+			}
+		}
+		prefixed := i == 0 && dc.Group == tpdu.GroupLanguagePrefixed
+		switch dc.Alphabet {
+		case tpdu.Alpha8Bit:
+			m.Text = append(m.Text, c...)
+		case tpdu.AlphaUCS2:
+			if prefixed {
+				l, n, err := LanguagePrefix(dc.Alphabet, c)
+				if err != nil {
+					return nil, err
+				}
+				m.Language = l
+				c = c[n:]
+			}
+			t, err := ucs2.Decode(c[:len(c)&^0x1])
+			if err != nil {
+				return nil, err
+			}
+			m.Text = append(m.Text, string(trimUCS2Padding(t))...)
+		default:
+			s := gsm7.Unpack7Bit(c, fillBits)
+			if len(s) > pageSeptets {
+				s = s[:pageSeptets]
+			}
+			if prefixed {
+				if l, n, err := LanguagePrefix(dc.Alphabet, s); err == nil {
+					m.Language = l
+					s = s[n:]
+				}
+			}
+			for len(s) > 0 && s[len(s)-1] == cr {
+				s = s[:len(s)-1]
+			}
+			t, err := d.Decode(s)
+			if err != nil {
+				return nil, err
+			}
+			m.Text = append(m.Text, t...)
+		}
+	}
+	return &m, nil
+}
+
+// trimUCS2Padding removes any trailing CR or NUL padding.
+func trimUCS2Padding(t []rune) []rune {
+	for len(t) > 0 && (t[len(t)-1] == cr || t[len(t)-1] == 0) {
+		t = t[:len(t)-1]
+	}
+	return t
+}
+
+// encoder contains the configuration for Encode.
+type encoder struct {
+	sn   SerialNumber
+	mid  MessageID
+	lang string
+}
+
+// EncodeOption modifies the behaviour of Encode.
+type EncodeOption func(*encoder)
+
+// WithSerialNumber sets the serial number of the encoded pages.
+func WithSerialNumber(sn SerialNumber) EncodeOption {
+	return func(e *encoder) {
+		e.sn = sn
+	}
+}
+
+// WithMessageID sets the message identifier of the encoded pages.
+func WithMessageID(mid MessageID) EncodeOption {
+	return func(e *encoder) {
+		e.mid = mid
+	}
+}
+
+// WithLanguage sets the language of the message, as an ISO 639-1 code.
+//
+// If the language has a CBS language group then the language is indicated
+// by the DCS, else the language is indicated by a prefix at the start of the
+// content.
+func WithLanguage(lang string) EncodeOption {
+	return func(e *encoder) {
+		e.lang = lang
+	}
+}
+
+// Encode converts the UTF-8 message into the pages of a CBS message.
+//
+// The message is encoded using the GSM7 default alphabet, if possible, else
+// UCS2.  Long messages are split into up to 15 pages, with the content of
+// each page padded with CR characters.
+func Encode(msg []byte, opts ...EncodeOption) ([]*Page, error) {
+	e := encoder{}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	var contents [][]byte
+	var dcs tpdu.CBSDCS
+	ge := gsm7.NewEncoder()
+	if s, err := ge.Encode(msg); err == nil {
+		var prefix []byte
+		if dcs, prefix, err = LanguageCoding(e.lang, tpdu.Alpha7Bit); err != nil {
+			return nil, err
+		}
+		contents = paginateGSM7(append(prefix, s...))
+	} else {
+		var prefix []byte
+		if dcs, prefix, err = LanguageCoding(e.lang, tpdu.AlphaUCS2); err != nil {
+			return nil, err
+		}
+		contents = paginateUCS2([]rune(string(msg)), prefix)
+	}
+	if len(contents) > MaxPages {
+		return nil, ErrOverlength
+	}
+	pages := make([]*Page, len(contents))
+	for i, c := range contents {
+		pages[i] = &Page{
+			SerialNumber: e.sn,
+			MessageID:    e.mid,
+			DCS:          dcs,
+			Page:         i + 1,
+			Pages:        len(contents),
+			Content:      c,
+		}
+	}
+	return pages, nil
+}
+
+// paginateGSM7 splits the septets into the packed content of pages.
+// Escape sequences are not split across pages.
+func paginateGSM7(s []byte) [][]byte {
+	var contents [][]byte
+	for {
+		n := 0
+		for n < len(s) {
+			l := 1
+			if s[n] == 0x1b {
+				l = 2
+			}
+			if n+l > pageSeptets {
+				break
+			}
+			n += l
+		}
+		page := make([]byte, pageSeptets)
+		copy(page, s[:n])
+		for i := n; i < pageSeptets; i++ {
+			page[i] = cr
+		}
+		contents = append(contents, gsm7.Pack7Bit(page, 0))
+		s = s[n:]
+		if len(s) == 0 {
+			return contents
+		}
+	}
+}
+
+// paginateUCS2 splits the runes into the UCS2 content of pages, prefixing
+// the first page with the language prefix, if provided.
+// Surrogate pairs are not split across pages.
+func paginateUCS2(r []rune, prefix []byte) [][]byte {
+	var contents [][]byte
+	for {
+		var page []byte
+		if len(contents) == 0 {
+			page = append(page, prefix...)
+		}
+		units := (ContentLength - len(page)) / 2
+		n := 0
+		for n < len(r) {
+			l := 1
+			if r[n] > 0xffff { // requires a surrogate pair
+				l = 2
+			}
+			if l > units {
+				break
+			}
+			units -= l
+			n++
+		}
+		page = append(page, ucs2.Encode(r[:n])...)
+		for len(page) < ContentLength {
+			page = append(page, 0, cr)
+		}
+		contents = append(contents, page)
+		r = r[n:]
+		if len(r) == 0 {
+			return contents
+		}
+	}
+}
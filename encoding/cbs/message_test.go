@@ -0,0 +1,162 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package cbs_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestDecode(t *testing.T) {
+	hello, _ := hex.DecodeString("405111120f11c8329bfd6e341a8d46a3d168341a8d46a3d168341a8d46" +
+		"a3d168341a8d46a3d168341a8d46a3d168341a8d46a3d168341a8d46a3d168341a8d46a3d168341a8d46" +
+		"a3d168341a8d46a3d168341a8d46a3d100")
+	ucs2, _ := hex.DecodeString("000000001111f23a041f04400438043204350442000d000d000d000d" +
+		"000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d000d" +
+		"000d000d000d000d000d000d000d000d000d")
+	udh := []byte{0x05, 0x00, 0x03, 0x01, 0x02, 0x01}
+	hi := append([]byte("Hi"), bytes.Repeat([]byte{0x0d}, 84)...)
+	udhContent := append(udh, gsm7.Pack7Bit(hi, 1)...)
+	patterns := []struct {
+		name  string
+		in    [][]byte
+		pages []cbs.Page
+		out   *cbs.Message
+		err   error
+	}{
+		{"gsm7",
+			[][]byte{hello},
+			nil,
+			&cbs.Message{SerialNumber: 0x4051, MessageID: 0x1112, DCS: 0x0f,
+				Text: []byte("Hello")},
+			nil},
+		{"ucs2 prefixed",
+			[][]byte{ucs2},
+			nil,
+			&cbs.Message{DCS: 0x11, Language: "ru", Text: []byte("Привет")},
+			nil},
+		{"udh",
+			nil,
+			[]cbs.Page{{DCS: 0x91, Page: 1, Pages: 1, Content: udhContent}},
+			&cbs.Message{DCS: 0x91, Text: []byte("Hi"),
+				UDH: tpdu.UserDataHeader{{ID: 0, Data: []byte{1, 2, 1}}}},
+			nil},
+		{"8bit",
+			nil,
+			[]cbs.Page{
+				{DCS: 0x44, Page: 1, Pages: 2, Content: []byte{1, 2, 3}},
+				{DCS: 0x44, Page: 2, Pages: 2, Content: []byte{4, 5}}},
+			&cbs.Message{DCS: 0x44, Text: []byte{1, 2, 3, 4, 5}},
+			nil},
+		{"compressed",
+			nil,
+			[]cbs.Page{{DCS: 0x60, Page: 1, Pages: 1}},
+			nil,
+			cbs.ErrCompressed},
+		{"empty",
+			nil,
+			nil,
+			nil,
+			cbs.ErrUnderflow},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			var pages []*cbs.Page
+			for _, b := range p.in {
+				pg := &cbs.Page{}
+				err := pg.UnmarshalBinary(b)
+				require.Nil(t, err)
+				pages = append(pages, pg)
+			}
+			for i := range p.pages {
+				pages = append(pages, &p.pages[i])
+			}
+			m, err := cbs.Decode(pages)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, m)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	patterns := []struct {
+		name  string
+		in    string
+		opts  []cbs.EncodeOption
+		dcs   tpdu.CBSDCS
+		pages int
+		lang  string
+	}{
+		{"gsm7", "Hello", nil, 0x0f, 1, ""},
+		{"gsm7 language", "Hello", []cbs.EncodeOption{cbs.WithLanguage("en")}, 0x01, 1, "en"},
+		{"gsm7 prefixed", "Hi", []cbs.EncodeOption{cbs.WithLanguage("ja")}, 0x10, 1, "ja"},
+		{"gsm7 empty", "", nil, 0x0f, 1, ""},
+		{"gsm7 full", strings.Repeat("a", 93), nil, 0x0f, 1, ""},
+		{"gsm7 multi", strings.Repeat("a", 200), nil, 0x0f, 3, ""},
+		{"gsm7 escape", strings.Repeat("a", 92) + "€", nil, 0x0f, 2, ""},
+		{"gsm7 max", strings.Repeat("a", 15*93), nil, 0x0f, 15, ""},
+		{"ucs2", "Привет", nil, 0x48, 1, ""},
+		{"ucs2 prefixed", "Привет", []cbs.EncodeOption{cbs.WithLanguage("ru")}, 0x11, 1, "ru"},
+		{"ucs2 multi", strings.Repeat("Ж", 42), nil, 0x48, 2, ""},
+		{"ucs2 surrogate", strings.Repeat("Ж", 40) + "😀", nil, 0x48, 2, ""},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			opts := append([]cbs.EncodeOption{
+				cbs.WithMessageID(0x1112),
+				cbs.WithSerialNumber(0x4051)}, p.opts...)
+			pages, err := cbs.Encode([]byte(p.in), opts...)
+			require.Nil(t, err)
+			require.Equal(t, p.pages, len(pages))
+			for i, pg := range pages {
+				assert.Equal(t, p.dcs, pg.DCS)
+				assert.Equal(t, i+1, pg.Page)
+				assert.Equal(t, p.pages, pg.Pages)
+				assert.Equal(t, cbs.ContentLength, len(pg.Content))
+			}
+			m, err := cbs.Decode(pages)
+			require.Nil(t, err)
+			assert.Equal(t, p.in, string(m.Text))
+			assert.Equal(t, p.lang, m.Language)
+			assert.Equal(t, cbs.MessageID(0x1112), m.MessageID)
+			assert.Equal(t, cbs.SerialNumber(0x4051), m.SerialNumber)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		opts []cbs.EncodeOption
+		err  error
+	}{
+		{"gsm7 overlength", strings.Repeat("a", 15*93+1), nil, cbs.ErrOverlength},
+		{"ucs2 overlength", strings.Repeat("Ж", 15*41+1), nil, cbs.ErrOverlength},
+		{"language length", "Hello", []cbs.EncodeOption{cbs.WithLanguage("eng")},
+			cbs.ErrUnsupportedLanguage},
+		{"language charset", "Hello", []cbs.EncodeOption{cbs.WithLanguage("ру")},
+			cbs.ErrUnsupportedLanguage},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pages, err := cbs.Encode([]byte(p.in), p.opts...)
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, pages)
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pws
+
+import (
+	"encoding/binary"
+
+	"github.com/warthog618/sms/encoding/cbs"
+)
+
+const (
+	// alertMask is the Emergency User Alert bit of the Serial Number.
+	alertMask cbs.SerialNumber = 0x2000
+	// popupMask is the Popup bit of the Serial Number.
+	popupMask cbs.SerialNumber = 0x1000
+
+	// warningTypeLength is the length of the Warning Type.
+	warningTypeLength = 2
+
+	// SecurityInfoLength is the length of the Warning Security Information
+	// in an ETWS Primary Notification.
+	SecurityInfoLength = 50
+)
+
+// SerialNumberFlags returns the Emergency User Alert and Popup flags from
+// the Serial Number of an ETWS message.
+//
+// The alert flag indicates the MS should activate an emergency user alert,
+// such as a sound or vibration, and the popup flag indicates the message
+// should be displayed immediately.
+func SerialNumberFlags(sn cbs.SerialNumber) (alert, popup bool) {
+	return sn&alertMask != 0, sn&popupMask != 0
+}
+
+// WarningType is the Warning Type of an ETWS Primary Notification, as
+// defined in 3GPP TS 23.041 Section 9.3.24.
+type WarningType struct {
+	Type ETWSType
+
+	// EmergencyUserAlert indicates the MS should activate an emergency user
+	// alert, such as a sound or vibration.
+	EmergencyUserAlert bool
+
+	// Popup indicates the MS should display the notification immediately.
+	Popup bool
+}
+
+// MarshalBinary marshals the WarningType.
+func (w *WarningType) MarshalBinary() ([]byte, error) {
+	if w.Type < 0 || w.Type > 0x7f {
+		return nil, ErrInvalid
+	}
+	b := []byte{byte(w.Type) << 1, 0}
+	if w.EmergencyUserAlert {
+		b[0] |= 0x01
+	}
+	if w.Popup {
+		b[1] |= 0x80
+	}
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals the WarningType.
+func (w *WarningType) UnmarshalBinary(src []byte) error {
+	if len(src) < warningTypeLength {
+		return ErrUnderflow
+	}
+	w.Type = ETWSType(src[0] >> 1)
+	w.EmergencyUserAlert = src[0]&0x01 != 0
+	w.Popup = src[1]&0x80 != 0
+	return nil
+}
+
+// PrimaryNotification is an ETWS Primary Notification, as broadcast in GSM
+// and defined in 3GPP TS 23.041 Section 9.4.1.3.
+//
+// The same fields are carried in LTE SIB10 and NR SIB6.
+type PrimaryNotification struct {
+	SerialNumber cbs.SerialNumber
+	MessageID    cbs.MessageID
+	WarningType  WarningType
+
+	// SecurityInfo is the Warning Security Information, which may be empty.
+	SecurityInfo []byte
+}
+
+// Classification returns the Classification of the notification, as
+// determined by its message identifier.
+func (p *PrimaryNotification) Classification() (Classification, bool) {
+	return Classify(p.MessageID)
+}
+
+// MarshalBinary marshals the PrimaryNotification.
+func (p *PrimaryNotification) MarshalBinary() ([]byte, error) {
+	if len(p.SecurityInfo) != 0 && len(p.SecurityInfo) != SecurityInfoLength {
+		return nil, ErrInvalid
+	}
+	wt, err := p.WarningType.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 4, 6+len(p.SecurityInfo))
+	binary.BigEndian.PutUint16(b, uint16(p.SerialNumber))
+	binary.BigEndian.PutUint16(b[2:], uint16(p.MessageID))
+	b = append(b, wt...)
+	b = append(b, p.SecurityInfo...)
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals the PrimaryNotification, which may or may not
+// contain the Warning Security Information.
+func (p *PrimaryNotification) UnmarshalBinary(src []byte) error {
+	switch {
+	case len(src) < 6:
+		return ErrUnderflow
+	case len(src) != 6 && len(src) != 6+SecurityInfoLength:
+		return ErrInvalid
+	}
+	n := PrimaryNotification{
+		SerialNumber: cbs.SerialNumber(binary.BigEndian.Uint16(src)),
+		MessageID:    cbs.MessageID(binary.BigEndian.Uint16(src[2:])),
+	}
+	if err := n.WarningType.UnmarshalBinary(src[4:]); err != nil {
+		return err
+	}
+	if len(src) > 6 {
+		n.SecurityInfo = append([]byte(nil), src[6:]...)
+	}
+	*p = n
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pws_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/pws"
+)
+
+func TestSerialNumberFlags(t *testing.T) {
+	patterns := []struct {
+		name  string
+		in    cbs.SerialNumber
+		alert bool
+		popup bool
+	}{
+		{"none", 0x0000, false, false},
+		{"alert", 0x2000, true, false},
+		{"popup", 0x1000, false, true},
+		{"both", 0x3000, true, true},
+		{"other", 0xcfff, false, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			alert, popup := pws.SerialNumberFlags(p.in)
+			assert.Equal(t, p.alert, alert)
+			assert.Equal(t, p.popup, popup)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestWarningType(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   pws.WarningType
+		out  []byte
+	}{
+		{"earthquake", pws.WarningType{Type: pws.ETWSEarthquake}, []byte{0x00, 0x00}},
+		{"tsunami alert", pws.WarningType{Type: pws.ETWSTsunami, EmergencyUserAlert: true},
+			[]byte{0x03, 0x00}},
+		{"test popup", pws.WarningType{Type: pws.ETWSTest, Popup: true}, []byte{0x06, 0x80}},
+		{"other both", pws.WarningType{Type: pws.ETWSOther, EmergencyUserAlert: true, Popup: true},
+			[]byte{0x09, 0x80}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, b)
+			w := pws.WarningType{}
+			err = w.UnmarshalBinary(b)
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, w)
+		}
+		t.Run(p.name, f)
+	}
+	w := pws.WarningType{Type: 0x80}
+	b, err := w.MarshalBinary()
+	assert.Equal(t, pws.ErrInvalid, err)
+	assert.Nil(t, b)
+	err = w.UnmarshalBinary([]byte{0x01})
+	assert.Equal(t, pws.ErrUnderflow, err)
+}
+
+func TestPrimaryNotification(t *testing.T) {
+	sec := bytes.Repeat([]byte{0x5a}, pws.SecurityInfoLength)
+	patterns := []struct {
+		name string
+		in   []byte
+		out  pws.PrimaryNotification
+		err  error
+	}{
+		{"minimal",
+			[]byte{0x30, 0x10, 0x11, 0x02, 0x05, 0x80},
+			pws.PrimaryNotification{SerialNumber: 0x3010, MessageID: 0x1102,
+				WarningType: pws.WarningType{Type: pws.ETWSEarthquakeTsunami,
+					EmergencyUserAlert: true, Popup: true}},
+			nil},
+		{"security",
+			append([]byte{0x30, 0x10, 0x11, 0x00, 0x00, 0x00}, sec...),
+			pws.PrimaryNotification{SerialNumber: 0x3010, MessageID: 0x1100,
+				SecurityInfo: sec},
+			nil},
+		{"underflow",
+			[]byte{0x30, 0x10, 0x11, 0x00, 0x00},
+			pws.PrimaryNotification{},
+			pws.ErrUnderflow},
+		{"invalid length",
+			[]byte{0x30, 0x10, 0x11, 0x00, 0x00, 0x00, 0x01},
+			pws.PrimaryNotification{},
+			pws.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			n := pws.PrimaryNotification{}
+			err := n.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, n)
+			if err != nil {
+				return
+			}
+			b, err := n.MarshalBinary()
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, b)
+		}
+		t.Run(p.name, f)
+	}
+	n := pws.PrimaryNotification{MessageID: 0x1101}
+	c, ok := n.Classification()
+	assert.True(t, ok)
+	assert.Equal(t, pws.Classification{System: pws.SystemETWS, ETWS: pws.ETWSTsunami}, c)
+	n.SecurityInfo = []byte{1}
+	b, err := n.MarshalBinary()
+	assert.Equal(t, pws.ErrInvalid, err)
+	assert.Nil(t, b)
+	n = pws.PrimaryNotification{WarningType: pws.WarningType{Type: 0x80}}
+	b, err = n.MarshalBinary()
+	assert.Equal(t, pws.ErrInvalid, err)
+	assert.Nil(t, b)
+}
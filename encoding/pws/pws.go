@@ -0,0 +1,264 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package pws provides decoding of Public Warning System messages carried
+// over Cell Broadcast, as described in 3GPP TS 23.041.
+//
+// The warning systems supported are the Earthquake and Tsunami Warning
+// System (ETWS), and the Commercial Mobile Alert System (CMAS), also known
+// as Wireless Emergency Alerts (WEA), and the equivalent EU-Alert and
+// KPAS systems that share its message identifiers.
+package pws
+
+import (
+	"fmt"
+
+	"github.com/warthog618/sms/encoding/cbs"
+)
+
+// System identifies the warning system a message belongs to.
+type System int
+
+const (
+	// SystemNone indicates the message is not a warning message.
+	SystemNone System = iota
+	// SystemETWS indicates an ETWS message.
+	SystemETWS
+	// SystemCMAS indicates a CMAS message.
+	SystemCMAS
+)
+
+var systemNames = map[System]string{
+	SystemNone: "none",
+	SystemETWS: "etws",
+	SystemCMAS: "cmas",
+}
+
+func (s System) String() string {
+	if n, ok := systemNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("System(%d)", int(s))
+}
+
+// ETWSType is the type of an ETWS warning, as defined in 3GPP TS 23.041
+// Section 9.3.24.
+type ETWSType int
+
+const (
+	// ETWSEarthquake indicates an earthquake warning.
+	ETWSEarthquake ETWSType = iota
+	// ETWSTsunami indicates a tsunami warning.
+	ETWSTsunami
+	// ETWSEarthquakeTsunami indicates an earthquake and tsunami warning.
+	ETWSEarthquakeTsunami
+	// ETWSTest indicates a test message.
+	ETWSTest
+	// ETWSOther indicates some other emergency.
+	ETWSOther
+	// All other values are reserved for future extension.
+)
+
+var etwsTypeNames = map[ETWSType]string{
+	ETWSEarthquake:        "earthquake",
+	ETWSTsunami:           "tsunami",
+	ETWSEarthquakeTsunami: "earthquakeTsunami",
+	ETWSTest:              "test",
+	ETWSOther:             "other",
+}
+
+func (t ETWSType) String() string {
+	if s, ok := etwsTypeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("ETWSType(%d)", int(t))
+}
+
+// CMASCategory is the category of a CMAS alert.
+type CMASCategory int
+
+const (
+	// CMASPresidential is a Presidential Level Alert.
+	CMASPresidential CMASCategory = iota
+	// CMASExtreme is an Extreme Alert.
+	CMASExtreme
+	// CMASSevere is a Severe Alert.
+	CMASSevere
+	// CMASAmber is a Child Abduction Emergency, or AMBER, Alert.
+	CMASAmber
+	// CMASRequiredMonthlyTest is a Required Monthly Test.
+	CMASRequiredMonthlyTest
+	// CMASExercise is a CMAS Exercise.
+	CMASExercise
+	// CMASOperatorDefined is reserved for operator defined use.
+	CMASOperatorDefined
+	// CMASPublicSafety is a Public Safety Alert.
+	CMASPublicSafety
+	// CMASStateLocalTest is a State/Local WEA Test.
+	CMASStateLocalTest
+	// CMASGeoFencingTrigger is a geo-fencing trigger message.
+	CMASGeoFencingTrigger
+)
+
+var cmasCategoryNames = map[CMASCategory]string{
+	CMASPresidential:        "presidential",
+	CMASExtreme:             "extreme",
+	CMASSevere:              "severe",
+	CMASAmber:               "amber",
+	CMASRequiredMonthlyTest: "requiredMonthlyTest",
+	CMASExercise:            "exercise",
+	CMASOperatorDefined:     "operatorDefined",
+	CMASPublicSafety:        "publicSafety",
+	CMASStateLocalTest:      "stateLocalTest",
+	CMASGeoFencingTrigger:   "geoFencingTrigger",
+}
+
+func (c CMASCategory) String() string {
+	if s, ok := cmasCategoryNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("CMASCategory(%d)", int(c))
+}
+
+// Urgency is the urgency of an Extreme or Severe CMAS alert.
+type Urgency int
+
+const (
+	// UrgencyUnknown indicates the urgency is not specified.
+	UrgencyUnknown Urgency = iota
+	// UrgencyImmediate indicates responsive action should be taken
+	// immediately.
+	UrgencyImmediate
+	// UrgencyExpected indicates responsive action should be taken within
+	// the next hour.
+	UrgencyExpected
+)
+
+var urgencyNames = map[Urgency]string{
+	UrgencyUnknown:   "unknown",
+	UrgencyImmediate: "immediate",
+	UrgencyExpected:  "expected",
+}
+
+func (u Urgency) String() string {
+	if s, ok := urgencyNames[u]; ok {
+		return s
+	}
+	return fmt.Sprintf("Urgency(%d)", int(u))
+}
+
+// Certainty is the certainty of an Extreme or Severe CMAS alert.
+type Certainty int
+
+const (
+	// CertaintyUnknown indicates the certainty is not specified.
+	CertaintyUnknown Certainty = iota
+	// CertaintyObserved indicates the event is determined to have occurred
+	// or to be ongoing.
+	CertaintyObserved
+	// CertaintyLikely indicates the event is likely, with a probability
+	// greater than 50%.
+	CertaintyLikely
+)
+
+var certaintyNames = map[Certainty]string{
+	CertaintyUnknown:  "unknown",
+	CertaintyObserved: "observed",
+	CertaintyLikely:   "likely",
+}
+
+func (c Certainty) String() string {
+	if s, ok := certaintyNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("Certainty(%d)", int(c))
+}
+
+// Classification is the classification of a warning message, as determined
+// by its message identifier.
+//
+// The fields relevant to a particular Classification depend on the System.
+type Classification struct {
+	System System
+
+	// ETWS is the type of ETWS warning.
+	// Only relevant to SystemETWS.
+	ETWS ETWSType
+
+	// CMAS is the category of CMAS alert.
+	// Only relevant to SystemCMAS.
+	CMAS CMASCategory
+
+	// Urgency is the urgency of an Extreme or Severe CMAS alert.
+	Urgency Urgency
+
+	// Certainty is the certainty of an Extreme or Severe CMAS alert.
+	Certainty Certainty
+
+	// AdditionalLanguage indicates the CMAS alert is broadcast in a
+	// language other than the primary language of the network.
+	AdditionalLanguage bool
+}
+
+const (
+	etwsFirst cbs.MessageID = 0x1100
+	etwsLast  cbs.MessageID = 0x1107
+
+	cmasFirst cbs.MessageID = 0x1112
+	// cmasLanguageOffset is the offset from a CMAS message identifier to
+	// the identifier of the same alert in an additional language.
+	cmasLanguageOffset cbs.MessageID = 0x0d
+)
+
+// cmasAlerts maps the CMAS message identifiers that have an additional
+// language equivalent to their Classification.
+var cmasAlerts = map[cbs.MessageID]Classification{
+	0x1112: {System: SystemCMAS, CMAS: CMASPresidential},
+	0x1113: {System: SystemCMAS, CMAS: CMASExtreme, Urgency: UrgencyImmediate, Certainty: CertaintyObserved},
+	0x1114: {System: SystemCMAS, CMAS: CMASExtreme, Urgency: UrgencyImmediate, Certainty: CertaintyLikely},
+	0x1115: {System: SystemCMAS, CMAS: CMASExtreme, Urgency: UrgencyExpected, Certainty: CertaintyObserved},
+	0x1116: {System: SystemCMAS, CMAS: CMASExtreme, Urgency: UrgencyExpected, Certainty: CertaintyLikely},
+	0x1117: {System: SystemCMAS, CMAS: CMASSevere, Urgency: UrgencyImmediate, Certainty: CertaintyObserved},
+	0x1118: {System: SystemCMAS, CMAS: CMASSevere, Urgency: UrgencyImmediate, Certainty: CertaintyLikely},
+	0x1119: {System: SystemCMAS, CMAS: CMASSevere, Urgency: UrgencyExpected, Certainty: CertaintyObserved},
+	0x111a: {System: SystemCMAS, CMAS: CMASSevere, Urgency: UrgencyExpected, Certainty: CertaintyLikely},
+	0x111b: {System: SystemCMAS, CMAS: CMASAmber},
+	0x111c: {System: SystemCMAS, CMAS: CMASRequiredMonthlyTest},
+	0x111d: {System: SystemCMAS, CMAS: CMASExercise},
+	0x111e: {System: SystemCMAS, CMAS: CMASOperatorDefined},
+}
+
+// Classify returns the Classification of the message identifier.
+// Returns false if the message identifier is not that of a warning message.
+func Classify(mid cbs.MessageID) (Classification, bool) {
+	switch {
+	case mid >= etwsFirst && mid <= etwsLast:
+		return Classification{System: SystemETWS, ETWS: ETWSType(mid - etwsFirst)}, true
+	case mid >= cmasFirst && mid < cmasFirst+cmasLanguageOffset:
+		return cmasAlerts[mid], true
+	case mid >= cmasFirst+cmasLanguageOffset && mid < cmasFirst+2*cmasLanguageOffset:
+		c := cmasAlerts[mid-cmasLanguageOffset]
+		c.AdditionalLanguage = true
+		return c, true
+	}
+	c := Classification{System: SystemCMAS}
+	switch mid {
+	case 0x112c:
+		c.CMAS = CMASPublicSafety
+	case 0x112d:
+		c.CMAS = CMASPublicSafety
+		c.AdditionalLanguage = true
+	case 0x112e:
+		c.CMAS = CMASStateLocalTest
+	case 0x112f:
+		c.CMAS = CMASStateLocalTest
+		c.AdditionalLanguage = true
+	case 0x1130:
+		c.CMAS = CMASGeoFencingTrigger
+	default:
+		return Classification{}, false
+	}
+	return c, true
+}
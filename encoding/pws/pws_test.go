@@ -0,0 +1,120 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pws_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/pws"
+)
+
+func TestClassify(t *testing.T) {
+	etws := func(e pws.ETWSType) pws.Classification {
+		return pws.Classification{System: pws.SystemETWS, ETWS: e}
+	}
+	cmas := func(c pws.CMASCategory, lang bool) pws.Classification {
+		return pws.Classification{System: pws.SystemCMAS, CMAS: c, AdditionalLanguage: lang}
+	}
+	alert := func(c pws.CMASCategory, u pws.Urgency, ct pws.Certainty, lang bool) pws.Classification {
+		return pws.Classification{System: pws.SystemCMAS, CMAS: c, Urgency: u, Certainty: ct,
+			AdditionalLanguage: lang}
+	}
+	patterns := []struct {
+		in  cbs.MessageID
+		out pws.Classification
+		ok  bool
+	}{
+		{0x1100, etws(pws.ETWSEarthquake), true},
+		{0x1101, etws(pws.ETWSTsunami), true},
+		{0x1102, etws(pws.ETWSEarthquakeTsunami), true},
+		{0x1103, etws(pws.ETWSTest), true},
+		{0x1104, etws(pws.ETWSOther), true},
+		{0x1107, etws(pws.ETWSType(7)), true},
+		{0x1112, cmas(pws.CMASPresidential, false), true},
+		{0x1113, alert(pws.CMASExtreme, pws.UrgencyImmediate, pws.CertaintyObserved, false), true},
+		{0x1114, alert(pws.CMASExtreme, pws.UrgencyImmediate, pws.CertaintyLikely, false), true},
+		{0x1115, alert(pws.CMASExtreme, pws.UrgencyExpected, pws.CertaintyObserved, false), true},
+		{0x1116, alert(pws.CMASExtreme, pws.UrgencyExpected, pws.CertaintyLikely, false), true},
+		{0x1117, alert(pws.CMASSevere, pws.UrgencyImmediate, pws.CertaintyObserved, false), true},
+		{0x1118, alert(pws.CMASSevere, pws.UrgencyImmediate, pws.CertaintyLikely, false), true},
+		{0x1119, alert(pws.CMASSevere, pws.UrgencyExpected, pws.CertaintyObserved, false), true},
+		{0x111a, alert(pws.CMASSevere, pws.UrgencyExpected, pws.CertaintyLikely, false), true},
+		{0x111b, cmas(pws.CMASAmber, false), true},
+		{0x111c, cmas(pws.CMASRequiredMonthlyTest, false), true},
+		{0x111d, cmas(pws.CMASExercise, false), true},
+		{0x111e, cmas(pws.CMASOperatorDefined, false), true},
+		{0x111f, cmas(pws.CMASPresidential, true), true},
+		{0x1120, alert(pws.CMASExtreme, pws.UrgencyImmediate, pws.CertaintyObserved, true), true},
+		{0x1127, alert(pws.CMASSevere, pws.UrgencyExpected, pws.CertaintyLikely, true), true},
+		{0x1128, cmas(pws.CMASAmber, true), true},
+		{0x1129, cmas(pws.CMASRequiredMonthlyTest, true), true},
+		{0x112a, cmas(pws.CMASExercise, true), true},
+		{0x112b, cmas(pws.CMASOperatorDefined, true), true},
+		{0x112c, cmas(pws.CMASPublicSafety, false), true},
+		{0x112d, cmas(pws.CMASPublicSafety, true), true},
+		{0x112e, cmas(pws.CMASStateLocalTest, false), true},
+		{0x112f, cmas(pws.CMASStateLocalTest, true), true},
+		{0x1130, cmas(pws.CMASGeoFencingTrigger, false), true},
+		{0x0000, pws.Classification{}, false},
+		{0x1108, pws.Classification{}, false},
+		{0x1111, pws.Classification{}, false},
+		{0x1131, pws.Classification{}, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c, ok := pws.Classify(p.in)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.out, c)
+		}
+		t.Run(fmt.Sprintf("%04x", p.in), f)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	patterns := []struct {
+		in  fmt.Stringer
+		out string
+	}{
+		{pws.SystemNone, "none"},
+		{pws.SystemETWS, "etws"},
+		{pws.SystemCMAS, "cmas"},
+		{pws.System(5), "System(5)"},
+		{pws.ETWSEarthquake, "earthquake"},
+		{pws.ETWSTsunami, "tsunami"},
+		{pws.ETWSEarthquakeTsunami, "earthquakeTsunami"},
+		{pws.ETWSTest, "test"},
+		{pws.ETWSOther, "other"},
+		{pws.ETWSType(5), "ETWSType(5)"},
+		{pws.CMASPresidential, "presidential"},
+		{pws.CMASExtreme, "extreme"},
+		{pws.CMASSevere, "severe"},
+		{pws.CMASAmber, "amber"},
+		{pws.CMASRequiredMonthlyTest, "requiredMonthlyTest"},
+		{pws.CMASExercise, "exercise"},
+		{pws.CMASOperatorDefined, "operatorDefined"},
+		{pws.CMASPublicSafety, "publicSafety"},
+		{pws.CMASStateLocalTest, "stateLocalTest"},
+		{pws.CMASGeoFencingTrigger, "geoFencingTrigger"},
+		{pws.CMASCategory(42), "CMASCategory(42)"},
+		{pws.UrgencyUnknown, "unknown"},
+		{pws.UrgencyImmediate, "immediate"},
+		{pws.UrgencyExpected, "expected"},
+		{pws.Urgency(3), "Urgency(3)"},
+		{pws.CertaintyUnknown, "unknown"},
+		{pws.CertaintyObserved, "observed"},
+		{pws.CertaintyLikely, "likely"},
+		{pws.Certainty(3), "Certainty(3)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pws
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Warning is a decoded warning message, being an ETWS secondary
+// notification or a CMAS alert.
+type Warning struct {
+	Classification
+
+	SerialNumber cbs.SerialNumber
+	MessageID    cbs.MessageID
+
+	// EmergencyUserAlert indicates the MS should activate an emergency user
+	// alert, such as a sound or vibration.
+	// Only relevant to SystemETWS.
+	EmergencyUserAlert bool
+
+	// Popup indicates the MS should display the warning immediately.
+	// Only relevant to SystemETWS.
+	Popup bool
+
+	// Language is the ISO 639-1 code for the language of the warning, or
+	// empty if unspecified.
+	Language string
+
+	// Text is the content of the warning, converted to UTF-8.
+	Text string
+}
+
+// Decode decodes the warning from the pages of a CBS message, which must be
+// ordered by page number.
+//
+// Returns ErrNotWarning if the message identifier is not that of a warning
+// message.
+func Decode(pages []*cbs.Page) (*Warning, error) {
+	if len(pages) == 0 {
+		return nil, ErrUnderflow
+	}
+	c, ok := Classify(pages[0].MessageID)
+	if !ok {
+		return nil, ErrNotWarning
+	}
+	m, err := cbs.Decode(pages)
+	if err != nil {
+		return nil, err
+	}
+	w := Warning{
+		Classification: c,
+		SerialNumber:   m.SerialNumber,
+		MessageID:      m.MessageID,
+		Language:       m.Language,
+		Text:           string(m.Text),
+	}
+	if c.System == SystemETWS {
+		w.EmergencyUserAlert, w.Popup = SerialNumberFlags(m.SerialNumber)
+	}
+	return &w, nil
+}
+
+// DecodeCBData decodes the warning from the CB-Data of a UMTS, LTE or NR
+// CBS message, along with the serial number, message identifier and DCS that
+// are carried alongside it.
+func DecodeCBData(sn cbs.SerialNumber, mid cbs.MessageID, dcs tpdu.CBSDCS, data []byte) (*Warning, error) {
+	if _, ok := Classify(mid); !ok {
+		return nil, ErrNotWarning
+	}
+	pages, err := cbs.UnmarshalCBData(sn, mid, dcs, data)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(pages)
+}
+
+// SIBSegment is a segment of a warning message broadcast in a System
+// Information Block, being LTE SIB11 and SIB12, as defined in
+// 3GPP TS 36.331, or NR SIB7 and SIB8, as defined in 3GPP TS 38.331.
+type SIBSegment struct {
+	MessageID    cbs.MessageID
+	SerialNumber cbs.SerialNumber
+
+	// Number is the warningMessageSegmentNumber, starting at 0.
+	Number int
+
+	// Last indicates this is the last segment of the message.
+	Last bool
+
+	// DCS is the dataCodingScheme, which is only present in the first
+	// segment.
+	DCS tpdu.CBSDCS
+
+	// Data is the warningMessageSegment.
+	Data []byte
+}
+
+// maxSIBSegments is the maximum number of segments in a SIB warning message.
+const maxSIBSegments = 64
+
+// SIBCollector buffers the segments of SIB warning messages until all the
+// segments of a message are available, then decodes the warning from the
+// CB-Data contained in the segments.
+//
+// As warnings are broadcast repeatedly, segments that duplicate those
+// already collected are ignored.  Once a warning is returned its segments
+// are discarded, so detecting repeated broadcasts of a complete warning is
+// left to the caller.
+//
+// The segments of an incomplete message are discarded if no further
+// segment of the message is collected within the duration provided to
+// NewSIBCollector.
+type SIBCollector struct {
+	sync.Mutex // covers messages and closing closed
+	messages   map[uint32]*sibMessage
+	duration   time.Duration
+	closed     chan struct{}
+}
+
+// sibMessage contains the segments of a SIB warning message.
+type sibMessage struct {
+	cleanup  *time.Timer
+	segments [][]byte
+	last     int
+	dcs      tpdu.CBSDCS
+}
+
+// join returns the concatenated segments of the message.
+// Returns false if any of the segments are not yet available.
+func (m *sibMessage) join() ([]byte, bool) {
+	if m.last < 0 {
+		return nil, false
+	}
+	var data []byte
+	for _, seg := range m.segments[:m.last+1] {
+		if seg == nil {
+			return nil, false
+		}
+		data = append(data, seg...)
+	}
+	return data, true
+}
+
+// NewSIBCollector creates a SIBCollector.
+// The segments of an incomplete message expire after the duration d.
+func NewSIBCollector(d time.Duration) *SIBCollector {
+	return &SIBCollector{
+		messages: make(map[uint32]*sibMessage),
+		duration: d,
+		closed:   make(chan struct{}),
+	}
+}
+
+// Close shuts down the SIBCollector and discards any incomplete messages.
+func (c *SIBCollector) Close() {
+	c.Lock()
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+		for key, m := range c.messages {
+			m.cleanup.Stop()
+			delete(c.messages, key)
+		}
+	}
+	c.Unlock()
+}
+
+// Collect adds a segment to the collection.
+// If all the segments of the message are available then the decoded warning
+// is returned.
+func (c *SIBCollector) Collect(s SIBSegment) (*Warning, error) {
+	if s.Number < 0 || s.Number >= maxSIBSegments {
+		return nil, ErrInvalid
+	}
+	key := uint32(s.MessageID)<<16 | uint32(s.SerialNumber)
+	c.Lock()
+	select {
+	case <-c.closed:
+		c.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+	m, ok := c.messages[key]
+	if ok {
+		if m.segments[s.Number] != nil || (m.last >= 0 && s.Number > m.last) {
+			c.Unlock()
+			return nil, nil
+		}
+		if !m.cleanup.Stop() {
+			// timer has fired, but cleanup hasn't been performed yet - so
+			// need a new message
+			ok = false
+		}
+	}
+	if !ok {
+		m = &sibMessage{segments: make([][]byte, maxSIBSegments), last: -1}
+		c.messages[key] = m
+	}
+	m.segments[s.Number] = append([]byte{}, s.Data...)
+	if s.Number == 0 {
+		m.dcs = s.DCS
+	}
+	if s.Last {
+		m.last = s.Number
+	}
+	data, ok := m.join()
+	if !ok {
+		m.cleanup = time.AfterFunc(c.duration, func() {
+			c.Lock()
+			if c.messages[key] == m {
+				delete(c.messages, key)
+			}
+			c.Unlock()
+		})
+		c.Unlock()
+		return nil, nil
+	}
+	delete(c.messages, key)
+	c.Unlock()
+	return DecodeCBData(s.SerialNumber, s.MessageID, m.dcs, data)
+}
+
+var (
+	// ErrClosed indicates the SIBCollector has been closed and is no longer
+	// accepting segments.
+	ErrClosed = errors.New("pws: closed")
+
+	// ErrInvalid indicates a value is invalid.
+	ErrInvalid = errors.New("pws: invalid")
+
+	// ErrNotWarning indicates the message identifier is not that of a
+	// warning message.
+	ErrNotWarning = errors.New("pws: not a warning message")
+
+	// ErrUnderflow indicates the binary provided is too short.
+	ErrUnderflow = errors.New("pws: underflow")
+)
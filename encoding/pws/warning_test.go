@@ -0,0 +1,218 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package pws_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/pws"
+)
+
+func TestDecode(t *testing.T) {
+	long := strings.Repeat("Evacuate now. ", 10)
+	patterns := []struct {
+		name string
+		mid  cbs.MessageID
+		sn   cbs.SerialNumber
+		lang string
+		text string
+		out  *pws.Warning
+	}{
+		{"presidential", 0x1112, 0x4010, "en", "Presidential alert",
+			&pws.Warning{
+				Classification: pws.Classification{System: pws.SystemCMAS,
+					CMAS: pws.CMASPresidential},
+				SerialNumber: 0x4010,
+				MessageID:    0x1112,
+				Language:     "en",
+				Text:         "Presidential alert",
+			}},
+		{"extreme multipage", 0x1113, 0x4020, "", long,
+			&pws.Warning{
+				Classification: pws.Classification{System: pws.SystemCMAS,
+					CMAS: pws.CMASExtreme, Urgency: pws.UrgencyImmediate,
+					Certainty: pws.CertaintyObserved},
+				SerialNumber: 0x4020,
+				MessageID:    0x1113,
+				Text:         long,
+			}},
+		{"etws", 0x1101, 0x3011, "", "Tsunami",
+			&pws.Warning{
+				Classification:     pws.Classification{System: pws.SystemETWS, ETWS: pws.ETWSTsunami},
+				SerialNumber:       0x3011,
+				MessageID:          0x1101,
+				EmergencyUserAlert: true,
+				Popup:              true,
+				Text:               "Tsunami",
+			}},
+		{"etws no flags", 0x1103, 0x0011, "", "Test",
+			&pws.Warning{
+				Classification: pws.Classification{System: pws.SystemETWS, ETWS: pws.ETWSTest},
+				SerialNumber:   0x0011,
+				MessageID:      0x1103,
+				Text:           "Test",
+			}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			opts := []cbs.EncodeOption{cbs.WithMessageID(p.mid), cbs.WithSerialNumber(p.sn)}
+			if p.lang != "" {
+				opts = append(opts, cbs.WithLanguage(p.lang))
+			}
+			pages, err := cbs.Encode([]byte(p.text), opts...)
+			require.Nil(t, err)
+			w, err := pws.Decode(pages)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, w)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	w, err := pws.Decode(nil)
+	assert.Equal(t, pws.ErrUnderflow, err)
+	assert.Nil(t, w)
+	pages, err := cbs.Encode([]byte("Not a warning"), cbs.WithMessageID(0x1000))
+	require.Nil(t, err)
+	w, err = pws.Decode(pages)
+	assert.Equal(t, pws.ErrNotWarning, err)
+	assert.Nil(t, w)
+	pages[0].DCS = 0x60 // compressed
+	pages[0].MessageID = 0x1112
+	w, err = pws.Decode(pages)
+	assert.Equal(t, cbs.ErrCompressed, err)
+	assert.Nil(t, w)
+}
+
+func TestDecodeCBData(t *testing.T) {
+	text := "Severe weather warning"
+	pages, err := cbs.Encode([]byte(text), cbs.WithMessageID(0x111a),
+		cbs.WithSerialNumber(0x4030))
+	require.Nil(t, err)
+	data, err := cbs.MarshalCBData(pages)
+	require.Nil(t, err)
+	dcs := pages[0].DCS
+	w, err := pws.DecodeCBData(0x4030, 0x111a, dcs, data)
+	assert.Nil(t, err)
+	assert.Equal(t, &pws.Warning{
+		Classification: pws.Classification{System: pws.SystemCMAS, CMAS: pws.CMASSevere,
+			Urgency: pws.UrgencyExpected, Certainty: pws.CertaintyLikely},
+		SerialNumber: 0x4030,
+		MessageID:    0x111a,
+		Text:         text,
+	}, w)
+	w, err = pws.DecodeCBData(0x4030, 0x1000, dcs, data)
+	assert.Equal(t, pws.ErrNotWarning, err)
+	assert.Nil(t, w)
+	w, err = pws.DecodeCBData(0x4030, 0x111a, dcs, data[:10])
+	assert.Equal(t, cbs.ErrUnderflow, err)
+	assert.Nil(t, w)
+}
+
+func TestSIBCollector(t *testing.T) {
+	text := strings.Repeat("Amber alert. ", 12)
+	pages, err := cbs.Encode([]byte(text), cbs.WithMessageID(0x111b),
+		cbs.WithSerialNumber(0x4040))
+	require.Nil(t, err)
+	require.True(t, len(pages) > 1)
+	data, err := cbs.MarshalCBData(pages)
+	require.Nil(t, err)
+	dcs := pages[0].DCS
+	// split into 50 octet segments
+	var segs []pws.SIBSegment
+	for i := 0; len(data) > 0; i++ {
+		n := 50
+		if n > len(data) {
+			n = len(data)
+		}
+		s := pws.SIBSegment{MessageID: 0x111b, SerialNumber: 0x4040, Number: i,
+			Data: data[:n]}
+		if i == 0 {
+			s.DCS = dcs
+		}
+		data = data[n:]
+		s.Last = len(data) == 0
+		segs = append(segs, s)
+	}
+	require.True(t, len(segs) > 2)
+	expected := &pws.Warning{
+		Classification: pws.Classification{System: pws.SystemCMAS, CMAS: pws.CMASAmber},
+		SerialNumber:   0x4040,
+		MessageID:      0x111b,
+		Text:           text,
+	}
+
+	c := pws.NewSIBCollector(time.Minute)
+	// out of order, with duplicates
+	order := []int{len(segs) - 1, 1, len(segs) - 1, 1}
+	for i := 2; i < len(segs)-1; i++ {
+		order = append(order, i)
+	}
+	for _, i := range order {
+		w, err := c.Collect(segs[i])
+		assert.Nil(t, err)
+		assert.Nil(t, w)
+	}
+	w, err := c.Collect(segs[0])
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// segments beyond the last are ignored
+	c = pws.NewSIBCollector(time.Minute)
+	for _, s := range segs[:len(segs)-1] {
+		w, err = c.Collect(s)
+		assert.Nil(t, err)
+		assert.Nil(t, w)
+	}
+	stray := segs[0]
+	stray.Number = len(segs) + 1
+	w, err = c.Collect(stray)
+	assert.Nil(t, err)
+	assert.Nil(t, w)
+	w, err = c.Collect(segs[len(segs)-1])
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// invalid segment numbers
+	for _, n := range []int{-1, 64} {
+		s := segs[0]
+		s.Number = n
+		w, err = c.Collect(s)
+		assert.Equal(t, pws.ErrInvalid, err)
+		assert.Nil(t, w)
+	}
+
+	// incomplete messages expire
+	c = pws.NewSIBCollector(10 * time.Millisecond)
+	w, err = c.Collect(segs[0])
+	assert.Nil(t, err)
+	assert.Nil(t, w)
+	time.Sleep(50 * time.Millisecond)
+	for _, s := range segs[1:] {
+		w, err = c.Collect(s)
+		assert.Nil(t, err)
+		assert.Nil(t, w)
+	}
+	w, err = c.Collect(segs[0])
+	assert.Nil(t, err)
+	assert.Equal(t, expected, w)
+
+	// closed
+	w, err = c.Collect(segs[1])
+	assert.Nil(t, err)
+	assert.Nil(t, w)
+	c.Close()
+	w, err = c.Collect(segs[0])
+	assert.Equal(t, pws.ErrClosed, err)
+	assert.Nil(t, w)
+	c.Close()
+}
@@ -6,6 +6,8 @@
 package tpdu
 
 import (
+	"fmt"
+
 	"github.com/warthog618/sms/encoding/gsm7"
 	"github.com/warthog618/sms/encoding/semioctet"
 )
@@ -118,7 +120,7 @@ func (a *Address) SetNumberingPlan(np NumberingPlan) {
 
 // SetTypeOfNumber sets the TON field in the TOA.
 func (a *Address) SetTypeOfNumber(ton TypeOfNumber) {
-	a.TOA = (a.TOA &^ 0x30) | (byte(ton&0x3) << 4)
+	a.TOA = (a.TOA &^ 0x70) | (byte(ton&0x7) << 4)
 }
 
 // TypeOfNumber extracts the TON field from the TOA.
@@ -150,6 +152,24 @@ const (
 	TonExtension
 )
 
+var tonNames = map[TypeOfNumber]string{
+	TonUnknown:          "unknown",
+	TonInternational:    "international",
+	TonNational:         "national",
+	TonNetworkSpecific:  "networkSpecific",
+	TonSubscriberNumber: "subscriberNumber",
+	TonAlphanumeric:     "alphanumeric",
+	TonAbbreviated:      "abbreviated",
+	TonExtension:        "extension",
+}
+
+func (ton TypeOfNumber) String() string {
+	if s, ok := tonNames[ton]; ok {
+		return s
+	}
+	return fmt.Sprintf("TypeOfNumber(%d)", int(ton))
+}
+
 // NumberingPlan corresponds to bits 4,3,2,1 of the Address TOA field.
 // i.e. 1yyyxxxx
 // as defined in 3GPP TS 23.040 Section 9.1.2.5
@@ -180,3 +200,23 @@ const (
 	NpExtension = 0x0f
 	// all other values reserved.
 )
+
+var npiNames = map[NumberingPlan]string{
+	NpUnknown:     "unknown",
+	NpISDN:        "isdn",
+	NpData:        "data",
+	NpTelex:       "telex",
+	NpScSpecificA: "scSpecificA",
+	NpScSpecificB: "scSpecificB",
+	NpNational:    "national",
+	NpPrivate:     "private",
+	NpErmes:       "ermes",
+	NpExtension:   "extension",
+}
+
+func (np NumberingPlan) String() string {
+	if s, ok := npiNames[np]; ok {
+		return s
+	}
+	return fmt.Sprintf("NumberingPlan(%d)", int(np))
+}
@@ -122,13 +122,13 @@ func TestAddressNumberingPlan(t *testing.T) {
 }
 
 func TestAddressTypeOfNumber(t *testing.T) {
-	patterns := []tpdu.TypeOfNumber{0, 2, 3, 5}
+	patterns := []tpdu.TypeOfNumber{0, 2, 3, 5, 7, 9}
 	for _, p := range patterns {
 		f := func(t *testing.T) {
 			a := tpdu.NewAddress()
 			a.SetTypeOfNumber((p))
 			ton := a.TypeOfNumber()
-			if ton != p&0x3 {
+			if ton != p&0x7 {
 				t.Errorf("failed to set and get TypeOfNumber 0x%02x, got 0x%02x", p, ton)
 			}
 		}
@@ -5,6 +5,10 @@
 
 package tpdu
 
+import (
+	"fmt"
+)
+
 // Command represents an SMS Command TPDU as defined in 3GPP TS 23.040 Section 9.2.2.4.
 type Command struct {
 	TPDU
@@ -21,6 +25,111 @@ func NewCommand() *Command {
 	return &Command{TPDU: TPDU{FirstOctet: byte(MtCommand), DCS: 0x04}}
 }
 
+// Direction returns the Direction in which the Command is carried, i.e. MO.
+func (c *Command) Direction() Direction {
+	return MO
+}
+
+// CommandType represents the TP-Command-Type field of a Command, as defined
+// in 3GPP TS 23.040 Section 9.2.3.19.
+type CommandType byte
+
+const (
+	// CTEnquiry is an enquiry relating to a previously submitted short
+	// message.
+	CTEnquiry CommandType = iota
+	// CTCancelSRR cancels the status report request relating to a
+	// previously submitted short message.
+	CTCancelSRR
+	// CTDelete deletes a previously submitted short message.
+	CTDelete
+	// CTEnableSRR enables a status report request relating to a previously
+	// submitted short message.
+	CTEnableSRR
+)
+
+var commandTypeNames = map[CommandType]string{
+	CTEnquiry:   "enquiry",
+	CTCancelSRR: "cancel status report request",
+	CTDelete:    "delete",
+	CTEnableSRR: "enable status report request",
+}
+
+func (ct CommandType) String() string {
+	if s, ok := commandTypeNames[ct]; ok {
+		return s
+	}
+	if ct.SCSpecific() {
+		return fmt.Sprintf("SC specific (0x%02x)", byte(ct))
+	}
+	return fmt.Sprintf("reserved (0x%02x)", byte(ct))
+}
+
+// SCSpecific returns true if the CommandType is a value reserved for SC
+// specific use.
+func (ct CommandType) SCSpecific() bool {
+	return ct >= 0xe0
+}
+
+// SRR returns the TP-SRR required in a Command of this type.
+// Only an enquiry requests a status report.
+func (ct CommandType) SRR() bool {
+	return ct == CTEnquiry
+}
+
+// NewCommandFor creates a Command of type ct relating to the previously
+// submitted Submit TPDU.
+//
+// The MN is set to the MR of the Submit, and the PID and DA are copied from
+// the Submit.
+// The TP-SRR is set as required by the CommandType.
+// The MR of the Command itself should be set by the caller.
+func NewCommandFor(ct CommandType, s *Submit) *Command {
+	c := NewCommand()
+	c.CT = byte(ct)
+	c.MN = s.MR
+	c.PID = s.PID
+	c.DA = s.DA
+	if ct.SRR() {
+		c.FirstOctet |= srrMask
+	}
+	return c
+}
+
+// NewEnquiryCommand creates a Command enquiring about the status of the
+// previously submitted Submit TPDU.
+func NewEnquiryCommand(s *Submit) *Command {
+	return NewCommandFor(CTEnquiry, s)
+}
+
+// NewCancelSRRCommand creates a Command cancelling the status report
+// request of the previously submitted Submit TPDU.
+func NewCancelSRRCommand(s *Submit) *Command {
+	return NewCommandFor(CTCancelSRR, s)
+}
+
+// NewDeleteCommand creates a Command deleting the previously submitted
+// Submit TPDU from the SC.
+func NewDeleteCommand(s *Submit) *Command {
+	return NewCommandFor(CTDelete, s)
+}
+
+// NewEnableSRRCommand creates a Command enabling a status report request
+// for the previously submitted Submit TPDU.
+func NewEnableSRRCommand(s *Submit) *Command {
+	return NewCommandFor(CTEnableSRR, s)
+}
+
+// CommandType returns the TP-CT of the Command as a CommandType.
+func (c *Command) CommandType() CommandType {
+	return CommandType(c.CT)
+}
+
+// SRR returns true if the Command requests a status report.
+func (c *Command) SRR() bool {
+	return c.FirstOctet&srrMask != 0
+}
+
 // MarshalBinary marshals an SMS-Command-Report TPDU.
 func (c *Command) MarshalBinary() ([]byte, error) {
 	b := []byte{c.FirstOctet, c.MR, c.PID, c.CT, c.MN}
@@ -119,7 +119,7 @@ func TestCommandUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterCommandDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterCommandDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
@@ -6,8 +6,10 @@
 package tpdu_test
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/warthog618/sms/encoding/tpdu"
 )
 
@@ -20,3 +22,62 @@ func TestNewCommand(t *testing.T) {
 		t.Errorf("UDHI initially set to true")
 	}
 }
+
+func TestNewCommandFor(t *testing.T) {
+	s := tpdu.NewSubmit()
+	s.MR = 0x42
+	s.PID = 0x41
+	s.DA = tpdu.Address{TOA: 0x91, Addr: "61409123456"}
+	patterns := []struct {
+		name string
+		fn   func(*tpdu.Submit) *tpdu.Command
+		ct   tpdu.CommandType
+		srr  bool
+	}{
+		{"enquiry", tpdu.NewEnquiryCommand, tpdu.CTEnquiry, true},
+		{"cancel srr", tpdu.NewCancelSRRCommand, tpdu.CTCancelSRR, false},
+		{"delete", tpdu.NewDeleteCommand, tpdu.CTDelete, false},
+		{"enable srr", tpdu.NewEnableSRRCommand, tpdu.CTEnableSRR, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := p.fn(s)
+			assert.Equal(t, tpdu.MtCommand, c.MTI())
+			assert.Equal(t, p.ct, c.CommandType())
+			assert.Equal(t, p.srr, c.SRR())
+			assert.Equal(t, byte(0x42), c.MN)
+			assert.Equal(t, byte(0x41), c.PID)
+			assert.Equal(t, s.DA, c.DA)
+			assert.Equal(t, c, tpdu.NewCommandFor(p.ct, s))
+		}
+		t.Run(p.name, f)
+	}
+	// binary form
+	s.MR = 0x05
+	c := tpdu.NewDeleteCommand(s)
+	c.MR = 0x06
+	b, err := c.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x02, 0x06, 0x41, 0x02, 0x05, 0x0b, 0x91,
+		0x16, 0x04, 0x19, 0x32, 0x54, 0xf6, 0x00}, b)
+}
+
+func TestCommandTypeString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.CommandType
+		out string
+	}{
+		{tpdu.CTEnquiry, "enquiry"},
+		{tpdu.CTCancelSRR, "cancel status report request"},
+		{tpdu.CTDelete, "delete"},
+		{tpdu.CTEnableSRR, "enable status report request"},
+		{0x04, "reserved (0x04)"},
+		{0xe0, "SC specific (0xe0)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(fmt.Sprintf("%02x", byte(p.in)), f)
+	}
+}
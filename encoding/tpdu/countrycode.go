@@ -0,0 +1,295 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+// CountryCode contains the metadata for an E.164 country calling code,
+// as assigned in ITU-T E.164 Annex to ITU Operational Bulletin.
+type CountryCode struct {
+	// Code is the country calling code, e.g. "61".
+	Code string
+
+	// Regions contains the ISO 3166-1 alpha-2 codes of the regions using
+	// the Code.
+	Regions []string
+
+	// Trunk is the national trunk prefix dialled before national numbers
+	// within the regions, if any, e.g. "0".
+	Trunk string
+
+	// IntlPrefix is the prefix dialled before international numbers within
+	// the regions, e.g. "00".
+	IntlPrefix string
+
+	// MinNSN and MaxNSN bound the length of the national significant
+	// number, i.e. the number following the Code.
+	MinNSN int
+	MaxNSN int
+}
+
+// LookupCountryCode returns the CountryCode with the given code, such as
+// "61".
+// Returns false if the code is not an assigned country calling code.
+func LookupCountryCode(code string) (CountryCode, bool) {
+	cc, ok := countryCodes[code]
+	return cc, ok
+}
+
+// MatchCountryCode returns the CountryCode at the start of the international
+// number, which must not include any international prefix or '+'.
+// Returns false if the number does not start with an assigned country
+// calling code.
+func MatchCountryCode(number string) (CountryCode, bool) {
+	// country codes are prefix free, so at most one can match.
+	for l := 1; l <= 3 && l <= len(number); l++ {
+		if cc, ok := countryCodes[number[:l]]; ok {
+			return cc, true
+		}
+	}
+	return CountryCode{}, false
+}
+
+func init() {
+	for code, cc := range countryCodes {
+		cc.Code = code
+		if cc.IntlPrefix == "" {
+			cc.IntlPrefix = "00"
+		}
+		if cc.MinNSN == 0 {
+			cc.MinNSN = 4
+		}
+		if cc.MaxNSN == 0 {
+			cc.MaxNSN = 15 - len(code)
+		}
+		countryCodes[code] = cc
+	}
+}
+
+func regions(r ...string) []string {
+	return r
+}
+
+// countryCodes maps the assigned country calling codes to their metadata.
+// Fields not specified default to an IntlPrefix of "00", a MinNSN of 4 and
+// a MaxNSN of the maximum permitted by E.164.
+var countryCodes = map[string]CountryCode{
+	"1": {Regions: regions("US", "CA", "AG", "AI", "AS", "BB", "BM", "BS", "DM", "DO",
+		"GD", "GU", "JM", "KN", "KY", "LC", "MP", "MS", "PR", "SX", "TC", "TT", "VC",
+		"VG", "VI"), Trunk: "1", IntlPrefix: "011", MinNSN: 10, MaxNSN: 10},
+	"7":   {Regions: regions("RU", "KZ"), Trunk: "8", IntlPrefix: "810", MinNSN: 10, MaxNSN: 10},
+	"20":  {Regions: regions("EG"), Trunk: "0", MinNSN: 7, MaxNSN: 10},
+	"27":  {Regions: regions("ZA"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"30":  {Regions: regions("GR"), MinNSN: 10, MaxNSN: 10},
+	"31":  {Regions: regions("NL"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"32":  {Regions: regions("BE"), Trunk: "0", MinNSN: 8, MaxNSN: 9},
+	"33":  {Regions: regions("FR"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"34":  {Regions: regions("ES"), MinNSN: 9, MaxNSN: 9},
+	"36":  {Regions: regions("HU"), Trunk: "06", MinNSN: 8, MaxNSN: 9},
+	"39":  {Regions: regions("IT", "VA"), MinNSN: 6, MaxNSN: 11},
+	"40":  {Regions: regions("RO"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"41":  {Regions: regions("CH"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"43":  {Regions: regions("AT"), Trunk: "0", MinNSN: 4, MaxNSN: 13},
+	"44":  {Regions: regions("GB", "GG", "IM", "JE"), Trunk: "0", MinNSN: 7, MaxNSN: 10},
+	"45":  {Regions: regions("DK"), MinNSN: 8, MaxNSN: 8},
+	"46":  {Regions: regions("SE"), Trunk: "0", MinNSN: 7, MaxNSN: 13},
+	"47":  {Regions: regions("NO", "SJ"), MinNSN: 8, MaxNSN: 8},
+	"48":  {Regions: regions("PL"), MinNSN: 9, MaxNSN: 9},
+	"49":  {Regions: regions("DE"), Trunk: "0", MinNSN: 6, MaxNSN: 13},
+	"51":  {Regions: regions("PE"), Trunk: "0", MinNSN: 8, MaxNSN: 9},
+	"52":  {Regions: regions("MX"), MinNSN: 10, MaxNSN: 10},
+	"53":  {Regions: regions("CU"), Trunk: "0", MinNSN: 6, MaxNSN: 8},
+	"54":  {Regions: regions("AR"), Trunk: "0", MinNSN: 10, MaxNSN: 11},
+	"55":  {Regions: regions("BR"), Trunk: "0", MinNSN: 10, MaxNSN: 11},
+	"56":  {Regions: regions("CL"), MinNSN: 9, MaxNSN: 9},
+	"57":  {Regions: regions("CO"), MinNSN: 8, MaxNSN: 10},
+	"58":  {Regions: regions("VE"), Trunk: "0", MinNSN: 10, MaxNSN: 10},
+	"60":  {Regions: regions("MY"), Trunk: "0", MinNSN: 7, MaxNSN: 10},
+	"61":  {Regions: regions("AU", "CC", "CX"), Trunk: "0", IntlPrefix: "0011", MinNSN: 9, MaxNSN: 9},
+	"62":  {Regions: regions("ID"), Trunk: "0", IntlPrefix: "001", MinNSN: 7, MaxNSN: 12},
+	"63":  {Regions: regions("PH"), Trunk: "0", MinNSN: 8, MaxNSN: 10},
+	"64":  {Regions: regions("NZ"), Trunk: "0", MinNSN: 8, MaxNSN: 10},
+	"65":  {Regions: regions("SG"), IntlPrefix: "000", MinNSN: 8, MaxNSN: 8},
+	"66":  {Regions: regions("TH"), Trunk: "0", IntlPrefix: "001", MinNSN: 8, MaxNSN: 9},
+	"81":  {Regions: regions("JP"), Trunk: "0", IntlPrefix: "010", MinNSN: 9, MaxNSN: 10},
+	"82":  {Regions: regions("KR"), Trunk: "0", IntlPrefix: "001", MinNSN: 8, MaxNSN: 10},
+	"84":  {Regions: regions("VN"), Trunk: "0", MinNSN: 9, MaxNSN: 10},
+	"86":  {Regions: regions("CN"), Trunk: "0", MinNSN: 10, MaxNSN: 11},
+	"90":  {Regions: regions("TR"), Trunk: "0", MinNSN: 10, MaxNSN: 10},
+	"91":  {Regions: regions("IN"), Trunk: "0", MinNSN: 10, MaxNSN: 10},
+	"92":  {Regions: regions("PK"), Trunk: "0", MinNSN: 9, MaxNSN: 10},
+	"93":  {Regions: regions("AF"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"94":  {Regions: regions("LK"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"95":  {Regions: regions("MM"), Trunk: "0", MinNSN: 7, MaxNSN: 10},
+	"98":  {Regions: regions("IR"), Trunk: "0", MinNSN: 10, MaxNSN: 10},
+	"211": {Regions: regions("SS"), Trunk: "0"},
+	"212": {Regions: regions("MA", "EH"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"213": {Regions: regions("DZ"), Trunk: "0"},
+	"216": {Regions: regions("TN"), MinNSN: 8, MaxNSN: 8},
+	"218": {Regions: regions("LY"), Trunk: "0"},
+	"220": {Regions: regions("GM")},
+	"221": {Regions: regions("SN")},
+	"222": {Regions: regions("MR")},
+	"223": {Regions: regions("ML")},
+	"224": {Regions: regions("GN")},
+	"225": {Regions: regions("CI")},
+	"226": {Regions: regions("BF")},
+	"227": {Regions: regions("NE")},
+	"228": {Regions: regions("TG")},
+	"229": {Regions: regions("BJ")},
+	"230": {Regions: regions("MU")},
+	"231": {Regions: regions("LR"), Trunk: "0"},
+	"232": {Regions: regions("SL"), Trunk: "0"},
+	"233": {Regions: regions("GH"), Trunk: "0"},
+	"234": {Regions: regions("NG"), Trunk: "0", IntlPrefix: "009"},
+	"235": {Regions: regions("TD")},
+	"236": {Regions: regions("CF")},
+	"237": {Regions: regions("CM")},
+	"238": {Regions: regions("CV")},
+	"239": {Regions: regions("ST")},
+	"240": {Regions: regions("GQ")},
+	"241": {Regions: regions("GA")},
+	"242": {Regions: regions("CG")},
+	"243": {Regions: regions("CD"), Trunk: "0"},
+	"244": {Regions: regions("AO")},
+	"245": {Regions: regions("GW")},
+	"246": {Regions: regions("IO")},
+	"247": {Regions: regions("AC")},
+	"248": {Regions: regions("SC")},
+	"249": {Regions: regions("SD"), Trunk: "0"},
+	"250": {Regions: regions("RW")},
+	"251": {Regions: regions("ET"), Trunk: "0"},
+	"252": {Regions: regions("SO")},
+	"253": {Regions: regions("DJ")},
+	"254": {Regions: regions("KE"), Trunk: "0", IntlPrefix: "000", MinNSN: 9, MaxNSN: 9},
+	"255": {Regions: regions("TZ"), Trunk: "0", IntlPrefix: "000"},
+	"256": {Regions: regions("UG"), Trunk: "0", IntlPrefix: "000"},
+	"257": {Regions: regions("BI")},
+	"258": {Regions: regions("MZ")},
+	"260": {Regions: regions("ZM"), Trunk: "0"},
+	"261": {Regions: regions("MG"), Trunk: "0"},
+	"262": {Regions: regions("RE", "YT"), Trunk: "0"},
+	"263": {Regions: regions("ZW"), Trunk: "0"},
+	"264": {Regions: regions("NA"), Trunk: "0"},
+	"265": {Regions: regions("MW"), Trunk: "0"},
+	"266": {Regions: regions("LS")},
+	"267": {Regions: regions("BW")},
+	"268": {Regions: regions("SZ")},
+	"269": {Regions: regions("KM")},
+	"290": {Regions: regions("SH", "TA")},
+	"291": {Regions: regions("ER"), Trunk: "0"},
+	"297": {Regions: regions("AW")},
+	"298": {Regions: regions("FO")},
+	"299": {Regions: regions("GL")},
+	"350": {Regions: regions("GI")},
+	"351": {Regions: regions("PT"), MinNSN: 9, MaxNSN: 9},
+	"352": {Regions: regions("LU")},
+	"353": {Regions: regions("IE"), Trunk: "0", MinNSN: 7, MaxNSN: 9},
+	"354": {Regions: regions("IS"), MinNSN: 7, MaxNSN: 9},
+	"355": {Regions: regions("AL"), Trunk: "0"},
+	"356": {Regions: regions("MT"), MinNSN: 8, MaxNSN: 8},
+	"357": {Regions: regions("CY"), MinNSN: 8, MaxNSN: 8},
+	"358": {Regions: regions("FI", "AX"), Trunk: "0"},
+	"359": {Regions: regions("BG"), Trunk: "0"},
+	"370": {Regions: regions("LT"), Trunk: "8"},
+	"371": {Regions: regions("LV"), MinNSN: 8, MaxNSN: 8},
+	"372": {Regions: regions("EE"), MinNSN: 7, MaxNSN: 8},
+	"373": {Regions: regions("MD"), Trunk: "0"},
+	"374": {Regions: regions("AM"), Trunk: "0"},
+	"375": {Regions: regions("BY"), Trunk: "8", IntlPrefix: "810"},
+	"376": {Regions: regions("AD")},
+	"377": {Regions: regions("MC")},
+	"378": {Regions: regions("SM")},
+	"380": {Regions: regions("UA"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"381": {Regions: regions("RS"), Trunk: "0"},
+	"382": {Regions: regions("ME"), Trunk: "0"},
+	"383": {Regions: regions("XK"), Trunk: "0"},
+	"385": {Regions: regions("HR"), Trunk: "0"},
+	"386": {Regions: regions("SI"), Trunk: "0"},
+	"387": {Regions: regions("BA"), Trunk: "0"},
+	"389": {Regions: regions("MK"), Trunk: "0"},
+	"420": {Regions: regions("CZ"), MinNSN: 9, MaxNSN: 9},
+	"421": {Regions: regions("SK"), Trunk: "0"},
+	"423": {Regions: regions("LI")},
+	"500": {Regions: regions("FK")},
+	"501": {Regions: regions("BZ")},
+	"502": {Regions: regions("GT")},
+	"503": {Regions: regions("SV")},
+	"504": {Regions: regions("HN")},
+	"505": {Regions: regions("NI")},
+	"506": {Regions: regions("CR")},
+	"507": {Regions: regions("PA")},
+	"508": {Regions: regions("PM")},
+	"509": {Regions: regions("HT")},
+	"590": {Regions: regions("GP", "BL", "MF"), Trunk: "0"},
+	"591": {Regions: regions("BO"), Trunk: "0"},
+	"592": {Regions: regions("GY")},
+	"593": {Regions: regions("EC"), Trunk: "0"},
+	"594": {Regions: regions("GF"), Trunk: "0"},
+	"595": {Regions: regions("PY"), Trunk: "0"},
+	"596": {Regions: regions("MQ"), Trunk: "0"},
+	"597": {Regions: regions("SR")},
+	"598": {Regions: regions("UY"), Trunk: "0"},
+	"599": {Regions: regions("CW", "BQ")},
+	"670": {Regions: regions("TL")},
+	"672": {Regions: regions("NF", "AQ")},
+	"673": {Regions: regions("BN")},
+	"674": {Regions: regions("NR")},
+	"675": {Regions: regions("PG")},
+	"676": {Regions: regions("TO")},
+	"677": {Regions: regions("SB")},
+	"678": {Regions: regions("VU")},
+	"679": {Regions: regions("FJ")},
+	"680": {Regions: regions("PW")},
+	"681": {Regions: regions("WF")},
+	"682": {Regions: regions("CK")},
+	"683": {Regions: regions("NU")},
+	"685": {Regions: regions("WS")},
+	"686": {Regions: regions("KI")},
+	"687": {Regions: regions("NC")},
+	"688": {Regions: regions("TV")},
+	"689": {Regions: regions("PF")},
+	"690": {Regions: regions("TK")},
+	"691": {Regions: regions("FM")},
+	"692": {Regions: regions("MH")},
+	"800": {},
+	"808": {},
+	"850": {Regions: regions("KP"), Trunk: "0"},
+	"852": {Regions: regions("HK"), IntlPrefix: "001", MinNSN: 8, MaxNSN: 8},
+	"853": {Regions: regions("MO"), MinNSN: 8, MaxNSN: 8},
+	"855": {Regions: regions("KH"), Trunk: "0"},
+	"856": {Regions: regions("LA"), Trunk: "0"},
+	"870": {},
+	"878": {},
+	"880": {Regions: regions("BD"), Trunk: "0"},
+	"881": {},
+	"882": {},
+	"883": {},
+	"886": {Regions: regions("TW"), Trunk: "0", IntlPrefix: "002"},
+	"888": {},
+	"960": {Regions: regions("MV")},
+	"961": {Regions: regions("LB"), Trunk: "0"},
+	"962": {Regions: regions("JO"), Trunk: "0"},
+	"963": {Regions: regions("SY"), Trunk: "0"},
+	"964": {Regions: regions("IQ"), Trunk: "0"},
+	"965": {Regions: regions("KW"), MinNSN: 8, MaxNSN: 8},
+	"966": {Regions: regions("SA"), Trunk: "0", MinNSN: 9, MaxNSN: 9},
+	"967": {Regions: regions("YE"), Trunk: "0"},
+	"968": {Regions: regions("OM")},
+	"970": {Regions: regions("PS"), Trunk: "0"},
+	"971": {Regions: regions("AE"), Trunk: "0", MinNSN: 8, MaxNSN: 9},
+	"972": {Regions: regions("IL"), Trunk: "0", MinNSN: 8, MaxNSN: 9},
+	"973": {Regions: regions("BH"), MinNSN: 8, MaxNSN: 8},
+	"974": {Regions: regions("QA"), MinNSN: 8, MaxNSN: 8},
+	"975": {Regions: regions("BT")},
+	"976": {Regions: regions("MN"), Trunk: "0", IntlPrefix: "001"},
+	"977": {Regions: regions("NP"), Trunk: "0"},
+	"979": {},
+	"992": {Regions: regions("TJ"), Trunk: "8", IntlPrefix: "810"},
+	"993": {Regions: regions("TM"), Trunk: "8", IntlPrefix: "810"},
+	"994": {Regions: regions("AZ"), Trunk: "0"},
+	"995": {Regions: regions("GE"), Trunk: "0"},
+	"996": {Regions: regions("KG"), Trunk: "0"},
+	"998": {Regions: regions("UZ"), Trunk: "8", IntlPrefix: "810"},
+}
@@ -5,6 +5,10 @@
 
 package tpdu
 
+import (
+	"fmt"
+)
+
 // DCS represents the SMS Data Coding Scheme field as defined in 3GPP TS 23.040 Section 4.
 type DCS byte
 
@@ -23,6 +27,20 @@ const (
 	AlphaReserved
 )
 
+var alphabetNames = map[Alphabet]string{
+	Alpha7Bit:     "7bit",
+	Alpha8Bit:     "8bit",
+	AlphaUCS2:     "ucs2",
+	AlphaReserved: "reserved",
+}
+
+func (a Alphabet) String() string {
+	if s, ok := alphabetNames[a]; ok {
+		return s
+	}
+	return fmt.Sprintf("Alphabet(%d)", int(a))
+}
+
 // Alphabet returns the alphabet used to encode the User Data according to the DCS.
 // The DCS is assumed to be defined as per 3GPP TS 23.038 Section 4.
 func (d DCS) Alphabet() (Alphabet, error) {
@@ -81,6 +99,21 @@ const (
 	MClassUnknown
 )
 
+var messageClassNames = map[MessageClass]string{
+	MClass0:       "class0",
+	MClass1:       "class1",
+	MClass2:       "class2",
+	MClass3:       "class3",
+	MClassUnknown: "unknown",
+}
+
+func (c MessageClass) String() string {
+	if s, ok := messageClassNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("MessageClass(%d)", int(c))
+}
+
 // Class returns the MessageClass indicated by the DCS.
 // The DCS is assumed to be defined as per 3GPP TS 23.038 Section 4.
 func (d DCS) Class() (MessageClass, error) {
@@ -115,3 +148,350 @@ func (d DCS) Compressed() bool {
 	// only true for 0x1xxxxx (binary)
 	return (d&0xa0 == 0x20)
 }
+
+// CodingGroup identifies the coding group of a DCS, as defined in
+// 3GPP TS 23.038.
+type CodingGroup int
+
+const (
+	// GroupGeneral is the general data coding group (SMS 00xx, CBS 01xx).
+	GroupGeneral CodingGroup = iota
+	// GroupAutoDelete is the general data coding group with the message
+	// marked for automatic deletion (SMS 01xx).
+	GroupAutoDelete
+	// GroupReserved indicates a reserved coding group.
+	// The UD should be assumed to be encoded using the GSM 7 bit default
+	// alphabet.
+	GroupReserved
+	// GroupMWIDiscard is the message waiting indication group where the
+	// message may be discarded (SMS 1100).
+	GroupMWIDiscard
+	// GroupMWIStore is the message waiting indication group where the
+	// message is to be stored (SMS 1101 and 1110).
+	GroupMWIStore
+	// GroupDataClass is the data coding/message class group (1111).
+	GroupDataClass
+	// GroupLanguage is a CBS language group, where the language is
+	// determined by the DCS (CBS 0000 and 0010).
+	GroupLanguage
+	// GroupLanguagePrefixed is the CBS group where the language is indicated
+	// by the start of the message content (CBS 0001).
+	GroupLanguagePrefixed
+	// GroupUDH is the CBS group for messages containing a UDH (CBS 1001).
+	GroupUDH
+	// GroupWAP is the CBS group for messages defined by the WAP Forum (CBS 1110).
+	GroupWAP
+)
+
+var codingGroupNames = map[CodingGroup]string{
+	GroupGeneral:          "general",
+	GroupAutoDelete:       "autoDelete",
+	GroupReserved:         "reserved",
+	GroupMWIDiscard:       "mwiDiscard",
+	GroupMWIStore:         "mwiStore",
+	GroupDataClass:        "dataClass",
+	GroupLanguage:         "language",
+	GroupLanguagePrefixed: "languagePrefixed",
+	GroupUDH:              "udh",
+	GroupWAP:              "wap",
+}
+
+func (g CodingGroup) String() string {
+	if s, ok := codingGroupNames[g]; ok {
+		return s
+	}
+	return fmt.Sprintf("CodingGroup(%d)", int(g))
+}
+
+// MWIType is the type of message waiting indicated by the DCS.
+type MWIType int
+
+const (
+	// MWIVoicemail indicates a voicemail message waiting.
+	MWIVoicemail MWIType = iota
+	// MWIFax indicates a fax message waiting.
+	MWIFax
+	// MWIEmail indicates an electronic mail message waiting.
+	MWIEmail
+	// MWIOther indicates some other message waiting.
+	MWIOther
+)
+
+// DataCoding is the decoded form of a DCS.
+//
+// The fields relevant to a particular DataCoding depend on the Group.
+// Fields that are not relevant to the Group are ignored when encoding, and
+// left as their zero values, or MClassUnknown, when decoding.
+//
+// A DataCoding can be used as a builder for a DCS, by populating the
+// relevant fields and calling DCS, or CBSDCS for Cell Broadcast.
+type DataCoding struct {
+	// Group is the coding group.
+	Group CodingGroup
+
+	// Alphabet is the alphabet used to encode the UD.
+	// Reserved alphabets are reported as AlphaReserved, and should be
+	// treated as Alpha7Bit.
+	Alphabet Alphabet
+
+	// Class is the message class, or MClassUnknown if no class is indicated.
+	Class MessageClass
+
+	// Compressed indicates the UD is compressed as per 3GPP TS 23.042.
+	Compressed bool
+
+	// MWIActive indicates if the message waiting indication is being set
+	// (true) or cleared (false).
+	// Only relevant to the MWI groups.
+	MWIActive bool
+
+	// MWI is the type of message waiting.
+	// Only relevant to the MWI groups.
+	MWI MWIType
+
+	// Language is the ISO 639-1 code for the language of a CBS message.
+	// Only relevant to GroupLanguage.
+	// An empty Language indicates the language is unspecified.
+	Language string
+}
+
+// AutoDelete indicates if the message is marked for automatic deletion.
+func (dc DataCoding) AutoDelete() bool {
+	return dc.Group == GroupAutoDelete
+}
+
+// DataCoding returns the decoded form of the DCS.
+// The DCS is assumed to be defined as per 3GPP TS 23.038 Section 4.
+func (d DCS) DataCoding() DataCoding {
+	dc := DataCoding{Class: MClassUnknown}
+	switch {
+	case d&0x80 == 0x00: // 0xxx
+		if d&0x40 == 0x40 {
+			dc.Group = GroupAutoDelete
+		}
+		dc.Compressed = d&0x20 == 0x20
+		if d&0x10 == 0x10 {
+			dc.Class = MessageClass(d & 0x03)
+		}
+		dc.Alphabet = Alphabet((d >> 2) & 0x03)
+	case d&0xe0 == 0xc0, d&0xf0 == 0xe0: // 1100, 1101 and 1110
+		switch d & 0xf0 {
+		case 0xc0:
+			dc.Group = GroupMWIDiscard
+		case 0xd0:
+			dc.Group = GroupMWIStore
+		case 0xe0:
+			dc.Group = GroupMWIStore
+			dc.Alphabet = AlphaUCS2
+		}
+		dc.MWIActive = d&0x08 == 0x08
+		dc.MWI = MWIType(d & 0x03)
+	case d&0xf0 == 0xf0: // 1111
+		dc.Group = GroupDataClass
+		if d&0x04 == 0x04 {
+			dc.Alphabet = Alpha8Bit
+		}
+		dc.Class = MessageClass(d & 0x03)
+	default: // 10xx
+		dc.Group = GroupReserved
+	}
+	return dc
+}
+
+// DCS returns the DCS corresponding to the DataCoding.
+// The DCS is encoded as per 3GPP TS 23.038 Section 4.
+// An error is returned if the DataCoding cannot be encoded as an SMS DCS.
+func (dc DataCoding) DCS() (DCS, error) {
+	if dc.Class < MClass0 || dc.Class > MClassUnknown {
+		return 0, ErrInvalid
+	}
+	switch dc.Group {
+	case GroupGeneral, GroupAutoDelete:
+		if dc.Alphabet < Alpha7Bit || dc.Alphabet > AlphaReserved {
+			return 0, ErrInvalid
+		}
+		d := DCS(dc.Alphabet) << 2
+		if dc.Group == GroupAutoDelete {
+			d |= 0x40
+		}
+		if dc.Compressed {
+			d |= 0x20
+		}
+		if dc.Class != MClassUnknown {
+			d |= 0x10 | DCS(dc.Class)
+		}
+		return d, nil
+	case GroupMWIDiscard, GroupMWIStore:
+		if dc.Class != MClassUnknown || dc.Compressed {
+			return 0, ErrInvalid
+		}
+		var d DCS
+		switch {
+		case dc.Group == GroupMWIDiscard && dc.Alphabet == Alpha7Bit:
+			d = 0xc0
+		case dc.Group == GroupMWIStore && dc.Alphabet == Alpha7Bit:
+			d = 0xd0
+		case dc.Group == GroupMWIStore && dc.Alphabet == AlphaUCS2:
+			d = 0xe0
+		default:
+			return 0, ErrInvalid
+		}
+		if dc.MWI < MWIVoicemail || dc.MWI > MWIOther {
+			return 0, ErrInvalid
+		}
+		if dc.MWIActive {
+			d |= 0x08
+		}
+		return d | DCS(dc.MWI), nil
+	case GroupDataClass:
+		if dc.Alphabet < Alpha7Bit || dc.Alphabet > Alpha8Bit ||
+			dc.Class == MClassUnknown || dc.Compressed {
+			return 0, ErrInvalid
+		}
+		return 0xf0 | DCS(dc.Alphabet)<<2 | DCS(dc.Class), nil
+	default:
+		return 0, ErrInvalid
+	}
+}
+
+// CBSDCS represents the Cell Broadcast Data Coding Scheme as defined in
+// 3GPP TS 23.038 Section 5.
+type CBSDCS byte
+
+// cbsLanguages maps the language groups of the CBS DCS to ISO 639-1
+// codes.  The index is the low 5 bits of the DCS.
+var cbsLanguages = map[byte]string{
+	0x00: "de",
+	0x01: "en",
+	0x02: "it",
+	0x03: "fr",
+	0x04: "es",
+	0x05: "nl",
+	0x06: "sv",
+	0x07: "da",
+	0x08: "pt",
+	0x09: "fi",
+	0x0a: "no",
+	0x0b: "el",
+	0x0c: "tr",
+	0x0d: "hu",
+	0x0e: "pl",
+	0x20: "cs",
+	0x21: "he",
+	0x22: "ar",
+	0x23: "ru",
+	0x24: "is",
+}
+
+// DataCoding returns the decoded form of the CBS DCS.
+// The DCS is assumed to be defined as per 3GPP TS 23.038 Section 5.
+func (d CBSDCS) DataCoding() DataCoding {
+	dc := DataCoding{Class: MClassUnknown}
+	switch {
+	case d == 0x0f: // 0000 1111 - language unspecified
+		dc.Group = GroupLanguage
+	case d&0xf0 == 0x00, d&0xf0 == 0x20: // 0000 and 0010
+		if l, ok := cbsLanguages[byte(d)]; ok {
+			dc.Group = GroupLanguage
+			dc.Language = l
+		} else {
+			dc.Group = GroupReserved
+		}
+	case d == 0x10:
+		dc.Group = GroupLanguagePrefixed
+	case d == 0x11:
+		dc.Group = GroupLanguagePrefixed
+		dc.Alphabet = AlphaUCS2
+	case d&0xc0 == 0x40: // 01xx
+		dc.Compressed = d&0x20 == 0x20
+		if d&0x10 == 0x10 {
+			dc.Class = MessageClass(d & 0x03)
+		}
+		dc.Alphabet = Alphabet((d >> 2) & 0x03)
+	case d&0xf0 == 0x90: // 1001
+		dc.Group = GroupUDH
+		dc.Alphabet = Alphabet((d >> 2) & 0x03)
+		dc.Class = MessageClass(d & 0x03)
+	case d&0xf0 == 0xe0: // 1110
+		dc.Group = GroupWAP
+		dc.Alphabet = Alpha8Bit
+	case d&0xf0 == 0xf0: // 1111
+		dc.Group = GroupDataClass
+		if d&0x04 == 0x04 {
+			dc.Alphabet = Alpha8Bit
+		}
+		if d&0x03 != 0 {
+			dc.Class = MessageClass(d & 0x03)
+		}
+	default: // 0001 reserved, 0011, 1000, 1010-1101
+		dc.Group = GroupReserved
+	}
+	return dc
+}
+
+// CBSDCS returns the CBS DCS corresponding to the DataCoding.
+// The DCS is encoded as per 3GPP TS 23.038 Section 5.
+// An error is returned if the DataCoding cannot be encoded as a CBS DCS.
+func (dc DataCoding) CBSDCS() (CBSDCS, error) {
+	if dc.Class < MClass0 || dc.Class > MClassUnknown {
+		return 0, ErrInvalid
+	}
+	if dc.Group != GroupGeneral && dc.Compressed {
+		return 0, ErrInvalid
+	}
+	switch dc.Group {
+	case GroupLanguage:
+		if dc.Alphabet != Alpha7Bit || dc.Class != MClassUnknown {
+			return 0, ErrInvalid
+		}
+		if dc.Language == "" {
+			return 0x0f, nil
+		}
+		for k, v := range cbsLanguages {
+			if v == dc.Language {
+				return CBSDCS(k), nil
+			}
+		}
+		return 0, ErrInvalid
+	case GroupLanguagePrefixed:
+		if dc.Class != MClassUnknown {
+			return 0, ErrInvalid
+		}
+		switch dc.Alphabet {
+		case Alpha7Bit:
+			return 0x10, nil
+		case AlphaUCS2:
+			return 0x11, nil
+		}
+		return 0, ErrInvalid
+	case GroupGeneral:
+		if dc.Alphabet < Alpha7Bit || dc.Alphabet > AlphaReserved {
+			return 0, ErrInvalid
+		}
+		d := 0x40 | CBSDCS(dc.Alphabet)<<2
+		if dc.Compressed {
+			d |= 0x20
+		}
+		if dc.Class != MClassUnknown {
+			d |= 0x10 | CBSDCS(dc.Class)
+		}
+		return d, nil
+	case GroupUDH:
+		if dc.Alphabet < Alpha7Bit || dc.Alphabet > AlphaReserved ||
+			dc.Class == MClassUnknown {
+			return 0, ErrInvalid
+		}
+		return 0x90 | CBSDCS(dc.Alphabet)<<2 | CBSDCS(dc.Class), nil
+	case GroupDataClass:
+		if dc.Alphabet < Alpha7Bit || dc.Alphabet > Alpha8Bit || dc.Class == MClass0 {
+			return 0, ErrInvalid
+		}
+		d := 0xf0 | CBSDCS(dc.Alphabet)<<2
+		if dc.Class != MClassUnknown {
+			d |= CBSDCS(dc.Class)
+		}
+		return d, nil
+	default:
+		return 0, ErrInvalid
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/warthog618/sms/encoding/tpdu"
 )
 
@@ -253,3 +255,167 @@ func TestDCSCompressed(t *testing.T) {
 		t.Run(fmt.Sprintf("%02x", p.in), f)
 	}
 }
+
+func TestDCSDataCoding(t *testing.T) {
+	patterns := []struct {
+		in  byte
+		out tpdu.DataCoding
+	}{
+		{0x00, tpdu.DataCoding{Class: tpdu.MClassUnknown}},
+		{0x08, tpdu.DataCoding{Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{0x0c, tpdu.DataCoding{Alphabet: tpdu.AlphaReserved, Class: tpdu.MClassUnknown}},
+		{0x16, tpdu.DataCoding{Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClass2}},
+		{0x21, tpdu.DataCoding{Compressed: true, Class: tpdu.MClassUnknown}},
+		{0x48, tpdu.DataCoding{Group: tpdu.GroupAutoDelete, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{0x70, tpdu.DataCoding{Group: tpdu.GroupAutoDelete, Compressed: true, Class: tpdu.MClass0}},
+		{0x80, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0xbf, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0xc0, tpdu.DataCoding{Group: tpdu.GroupMWIDiscard, Class: tpdu.MClassUnknown}},
+		{0xc9, tpdu.DataCoding{Group: tpdu.GroupMWIDiscard, Class: tpdu.MClassUnknown, MWIActive: true, MWI: tpdu.MWIFax}},
+		{0xd8, tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClassUnknown, MWIActive: true}},
+		{0xd2, tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClassUnknown, MWI: tpdu.MWIEmail}},
+		{0xeb, tpdu.DataCoding{Group: tpdu.GroupMWIStore, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown, MWIActive: true, MWI: tpdu.MWIOther}},
+		{0xf0, tpdu.DataCoding{Group: tpdu.GroupDataClass, Class: tpdu.MClass0}},
+		{0xf5, tpdu.DataCoding{Group: tpdu.GroupDataClass, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClass1}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			dc := tpdu.DCS(p.in).DataCoding()
+			assert.Equal(t, p.out, dc)
+			assert.Equal(t, p.in&0xc0 == 0x40, dc.AutoDelete())
+		}
+		t.Run(fmt.Sprintf("%08b", p.in), f)
+	}
+}
+
+func TestDataCodingDCS(t *testing.T) {
+	// round trip all the valid ones, with the reserved bits cleared.
+	for i := 0x00; i <= 0xff; i++ {
+		var out byte
+		var err error
+		switch {
+		case i&0x90 == 0x00: // 0xx0, class bits reserved
+			out = byte(i &^ 0x03)
+		case i&0x80 == 0x00: // 0xx1
+			out = byte(i)
+		case i&0xc0 == 0x80: // 10xx
+			err = tpdu.ErrInvalid
+		case i&0xf0 == 0xf0: // 1111
+			out = byte(i &^ 0x08)
+		default: // MWI
+			out = byte(i &^ 0x04)
+		}
+		f := func(t *testing.T) {
+			d, derr := tpdu.DCS(i).DataCoding().DCS()
+			assert.Equal(t, err, derr)
+			assert.Equal(t, tpdu.DCS(out), d)
+		}
+		t.Run(fmt.Sprintf("%08b", i), f)
+	}
+	patterns := []struct {
+		name string
+		in   tpdu.DataCoding
+	}{
+		{"bad class", tpdu.DataCoding{Class: tpdu.MClassUnknown + 1}},
+		{"bad alphabet", tpdu.DataCoding{Alphabet: tpdu.AlphaReserved + 1, Class: tpdu.MClassUnknown}},
+		{"mwi class", tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClass1}},
+		{"mwi compressed", tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClassUnknown, Compressed: true}},
+		{"mwi 8bit", tpdu.DataCoding{Group: tpdu.GroupMWIStore, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClassUnknown}},
+		{"mwi discard ucs2", tpdu.DataCoding{Group: tpdu.GroupMWIDiscard, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{"mwi type", tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClassUnknown, MWI: tpdu.MWIOther + 1}},
+		{"data class ucs2", tpdu.DataCoding{Group: tpdu.GroupDataClass, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClass1}},
+		{"data class no class", tpdu.DataCoding{Group: tpdu.GroupDataClass, Class: tpdu.MClassUnknown}},
+		{"reserved", tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{"cbs", tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d, err := p.in.DCS()
+			assert.Equal(t, tpdu.ErrInvalid, err)
+			assert.Equal(t, tpdu.DCS(0), d)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCBSDCSDataCoding(t *testing.T) {
+	patterns := []struct {
+		in  byte
+		out tpdu.DataCoding
+	}{
+		{0x00, tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: "de"}},
+		{0x01, tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: "en"}},
+		{0x0e, tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: "pl"}},
+		{0x0f, tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown}},
+		{0x10, tpdu.DataCoding{Group: tpdu.GroupLanguagePrefixed, Class: tpdu.MClassUnknown}},
+		{0x11, tpdu.DataCoding{Group: tpdu.GroupLanguagePrefixed, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{0x12, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0x23, tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: "ru"}},
+		{0x25, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0x30, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0x48, tpdu.DataCoding{Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{0x76, tpdu.DataCoding{Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClass2, Compressed: true}},
+		{0x80, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0x95, tpdu.DataCoding{Group: tpdu.GroupUDH, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClass1}},
+		{0xa0, tpdu.DataCoding{Group: tpdu.GroupReserved, Class: tpdu.MClassUnknown}},
+		{0xe3, tpdu.DataCoding{Group: tpdu.GroupWAP, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClassUnknown}},
+		{0xf0, tpdu.DataCoding{Group: tpdu.GroupDataClass, Class: tpdu.MClassUnknown}},
+		{0xf7, tpdu.DataCoding{Group: tpdu.GroupDataClass, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClass3}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			dc := tpdu.CBSDCS(p.in).DataCoding()
+			assert.Equal(t, p.out, dc)
+		}
+		t.Run(fmt.Sprintf("%08b", p.in), f)
+	}
+}
+
+func TestDataCodingCBSDCS(t *testing.T) {
+	// round trip all the valid ones, with the reserved bits cleared.
+	for i := 0x00; i <= 0xff; i++ {
+		out := byte(i)
+		var err error
+		dc := tpdu.CBSDCS(i).DataCoding()
+		switch {
+		case dc.Group == tpdu.GroupReserved, dc.Group == tpdu.GroupWAP:
+			out = 0
+			err = tpdu.ErrInvalid
+		case i&0xd0 == 0x40: // 01x0, class bits reserved
+			out = byte(i &^ 0x03)
+		case i&0xf0 == 0xf0: // 1111
+			out = byte(i &^ 0x08)
+		}
+		f := func(t *testing.T) {
+			d, derr := dc.CBSDCS()
+			assert.Equal(t, err, derr)
+			assert.Equal(t, tpdu.CBSDCS(out), d)
+		}
+		t.Run(fmt.Sprintf("%08b", i), f)
+	}
+	patterns := []struct {
+		name string
+		in   tpdu.DataCoding
+	}{
+		{"bad class", tpdu.DataCoding{Class: tpdu.MClassUnknown + 1}},
+		{"bad alphabet", tpdu.DataCoding{Alphabet: tpdu.AlphaReserved + 1, Class: tpdu.MClassUnknown}},
+		{"language ucs2", tpdu.DataCoding{Group: tpdu.GroupLanguage, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{"language class", tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClass1}},
+		{"language unknown", tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Language: "xx"}},
+		{"language compressed", tpdu.DataCoding{Group: tpdu.GroupLanguage, Class: tpdu.MClassUnknown, Compressed: true}},
+		{"prefixed 8bit", tpdu.DataCoding{Group: tpdu.GroupLanguagePrefixed, Alphabet: tpdu.Alpha8Bit, Class: tpdu.MClassUnknown}},
+		{"prefixed class", tpdu.DataCoding{Group: tpdu.GroupLanguagePrefixed, Class: tpdu.MClass1}},
+		{"udh no class", tpdu.DataCoding{Group: tpdu.GroupUDH, Class: tpdu.MClassUnknown}},
+		{"data class ucs2", tpdu.DataCoding{Group: tpdu.GroupDataClass, Alphabet: tpdu.AlphaUCS2, Class: tpdu.MClassUnknown}},
+		{"data class 0", tpdu.DataCoding{Group: tpdu.GroupDataClass, Class: tpdu.MClass0}},
+		{"mwi", tpdu.DataCoding{Group: tpdu.GroupMWIStore, Class: tpdu.MClassUnknown}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d, err := p.in.CBSDCS()
+			assert.Equal(t, tpdu.ErrInvalid, err)
+			assert.Equal(t, tpdu.CBSDCS(0), d)
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -13,6 +13,10 @@ import (
 type Decoder struct {
 	// d maps MessageType and Direction to the corresponding decoder.
 	d map[byte]ConcreteDecoder
+
+	// lenient indicates the Decoder should recover from deviations in the
+	// TPDUs it decodes.
+	lenient bool
 }
 
 // DecoderOption is function that modifies an existing Decoder.
@@ -20,7 +24,7 @@ type DecoderOption func(*Decoder) error
 
 // NewDecoder creates a new Decoder.
 func NewDecoder(opts ...DecoderOption) (*Decoder, error) {
-	d := &Decoder{map[byte]ConcreteDecoder{}}
+	d := &Decoder{d: map[byte]ConcreteDecoder{}}
 	for _, opt := range opts {
 		if err := opt(d); err != nil {
 			return nil, err
@@ -30,6 +34,8 @@ func NewDecoder(opts ...DecoderOption) (*Decoder, error) {
 }
 
 // ConcreteDecoder is a function that decodes a binary TPDU into a particular TPDU struct.
+//
+// The TPDU returned must implement PDU.
 type ConcreteDecoder func([]byte) (interface{}, error)
 
 // RegisterDecoder registers a decoder for the given MessageType and Direction.
@@ -48,19 +54,49 @@ func (d *Decoder) RegisterDecoder(mt MessageType, drn Direction, f ConcreteDecod
 // TPDUs depending on whether the SMS is being sent to the MS, or is from the MS.)
 //
 // The reverse of this operation is MarshalBinary on the returned TPDU.
-func (d *Decoder) Decode(src []byte, drn Direction) (interface{}, error) {
+//
+// If the Decoder is lenient then any warnings are discarded.
+func (d *Decoder) Decode(src []byte, drn Direction) (PDU, error) {
+	pdu, _, err := d.DecodeWithWarnings(src, drn)
+	return pdu, err
+}
+
+// DecodeWithWarnings returns the TPDU decoded from the SMS TPDU in src,
+// as per Decode, along with any warnings describing deviations recovered
+// from while decoding.
+//
+// Warnings are only returned if the Decoder was created with the
+// LenientDecoding option.
+func (d *Decoder) DecodeWithWarnings(src []byte, drn Direction) (PDU, []Warning, error) {
 	if len(src) < 1 {
-		return nil, DecodeError("firstOctet", 0, ErrUnderflow)
+		return nil, nil, DecodeError("firstOctet", 0, ErrUnderflow)
 	}
 	firstOctet := src[0]
 	k := (firstOctet & 0x3) | byte(drn<<2)
 	f, ok := d.d[k]
 	if !ok {
-		return nil, DecodeError("firstOctet", 0, ErrUnsupportedMTI(firstOctet&0x03))
+		return nil, nil, DecodeError("firstOctet", 0, ErrUnsupportedMTI(firstOctet&0x03))
 	}
-	pdu, err := f(src)
+	if d.lenient {
+		return decodeLenient(f, src, drn)
+	}
+	pdu, err := decodePDU(f, src)
 	if err != nil {
+		return nil, nil, err
+	}
+	return pdu, nil, nil
+}
+
+// decodePDU decodes the binary TPDU using f, and returns the decoded TPDU as
+// a PDU.
+func decodePDU(f ConcreteDecoder, src []byte) (PDU, error) {
+	v, err := f(src)
+	if err != nil || v == nil {
 		return nil, err
 	}
+	pdu, ok := v.(PDU)
+	if !ok {
+		return nil, ErrNotPDU
+	}
 	return pdu, nil
 }
@@ -105,3 +105,24 @@ func TestDecoderDecode(t *testing.T) {
 		t.Errorf("unexpected TPDU returned on decode: got %v", p)
 	}
 }
+
+func TestDecoderDecodeNotPDU(t *testing.T) {
+	d, err := tpdu.NewDecoder()
+	if err != nil {
+		t.Fatalf("error creating decoder: %v", err)
+	}
+	f := func(src []byte) (interface{}, error) {
+		return "not a PDU", nil
+	}
+	err = d.RegisterDecoder(tpdu.MtSubmit, tpdu.MO, f)
+	if err != nil {
+		t.Fatalf("error registering decoder: %v", err)
+	}
+	p, err := d.Decode([]byte{byte(tpdu.MtSubmit)}, tpdu.MO)
+	if err != tpdu.ErrNotPDU {
+		t.Errorf("unexpected error on decode: got %v", err)
+	}
+	if p != nil {
+		t.Errorf("unexpected TPDU returned on decode: got %v", p)
+	}
+}
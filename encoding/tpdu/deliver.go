@@ -20,6 +20,11 @@ func NewDeliver() *Deliver {
 	return &Deliver{TPDU: TPDU{FirstOctet: byte(MtDeliver)}}
 }
 
+// Direction returns the Direction in which the Deliver is carried, i.e. MT.
+func (d *Deliver) Direction() Direction {
+	return MT
+}
+
 // MaxUDL returns the maximum number of octets that can be encoded into the UD.
 // Note that for 7bit encoding this can result in up to 160 septets.
 func (d *Deliver) MaxUDL() int {
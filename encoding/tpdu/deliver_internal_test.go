@@ -136,7 +136,7 @@ func TestDeliverUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterDeliverDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterDeliverDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
@@ -154,7 +154,7 @@ func TestRegisterDeliverDecoder(t *testing.T) {
 }
 
 func TestRegisterReservedDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterReservedDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
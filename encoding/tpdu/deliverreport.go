@@ -17,6 +17,16 @@ func NewDeliverReport() *DeliverReport {
 	return &DeliverReport{TPDU: TPDU{FirstOctet: byte(MtDeliver)}}
 }
 
+// Direction returns the Direction in which the DeliverReport is carried, i.e. MO.
+func (d *DeliverReport) Direction() Direction {
+	return MO
+}
+
+// HasUD returns true if the PI indicates the DeliverReport contains the UD.
+func (d *DeliverReport) HasUD() bool {
+	return d.PI&0x04 != 0
+}
+
 // SetDCS sets the DeliverReport dcs field and the corresponding bit of the pi.
 func (d *DeliverReport) SetDCS(dcs byte) {
 	d.PI = d.PI | 0x02
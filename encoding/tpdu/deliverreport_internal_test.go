@@ -123,7 +123,7 @@ func TestDeliverReportUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterDeliverReportDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterDeliverReportDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
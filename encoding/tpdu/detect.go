@@ -0,0 +1,162 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"sort"
+	"strings"
+)
+
+// Candidate is a possible decoding of a binary TPDU of unknown Direction,
+// as returned by DecodeAnyDirection.
+type Candidate struct {
+	// Direction is the Direction assumed when decoding the TPDU.
+	Direction Direction
+
+	// TPDU is the decoded TPDU, or nil if the TPDU could not be decoded
+	// in this Direction.
+	TPDU PDU
+
+	// Warnings contains any warnings returned while decoding the TPDU,
+	// if the Decoder is lenient.
+	Warnings []Warning
+
+	// Err is the error returned while decoding the TPDU, if any.
+	Err error
+
+	// Score is the structural validity score of the TPDU in this
+	// Direction.  Higher scores indicate a more plausible decoding.
+	Score int
+
+	// Confidence is the confidence in this decoding relative to the other
+	// candidates, in the range 0 to 1.
+	// The Confidence is 0 if the decoding failed or the Score is not
+	// positive.
+	Confidence float64
+}
+
+// DecodeAnyDirection decodes a binary TPDU of unknown Direction.
+//
+// The TPDU is decoded in each Direction and each of the decodings is
+// scored on its structural validity, such as whether the length of the
+// TPDU is consumed exactly, the timestamps contain valid BCD and plausible
+// dates and times, and the addresses contain valid digits.
+//
+// Returns the best Candidate and the alternatives, in order of decreasing
+// score.  If the TPDU cannot be decoded in any Direction then the error
+// from the best Candidate is returned.
+func (d *Decoder) DecodeAnyDirection(src []byte) (Candidate, []Candidate, error) {
+	cc := []Candidate{}
+	for _, drn := range []Direction{MT, MO} {
+		c := Candidate{Direction: drn}
+		c.TPDU, c.Warnings, c.Err = d.DecodeWithWarnings(src, drn)
+		c.Score = score(src, drn, c)
+		cc = append(cc, c)
+	}
+	sort.SliceStable(cc, func(i, j int) bool {
+		if (cc[i].Err == nil) != (cc[j].Err == nil) {
+			return cc[i].Err == nil
+		}
+		return cc[i].Score > cc[j].Score
+	})
+	total := 0
+	for _, c := range cc {
+		if c.Err == nil && c.Score > 0 {
+			total += c.Score
+		}
+	}
+	for i := range cc {
+		c := &cc[i]
+		if c.Err == nil && c.Score > 0 {
+			c.Confidence = float64(c.Score) / float64(total)
+		}
+	}
+	return cc[0], cc[1:], cc[0].Err
+}
+
+// Scoring weights.
+const (
+	scoreField       = 1
+	scoreError       = -10
+	scoreComplete    = 5
+	scoreWarning     = -3
+	scoreAddress     = 2
+	scoreTOA         = 1
+	scoreTimestamp   = 3
+	scoreUndecodable = -20
+)
+
+// score returns the structural validity score of the src decoded in the
+// drn Direction.
+func score(src []byte, drn Direction, c Candidate) int {
+	s := 0
+	fields, err := Dissect(src, drn)
+	if err == nil {
+		s += scoreComplete
+	}
+	for _, f := range fields {
+		if f.Err != nil {
+			s += scoreError
+			continue
+		}
+		s += scoreField
+		switch f.Name {
+		case "oa", "da", "ra":
+			s += scoreAddr(f)
+		case "scts", "dt":
+			s += scoreTime(f)
+		case "vp":
+			if len(f.Children) == 7 {
+				s += scoreTime(f)
+			}
+		}
+	}
+	s += len(c.Warnings) * scoreWarning
+	if c.Err != nil {
+		s += scoreUndecodable
+	}
+	return s
+}
+
+// scoreAddr scores the plausibility of a dissected address.
+func scoreAddr(f Field) int {
+	s := 0
+	toa := f.Children[1]
+	if toa.Raw[0]&0x80 != 0 {
+		s += scoreTOA
+	}
+	if TypeOfNumber((toa.Raw[0]>>4)&0x07) == TonAlphanumeric {
+		return s + scoreAddress
+	}
+	if strings.Trim(f.Children[2].Value, "0123456789") == "" {
+		s += scoreAddress
+	}
+	return s
+}
+
+// timestampRanges are the plausible ranges of the timestamp fields, after
+// the year.
+var timestampRanges = []struct{ min, max int }{
+	{1, 12}, {1, 31}, {0, 23}, {0, 59}, {0, 59},
+}
+
+// scoreTime scores the plausibility of a dissected timestamp.
+func scoreTime(f Field) int {
+	for i, r := range timestampRanges {
+		c := f.Children[i+1]
+		if c.Err != nil {
+			return 0
+		}
+		v := int(c.Raw[0]&0x0f)*10 + int(c.Raw[0]>>4)
+		if v < r.min || v > r.max {
+			return 0
+		}
+	}
+	if f.Children[6].Err != nil {
+		return 0
+	}
+	return scoreTimestamp
+}
@@ -0,0 +1,154 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestDecodeAnyDirection(t *testing.T) {
+	patterns := []struct {
+		name     string
+		in       []byte
+		drn      tpdu.Direction
+		altValid bool
+		err      error
+	}{
+		{"deliver",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			true,
+			nil},
+		{"submit",
+			[]byte{0x01, 0x00, 0x05, 0x91, 0x21, 0x43, 0xf5, 0x00, 0x00, 0x0b, 0xc8,
+				0x32, 0x9b, 0xfd, 0x06, 0xdd, 0xdf, 0x72, 0x36, 0x19},
+			tpdu.MO,
+			false,
+			nil},
+		{"status report",
+			[]byte{0x06, 0x23, 0x04, 0x91, 0x36, 0x19,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x46, 0x00},
+			tpdu.MT,
+			false,
+			nil},
+		{"command",
+			[]byte{0x22, 0x01, 0x00, 0x00, 0x23, 0x04, 0x91, 0x36, 0x19, 0x00},
+			tpdu.MO,
+			false,
+			nil},
+		{"submit report",
+			[]byte{0x01, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23},
+			tpdu.MT,
+			false,
+			nil},
+		{"deliver report",
+			[]byte{0x00, 0xd0, 0x06, 0x00, 0x00},
+			tpdu.MO,
+			false,
+			nil},
+		{"undecodable",
+			[]byte{0x00, 0x00},
+			tpdu.MO,
+			false,
+			tpdu.DecodeError("pi", 2, tpdu.ErrUnderflow)},
+	}
+	d, err := tpdu.NewDecoder(
+		tpdu.RegisterCommandDecoder,
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterDeliverReportDecoder,
+		tpdu.RegisterReservedDecoder,
+		tpdu.RegisterSubmitDecoder,
+		tpdu.RegisterSubmitReportDecoder,
+		tpdu.RegisterStatusReportDecoder,
+	)
+	require.Nil(t, err)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			best, alts, err := d.DecodeAnyDirection(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.drn, best.Direction)
+			require.Len(t, alts, 1)
+			assert.NotEqual(t, p.drn, alts[0].Direction)
+			assert.True(t, best.Score > alts[0].Score)
+			assert.Equal(t, p.altValid, alts[0].Err == nil)
+			assert.Equal(t, float64(0), alts[0].Confidence)
+			if p.err != nil {
+				assert.Nil(t, best.TPDU)
+				assert.Equal(t, float64(0), best.Confidence)
+				return
+			}
+			assert.Equal(t, float64(1), best.Confidence)
+			expected, err := d.Decode(p.in, p.drn)
+			require.Nil(t, err)
+			assert.Equal(t, expected, best.TPDU)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeAnyDirectionEmptyUD(t *testing.T) {
+	// a Deliver with the UDHI set, but truncated before the UD.
+	in := []byte{0x40, 0x00, 0x80, 0x00, 0x00, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+		0x11, 0x05}
+	d, err := tpdu.NewDecoder(
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterDeliverReportDecoder,
+	)
+	require.Nil(t, err)
+	best, alts, err := d.DecodeAnyDirection(in)
+	require.Nil(t, err)
+	require.Len(t, alts, 1)
+	assert.Equal(t, tpdu.MO, best.Direction)
+	assert.Equal(t, tpdu.DecodeError("ud.sm", 13, tpdu.ErrUnderflow), alts[0].Err)
+
+	d, err = tpdu.NewDecoder(
+		tpdu.LenientDecoding,
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterDeliverReportDecoder,
+	)
+	require.Nil(t, err)
+	best, alts, err = d.DecodeAnyDirection(in)
+	require.Nil(t, err)
+	require.Len(t, alts, 1)
+	assert.Equal(t, tpdu.MT, best.Direction)
+	assert.IsType(t, &tpdu.Deliver{}, best.TPDU)
+	assert.Equal(t, []tpdu.Warning{
+		{"udl", 12, tpdu.ErrUnderflow, "reduced udl from 5 to 0"},
+	}, best.Warnings)
+	assert.True(t, best.Score > alts[0].Score)
+}
+
+func TestDecodeAnyDirectionConfidence(t *testing.T) {
+	// valid both as a Deliver and as a DeliverReport with PID, DCS and UD.
+	in := []byte{0x00, 0x02, 0x87, 0x21, 0x04, 0x08, 0x51, 0x50, 0x71, 0x32, 0x20,
+		0x05, 0x23, 0x00}
+	d, err := tpdu.NewDecoder(
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterDeliverReportDecoder,
+	)
+	require.Nil(t, err)
+	best, alts, err := d.DecodeAnyDirection(in)
+	require.Nil(t, err)
+	require.Len(t, alts, 1)
+	assert.Nil(t, alts[0].Err)
+	assert.Equal(t, tpdu.MT, best.Direction)
+	assert.True(t, best.Confidence > 0.5)
+	assert.True(t, alts[0].Confidence > 0)
+	assert.InDelta(t, 1, best.Confidence+alts[0].Confidence, 0.0001)
+}
+
+func TestDirectionString(t *testing.T) {
+	assert.Equal(t, "MT", tpdu.MT.String())
+	assert.Equal(t, "MO", tpdu.MO.String())
+	assert.Equal(t, "Direction(2)", tpdu.Direction(2).String())
+}
@@ -0,0 +1,685 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/warthog618/sms/encoding/bcd"
+)
+
+// Field is a field dissected from a binary TPDU by Dissect.
+type Field struct {
+	// Name is the name of the field, as used in decode errors.
+	Name string
+
+	// Offset is the offset of the first octet of the field from the start
+	// of the TPDU.
+	Offset int
+
+	// Length is the number of octets spanned by the field.
+	Length int
+
+	// Bits identifies the bits occupied by the field within the octet at
+	// Offset, for fields smaller than an octet, else nil.
+	Bits *BitRange
+
+	// Raw contains the octets spanned by the field.
+	Raw []byte
+
+	// Value is the interpretation of the field.
+	Value string
+
+	// Err is the error detected while dissecting the field, if any.
+	Err error
+
+	// Children contains the subfields of the field, if any.
+	Children []Field
+}
+
+// BitRange identifies a range of bits within an octet, with bits numbered
+// from 0, the least significant bit, to 7.
+type BitRange struct {
+	Low  int
+	High int
+}
+
+// Mask returns the mask of the bits in the range.
+func (b BitRange) Mask() byte {
+	return byte(0xff>>uint(7-b.High)) &^ byte(1<<uint(b.Low)-1)
+}
+
+// Dissect walks the binary TPDU in src and returns the tree of fields it
+// contains, such as for displaying the TPDU in an annotated hex dump.
+//
+// The direction of the TPDU must be provided to determine the type of TPDU
+// from the MTI.
+//
+// Dissection continues as far as possible through invalid input.
+// Fields containing invalid values, such as invalid BCD or address digits,
+// have their Err set and dissection continues with the following field.
+// Dissection only stops early if the TPDU is truncated or the remaining
+// fields cannot be located.
+// The error returned identifies the first offending field and its offset,
+// as per UnmarshalBinary.
+// Any octets beyond the end of the TPDU are returned in a "trailing" field,
+// and any octets that could not be dissected are returned in a "remaining"
+// field.
+func Dissect(src []byte, drn Direction) ([]Field, error) {
+	d := dissector{src: src}
+	if len(src) < 1 {
+		d.fail("firstOctet", ErrUnderflow)
+		return d.fields, d.err
+	}
+	mt := MessageType(src[0] & 0x03)
+	switch {
+	case mt == MtDeliver && drn == MT:
+		d.deliver()
+	case mt == MtSubmit && drn == MT:
+		d.submitReport()
+	case mt == MtCommand && drn == MT:
+		d.statusReport()
+	case mt == MtDeliver && drn == MO:
+		d.deliverReport()
+	case mt == MtSubmit && drn == MO:
+		d.submit()
+	case mt == MtCommand && drn == MO:
+		d.command()
+	default:
+		d.firstOctet(drn, nil)
+		d.fields[0].Err = ErrUnsupportedMTI(mt)
+		d.err = DecodeError("firstOctet", 0, ErrUnsupportedMTI(mt))
+		d.stopped = true
+	}
+	if d.ri < len(src) {
+		if d.stopped {
+			d.add(Field{Name: "remaining", Raw: src[d.ri:]})
+		} else {
+			d.fail("trailing", ErrOverlength)
+		}
+	}
+	return d.fields, d.err
+}
+
+// dissector accumulates the fields dissected from src.
+type dissector struct {
+	src    []byte
+	ri     int
+	fields []Field
+	// err is the error for the first field found to be invalid.
+	err error
+	// stopped indicates the following fields cannot be located.
+	stopped bool
+}
+
+// bitDef defines a field within an octet.
+type bitDef struct {
+	name      string
+	high, low int
+	interp    func(v byte) string
+}
+
+var (
+	mmsDef  = bitDef{"mms", 2, 2, flag("no more messages waiting", "more messages waiting")}
+	lpDef   = bitDef{"lp", 3, 3, flag("forwarded or spawned", "not forwarded or spawned")}
+	udhiDef = bitDef{"udhi", 6, 6, flag("udh present", "no udh")}
+	rpDef   = bitDef{"rp", 7, 7, flag("reply path set", "no reply path")}
+
+	deliverDefs = []bitDef{mmsDef, lpDef,
+		{"sri", 5, 5, flag("status report requested", "no status report requested")},
+		udhiDef, rpDef}
+	submitDefs = []bitDef{
+		{"rd", 2, 2, flag("reject duplicates", "accept duplicates")},
+		{"vpf", 4, 3, func(v byte) string { return ValidityPeriodFormat(v).String() }},
+		{"srr", 5, 5, flag("status report requested", "no status report requested")},
+		udhiDef, rpDef}
+	statusReportDefs = []bitDef{mmsDef, lpDef,
+		{"srq", 5, 5, flag("result of command", "result of submit")},
+		udhiDef}
+	commandDefs = []bitDef{
+		{"srr", 5, 5, flag("status report requested", "no status report requested")},
+		udhiDef}
+	reportDefs = []bitDef{udhiDef}
+	piDefs     = []bitDef{
+		{"pid", 0, 0, flag("pid present", "no pid")},
+		{"dcs", 1, 1, flag("dcs present", "no dcs")},
+		{"udl", 2, 2, flag("udl present", "no udl")},
+		{"ext", 7, 7, flag("extended", "not extended")}}
+	toaDefs = []bitDef{
+		{"ext", 7, 7, nil},
+		{"ton", 6, 4, func(v byte) string { return TypeOfNumber(v).String() }},
+		{"npi", 3, 0, func(v byte) string { return NumberingPlan(v).String() }}}
+	efiDefs = []bitDef{
+		{"ext", 7, 7, flag("extended", "not extended")},
+		{"singleShot", 6, 6, flag("single shot", "not single shot")},
+		{"evpf", 2, 0, nil}}
+)
+
+// flag returns an interpretation of a single bit field.
+func flag(set, clear string) func(v byte) string {
+	return func(v byte) string {
+		if v != 0 {
+			return set
+		}
+		return clear
+	}
+}
+
+var tpduNames = map[Direction][]string{
+	MT: {"SMS-DELIVER", "SMS-SUBMIT-REPORT", "SMS-STATUS-REPORT", "reserved"},
+	MO: {"SMS-DELIVER-REPORT", "SMS-SUBMIT", "SMS-COMMAND", "reserved"},
+}
+
+// bitFields returns the fields within the octet at offset off.
+func bitFields(off int, b byte, defs []bitDef) []Field {
+	fields := make([]Field, len(defs))
+	for i, def := range defs {
+		br := BitRange{def.low, def.high}
+		v := (b & br.Mask()) >> uint(def.low)
+		value := fmt.Sprintf("%d", v)
+		if def.interp != nil {
+			value = def.interp(v)
+		}
+		fields[i] = Field{
+			Name:   def.name,
+			Offset: off,
+			Length: 1,
+			Bits:   &br,
+			Raw:    []byte{b},
+			Value:  value,
+		}
+	}
+	return fields
+}
+
+// fail adds a field covering the remaining octets, with the error, and
+// stops the dissection.
+func (d *dissector) fail(name string, err error) {
+	d.add(Field{Name: name, Raw: d.src[d.ri:], Err: err})
+	d.stopped = true
+}
+
+// take returns the next n octets, if available.
+// Once the dissection has stopped no further octets are available.
+func (d *dissector) take(name string, n int) ([]byte, bool) {
+	if d.stopped {
+		return nil, false
+	}
+	if len(d.src) < d.ri+n {
+		d.fail(name, ErrUnderflow)
+		return nil, false
+	}
+	return d.src[d.ri : d.ri+n], true
+}
+
+// add adds the field, which spans the octets in f.Raw, at the current
+// offset.
+func (d *dissector) add(f Field) {
+	f.Offset = d.ri
+	f.Length = len(f.Raw)
+	d.fields = append(d.fields, f)
+	if f.Err != nil && d.err == nil {
+		d.err = DecodeError(f.Name, d.ri, f.Err)
+	}
+	d.ri += f.Length
+}
+
+func (d *dissector) firstOctet(drn Direction, defs []bitDef) (byte, bool) {
+	b, ok := d.take("firstOctet", 1)
+	if !ok {
+		return 0, false
+	}
+	mti := bitFields(0, b[0], []bitDef{{"mti", 1, 0, func(v byte) string {
+		if names, ok := tpduNames[drn]; ok {
+			return names[v]
+		}
+		return MessageType(v).String()
+	}}})
+	d.add(Field{
+		Name:     "firstOctet",
+		Raw:      b,
+		Value:    fmt.Sprintf("0x%02x", b[0]),
+		Children: append(mti, bitFields(0, b[0], defs)...),
+	})
+	return b[0], true
+}
+
+func (d *dissector) octet(name string, interp func(b byte) string) (byte, bool) {
+	b, ok := d.take(name, 1)
+	if !ok {
+		return 0, false
+	}
+	d.add(Field{Name: name, Raw: b, Value: interp(b[0])})
+	return b[0], true
+}
+
+func (d *dissector) pi() (byte, bool) {
+	b, ok := d.take("pi", 1)
+	if !ok {
+		return 0, false
+	}
+	d.add(Field{
+		Name:     "pi",
+		Raw:      b,
+		Value:    fmt.Sprintf("0x%02x", b[0]),
+		Children: bitFields(d.ri, b[0], piDefs),
+	})
+	return b[0], true
+}
+
+func decimal(b byte) string {
+	return fmt.Sprintf("%d", b)
+}
+
+func describePID(b byte) string {
+	p := PID(b)
+	if td, ok := p.TelematicDevice(); ok {
+		return fmt.Sprintf("%v, device 0x%02x", p.Class(), byte(td))
+	}
+	return p.Class().String()
+}
+
+func describeDCS(b byte) string {
+	dc := DCS(b).DataCoding()
+	s := fmt.Sprintf("%v, %v", dc.Group, dc.Alphabet)
+	if dc.Class != MClassUnknown {
+		s += ", " + dc.Class.String()
+	}
+	if dc.Compressed {
+		s += ", compressed"
+	}
+	return s
+}
+
+func (d *dissector) address(name string) {
+	hdr, ok := d.take(name, 2)
+	if !ok {
+		return
+	}
+	off := d.ri
+	l := int(hdr[0])
+	toa := hdr[1]
+	n := (l + 1) / 2
+	if TypeOfNumber((toa>>4)&0x07) == TonAlphanumeric {
+		n = (l*7 + 7) / 8
+	}
+	end := off + 2 + n
+	if end > len(d.src) {
+		end = len(d.src)
+		d.stopped = true
+	}
+	af := Field{Name: "addr", Offset: off + 2, Length: end - off - 2, Raw: d.src[off+2 : end]}
+	f := Field{
+		Name: name,
+		Raw:  d.src[off:end],
+		Children: []Field{
+			{Name: "len", Offset: off, Length: 1, Raw: hdr[:1], Value: fmt.Sprintf("%d digits", l)},
+			{Name: "toa", Offset: off + 1, Length: 1, Raw: hdr[1:],
+				Value:    fmt.Sprintf("0x%02x", toa),
+				Children: bitFields(off+1, toa, toaDefs)},
+		},
+	}
+	a := Address{}
+	if _, err := a.UnmarshalBinary(d.src[off:end]); err != nil {
+		af.Err = cause(err)
+		f.Err = err
+	} else {
+		af.Value = a.Addr
+		f.Value = a.Number()
+	}
+	f.Children = append(f.Children, af)
+	d.add(f)
+}
+
+// timestampFields are the semi-octet fields of a Timestamp.
+var timestampFields = []string{"year", "month", "day", "hour", "minute", "second"}
+
+func (d *dissector) timestamp(name string) {
+	b, ok := d.take(name, 7)
+	if !ok {
+		return
+	}
+	f := Field{Name: name, Raw: b}
+	for i, n := range timestampFields {
+		c := Field{Name: n, Offset: d.ri + i, Length: 1, Raw: b[i : i+1]}
+		if v, err := bcd.Decode(b[i]); err != nil {
+			c.Err = err
+		} else {
+			c.Value = fmt.Sprintf("%02d", v)
+		}
+		f.Children = append(f.Children, c)
+	}
+	tz := Field{Name: "tz", Offset: d.ri + 6, Length: 1, Raw: b[6:]}
+	if v, err := bcd.DecodeSigned(b[6]); err != nil {
+		tz.Err = err
+	} else {
+		tz.Value = fmt.Sprintf("%d quarter hours", v)
+	}
+	f.Children = append(f.Children, tz)
+	t := Timestamp{}
+	if err := t.UnmarshalBinary(b); err != nil {
+		f.Err = err
+	} else {
+		f.Value = t.Format("2006-01-02 15:04:05 -07:00")
+	}
+	d.add(f)
+}
+
+func (d *dissector) vp(vpf ValidityPeriodFormat) {
+	switch vpf {
+	case VpfRelative:
+		d.octet("vp", func(b byte) string {
+			return relativeToDuration(b).String()
+		})
+	case VpfAbsolute:
+		d.timestamp("vp")
+	case VpfEnhanced:
+		b, ok := d.take("vp", 7)
+		if !ok {
+			return
+		}
+		f := Field{
+			Name: "vp",
+			Raw:  b,
+			Children: []Field{{Name: "efi", Offset: d.ri, Length: 1, Raw: b[:1],
+				Value:    fmt.Sprintf("0x%02x", b[0]),
+				Children: bitFields(d.ri, b[0], efiDefs)}},
+		}
+		for i := 1; i < len(b)-1 && b[i-1]&efiExtMask != 0; i++ {
+			f.Children = append(f.Children, Field{Name: "efiExt", Offset: d.ri + i,
+				Length: 1, Raw: b[i : i+1], Value: fmt.Sprintf("0x%02x", b[i])})
+		}
+		vp := ValidityPeriod{}
+		if _, err := vp.UnmarshalBinary(b, VpfEnhanced); err != nil {
+			f.Err = err
+		} else {
+			f.Value = vp.Duration.String()
+		}
+		d.add(f)
+	}
+}
+
+// userData dissects the UDL and UD, named l and ud, with the UD encoded
+// using the alphabet indicated by the dcs.
+func (d *dissector) userData(l, ud string, udhi bool, dcs byte) {
+	b, ok := d.take(l, 1)
+	if !ok {
+		return
+	}
+	udl := int(b[0])
+	p := TPDU{DCS: dcs}
+	alpha, err := p.udAlphabet()
+	n := udl
+	unit := "octets"
+	if err == nil && alpha == Alpha7Bit {
+		n = (udl*7 + 7) / 8
+		unit = "septets"
+	}
+	d.add(Field{Name: l, Raw: b, Value: fmt.Sprintf("%d %s", udl, unit)})
+	if udl == 0 {
+		return
+	}
+	if err != nil {
+		d.fail(ud, err)
+		return
+	}
+	off := d.ri
+	end := off + n
+	if end > len(d.src) {
+		end = len(d.src)
+	}
+	raw := d.src[off:end]
+	f := Field{Name: ud, Raw: raw}
+	if end-off < n {
+		f.Err = ErrUnderflow
+		d.stopped = true
+	}
+	udhl := 0
+	var udh UserDataHeader
+	if udhi {
+		hf := dissectUDH(off, raw)
+		udhl = hf.Length
+		f.Children = append(f.Children, hf)
+		if hf.Err != nil {
+			f.Err = DecodeError("udh", 0, hf.Err)
+			d.add(f)
+			return
+		}
+		udh.UnmarshalBinary(raw)
+	}
+	sm := Field{Name: "sm", Offset: off + udhl, Length: len(raw) - udhl, Raw: raw[udhl:]}
+	switch {
+	case len(sm.Raw) == 0:
+	case p.DCS != 0 && DCS(p.DCS).Compressed():
+		sm.Value = "compressed"
+	case alpha == Alpha7Bit:
+		if fill := (7 - udhl%7) % 7; udhl > 0 && fill > 0 {
+			fb := bitFields(sm.Offset, sm.Raw[0], []bitDef{{"fill", fill - 1, 0, nil}})[0]
+			fb.Value = fmt.Sprintf("%d fill bits", fill)
+			if sm.Raw[0]&fb.Bits.Mask() != 0 {
+				fb.Value += ", non-zero"
+			}
+			sm.Children = append(sm.Children, fb)
+		}
+		septets, err := decode7Bit(udl, udhl, sm.Raw)
+		if err != nil {
+			sm.Err = err
+			break
+		}
+		sm.Value = decodeText(septets, udh, alpha)
+	case alpha == AlphaUCS2:
+		if len(sm.Raw)&0x01 == 0x01 {
+			sm.Err = ErrOddUCS2Length
+			break
+		}
+		sm.Value = decodeText(sm.Raw, udh, alpha)
+	default:
+		sm.Value = fmt.Sprintf("%d octets", len(sm.Raw))
+	}
+	if sm.Err != nil && f.Err == nil {
+		f.Err = DecodeError("sm", udhl, sm.Err)
+	}
+	f.Children = append(f.Children, sm)
+	d.add(f)
+}
+
+// decodeText returns the quoted UTF-8 form of the UD, or an empty string if
+// the UD cannot be decoded.
+func decodeText(ud UserData, udh UserDataHeader, alpha Alphabet) string {
+	dec, err := NewUDDecoder()
+	if err != nil {
+		return ""
+	}
+	dec.AddAllCharsets()
+	text, err := dec.Decode(ud, udh, alpha)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%q", text)
+}
+
+// dissectUDH dissects the UDH at the start of the UD in src, which is
+// located at offset off.
+func dissectUDH(off int, src []byte) Field {
+	if len(src) == 0 {
+		return Field{Name: "udh", Offset: off, Raw: src, Err: ErrUnderflow}
+	}
+	udhl := int(src[0])
+	end := 1 + udhl
+	f := Field{Name: "udh", Offset: off}
+	if end > len(src) {
+		end = len(src)
+		f.Err = ErrUnderflow
+	}
+	f.Raw = src[:end]
+	f.Length = end
+	f.Children = []Field{{Name: "udhl", Offset: off, Length: 1, Raw: src[:1],
+		Value: fmt.Sprintf("%d octets", udhl)}}
+	for ri := 1; ri < end; {
+		ie := Field{Name: "ie", Offset: off + ri}
+		id := src[ri]
+		ie.Children = []Field{{Name: "iei", Offset: off + ri, Length: 1, Raw: src[ri : ri+1],
+			Value: ieName(id)}}
+		if ri+2 > end {
+			ie.Raw = src[ri:end]
+			ie.Length = len(ie.Raw)
+			ie.Err = ErrUnderflow
+			f.Children = append(f.Children, ie)
+			f.Err = DecodeError("ie", ri, ErrUnderflow)
+			break
+		}
+		l := int(src[ri+1])
+		ie.Children = append(ie.Children, Field{Name: "iedl", Offset: off + ri + 1, Length: 1,
+			Raw: src[ri+1 : ri+2], Value: fmt.Sprintf("%d octets", l)})
+		iend := ri + 2 + l
+		if iend > end {
+			iend = end
+			ie.Err = ErrUnderflow
+		}
+		ied := Field{Name: "ied", Offset: off + ri + 2, Length: iend - ri - 2, Raw: src[ri+2 : iend]}
+		if ie.Err == nil {
+			t, err := defaultIERegistry.DecodeIE(InformationElement{ID: id, Data: ied.Raw})
+			if err != nil {
+				ied.Err = cause(err)
+			} else if _, ok := t.(*InformationElement); !ok {
+				ied.Value = strings.TrimPrefix(fmt.Sprintf("%+v", t), "&")
+			}
+		}
+		ie.Children = append(ie.Children, ied)
+		ie.Raw = src[ri:iend]
+		ie.Length = len(ie.Raw)
+		ie.Value = ieName(id)
+		if ie.Err != nil {
+			f.Children = append(f.Children, ie)
+			f.Err = DecodeError("ie", ri, ie.Err)
+			break
+		}
+		f.Children = append(f.Children, ie)
+		ri = iend
+	}
+	return f
+}
+
+func ieName(id byte) string {
+	if n, ok := ieNames[id]; ok {
+		return n
+	}
+	return fmt.Sprintf("unknown (0x%02x)", id)
+}
+
+// cause returns the error underlying a decodeError.
+func cause(err error) error {
+	if de, ok := err.(decodeError); ok {
+		return de.Err
+	}
+	return err
+}
+
+func (d *dissector) deliver() {
+	fo, ok := d.firstOctet(MT, deliverDefs)
+	if !ok {
+		return
+	}
+	d.address("oa")
+	d.octet("pid", describePID)
+	dcs, ok := d.octet("dcs", describeDCS)
+	if !ok {
+		return
+	}
+	d.timestamp("scts")
+	d.userData("udl", "ud", fo&udhiMask != 0, dcs)
+}
+
+func (d *dissector) submit() {
+	fo, ok := d.firstOctet(MO, submitDefs)
+	if !ok {
+		return
+	}
+	d.octet("mr", decimal)
+	d.address("da")
+	d.octet("pid", describePID)
+	dcs, ok := d.octet("dcs", describeDCS)
+	if !ok {
+		return
+	}
+	d.vp(ValidityPeriodFormat((fo >> 3) & 0x3))
+	d.userData("udl", "ud", fo&udhiMask != 0, dcs)
+}
+
+func (d *dissector) statusReport() {
+	fo, ok := d.firstOctet(MT, statusReportDefs)
+	if !ok {
+		return
+	}
+	d.octet("mr", decimal)
+	d.address("ra")
+	d.timestamp("scts")
+	d.timestamp("dt")
+	d.octet("st", func(b byte) string { return Status(b).String() })
+	if d.stopped || d.ri == len(d.src) {
+		return
+	}
+	d.optionals(fo)
+}
+
+func (d *dissector) command() {
+	fo, ok := d.firstOctet(MO, commandDefs)
+	if !ok {
+		return
+	}
+	d.octet("mr", decimal)
+	d.octet("pid", describePID)
+	d.octet("ct", func(b byte) string { return CommandType(b).String() })
+	d.octet("mn", decimal)
+	d.address("da")
+	d.userData("cdl", "cd", fo&udhiMask != 0, 0x04)
+}
+
+func (d *dissector) deliverReport() {
+	fo, ok := d.firstOctet(MO, reportDefs)
+	if !ok {
+		return
+	}
+	d.octet("fcs", func(b byte) string { return FailureCause(b).String() })
+	d.optionals(fo)
+}
+
+func (d *dissector) submitReport() {
+	fo, ok := d.firstOctet(MT, reportDefs)
+	if !ok {
+		return
+	}
+	d.octet("fcs", func(b byte) string { return FailureCause(b).String() })
+	pi, ok := d.pi()
+	if !ok {
+		return
+	}
+	d.timestamp("scts")
+	d.piFields(fo, pi)
+}
+
+// optionals dissects the PI and the optional fields it indicates.
+func (d *dissector) optionals(fo byte) {
+	pi, ok := d.pi()
+	if !ok {
+		return
+	}
+	d.piFields(fo, pi)
+}
+
+func (d *dissector) piFields(fo, pi byte) {
+	if pi&0x01 != 0 {
+		d.octet("pid", describePID)
+	}
+	var dcs byte
+	if pi&0x02 != 0 {
+		dcs, _ = d.octet("dcs", describeDCS)
+	}
+	if pi&0x04 != 0 {
+		d.userData("udl", "ud", fo&udhiMask != 0, dcs)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/bcd"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// flatten returns a summary of each field, and its children, in the form
+// name@offset+length=value.
+func flatten(fields []tpdu.Field, prefix string) []string {
+	var s []string
+	for _, f := range fields {
+		name := prefix + f.Name
+		l := fmt.Sprintf("%s@%d+%d=%s", name, f.Offset, f.Length, f.Value)
+		if f.Bits != nil {
+			l = fmt.Sprintf("%s@%d[%d:%d]=%s", name, f.Offset, f.Bits.High, f.Bits.Low, f.Value)
+		}
+		if f.Err != nil {
+			l += " !" + f.Err.Error()
+		}
+		s = append(s, l)
+		s = append(s, flatten(f.Children, name+".")...)
+	}
+	return s
+}
+
+func TestDissect(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		drn  tpdu.Direction
+		out  []string
+		err  error
+	}{
+		{"deliver",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"firstOctet.mti@0[1:0]=SMS-DELIVER",
+				"firstOctet.mms@0[2:2]=no more messages waiting",
+				"firstOctet.lp@0[3:3]=not forwarded or spawned",
+				"firstOctet.sri@0[5:5]=no status report requested",
+				"firstOctet.udhi@0[6:6]=no udh",
+				"firstOctet.rp@0[7:7]=no reply path",
+				"oa@1+4=+6391",
+				"oa.len@1+1=4 digits",
+				"oa.toa@2+1=0x91",
+				"oa.toa.ext@2[7:7]=1",
+				"oa.toa.ton@2[6:4]=international",
+				"oa.toa.npi@2[3:0]=isdn",
+				"oa.addr@3+2=6391",
+				"pid@5+1=sme-to-sme",
+				"dcs@6+1=general, 7bit",
+				"scts@7+7=2015-05-17 23:02:50 +08:00",
+				"scts.year@7+1=15",
+				"scts.month@8+1=05",
+				"scts.day@9+1=17",
+				"scts.hour@10+1=23",
+				"scts.minute@11+1=02",
+				"scts.second@12+1=50",
+				"scts.tz@13+1=32 quarter hours",
+				"udl@14+1=8 septets",
+				"ud@15+7=",
+				"ud.sm@15+7=\"Hahahaha\"",
+			},
+			nil},
+		{"submit",
+			[]byte{0x51, 0x23, 0x04, 0x91, 0x36, 0x19, 0x34, 0x00, 0x45, 0x0c,
+				0x05, 0x00, 0x03, 0x01, 0x02, 0x01, 0x90, 0x65, 0x36, 0xfb, 0x0d},
+			tpdu.MO,
+			[]string{
+				"firstOctet@0+1=0x51",
+				"firstOctet.mti@0[1:0]=SMS-SUBMIT",
+				"firstOctet.rd@0[2:2]=accept duplicates",
+				"firstOctet.vpf@0[4:3]=relative",
+				"firstOctet.srr@0[5:5]=no status report requested",
+				"firstOctet.udhi@0[6:6]=udh present",
+				"firstOctet.rp@0[7:7]=no reply path",
+				"mr@1+1=35",
+				"da@2+4=+6391",
+				"da.len@2+1=4 digits",
+				"da.toa@3+1=0x91",
+				"da.toa.ext@3[7:7]=1",
+				"da.toa.ton@3[6:4]=international",
+				"da.toa.npi@3[3:0]=isdn",
+				"da.addr@4+2=6391",
+				"pid@6+1=telematic, device 0x14",
+				"dcs@7+1=general, 7bit",
+				"vp@8+1=5h50m0s",
+				"udl@9+1=12 septets",
+				"ud@10+11=",
+				"ud.udh@10+6=",
+				"ud.udh.udhl@10+1=5 octets",
+				"ud.udh.ie@11+5=concatenated8",
+				"ud.udh.ie.iei@11+1=concatenated8",
+				"ud.udh.ie.iedl@12+1=3 octets",
+				"ud.udh.ie.ied@13+3={MR:1 Segments:2 SeqNo:1}",
+				"ud.sm@16+5=\"Hello\"",
+				"ud.sm.fill@16[0:0]=1 fill bits",
+			},
+			nil},
+		{"status report",
+			[]byte{0x06, 0x23, 0x04, 0x91, 0x36, 0x19,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x46},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x06",
+				"firstOctet.mti@0[1:0]=SMS-STATUS-REPORT",
+				"firstOctet.mms@0[2:2]=no more messages waiting",
+				"firstOctet.lp@0[3:3]=not forwarded or spawned",
+				"firstOctet.srq@0[5:5]=result of submit",
+				"firstOctet.udhi@0[6:6]=no udh",
+				"mr@1+1=35",
+				"ra@2+4=+6391",
+				"ra.len@2+1=4 digits",
+				"ra.toa@3+1=0x91",
+				"ra.toa.ext@3[7:7]=1",
+				"ra.toa.ton@3[6:4]=international",
+				"ra.toa.npi@3[3:0]=isdn",
+				"ra.addr@4+2=6391",
+				"scts@6+7=2015-05-17 23:02:50 +08:00",
+				"scts.year@6+1=15",
+				"scts.month@7+1=05",
+				"scts.day@8+1=17",
+				"scts.hour@9+1=23",
+				"scts.minute@10+1=02",
+				"scts.second@11+1=50",
+				"scts.tz@12+1=32 quarter hours",
+				"dt@13+7=2015-05-17 23:02:50 +08:00",
+				"dt.year@13+1=15",
+				"dt.month@14+1=05",
+				"dt.day@15+1=17",
+				"dt.hour@16+1=23",
+				"dt.minute@17+1=02",
+				"dt.second@18+1=50",
+				"dt.tz@19+1=32 quarter hours",
+				"st@20+1=SM validity period expired",
+			},
+			nil},
+		{"deliver report",
+			[]byte{0x00, 0xd0, 0x06, 0x00, 0x00},
+			tpdu.MO,
+			[]string{
+				"firstOctet@0+1=0x00",
+				"firstOctet.mti@0[1:0]=SMS-DELIVER-REPORT",
+				"firstOctet.udhi@0[6:6]=no udh",
+				"fcs@1+1=(U)SIM SMS storage full",
+				"pi@2+1=0x06",
+				"pi.pid@2[0:0]=no pid",
+				"pi.dcs@2[1:1]=dcs present",
+				"pi.udl@2[2:2]=udl present",
+				"pi.ext@2[7:7]=not extended",
+				"dcs@3+1=general, 7bit",
+				"udl@4+1=0 septets",
+			},
+			nil},
+		{"command",
+			[]byte{0x22, 0x01, 0x00, 0x00, 0x23, 0x04, 0x91, 0x36, 0x19, 0x00},
+			tpdu.MO,
+			[]string{
+				"firstOctet@0+1=0x22",
+				"firstOctet.mti@0[1:0]=SMS-COMMAND",
+				"firstOctet.srr@0[5:5]=status report requested",
+				"firstOctet.udhi@0[6:6]=no udh",
+				"mr@1+1=1",
+				"pid@2+1=sme-to-sme",
+				"ct@3+1=enquiry",
+				"mn@4+1=35",
+				"da@5+4=+6391",
+				"da.len@5+1=4 digits",
+				"da.toa@6+1=0x91",
+				"da.toa.ext@6[7:7]=1",
+				"da.toa.ton@6[6:4]=international",
+				"da.toa.npi@6[3:0]=isdn",
+				"da.addr@7+2=6391",
+				"cdl@9+1=0 octets",
+			},
+			nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			fields, err := tpdu.Dissect(p.in, p.drn)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, flatten(fields, ""))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDissectInvalid(t *testing.T) {
+	// only the top level fields are checked, as the partial fields are
+	// otherwise as per TestDissect.
+	patterns := []struct {
+		name string
+		in   []byte
+		drn  tpdu.Direction
+		out  []string
+		err  error
+	}{
+		{"empty",
+			nil,
+			tpdu.MT,
+			[]string{"firstOctet@0+0= !underflow"},
+			tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnderflow)},
+		{"reserved mti",
+			[]byte{0x03, 0x00},
+			tpdu.MO,
+			[]string{
+				"firstOctet@0+1=0x03 !unsupported MTI: 0x3",
+				"remaining@1+1=",
+			},
+			tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(3))},
+		{"short scts",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"oa@1+4=+6391",
+				"pid@5+1=sme-to-sme",
+				"dcs@6+1=general, 7bit",
+				"scts@7+3= !underflow",
+			},
+			tpdu.DecodeError("scts", 7, tpdu.ErrUnderflow)},
+		{"bad scts",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x2a, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"oa@1+4=+6391",
+				"pid@5+1=sme-to-sme",
+				"dcs@6+1=general, 7bit",
+				"scts@7+7= !bcd: invalid octet: 0x2a",
+				"udl@14+1=8 septets",
+				"ud@15+7=",
+			},
+			tpdu.DecodeError("scts", 7, bcd.ErrInvalidOctet(0x2a))},
+		{"bad scts trailing",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x2a, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3, 0xff},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"oa@1+4=+6391",
+				"pid@5+1=sme-to-sme",
+				"dcs@6+1=general, 7bit",
+				"scts@7+7= !bcd: invalid octet: 0x2a",
+				"udl@14+1=8 septets",
+				"ud@15+7=",
+				"trailing@22+1= !overlength",
+			},
+			tpdu.DecodeError("scts", 7, bcd.ErrInvalidOctet(0x2a))},
+		{"short ud",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x08, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x06, 0x00, 0x48, 0x00},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"oa@1+4=+6391",
+				"pid@5+1=sme-to-sme",
+				"dcs@6+1=general, ucs2",
+				"scts@7+7=2015-05-17 23:02:50 +08:00",
+				"udl@14+1=6 octets",
+				"ud@15+3= !underflow",
+			},
+			tpdu.DecodeError("ud", 15, tpdu.ErrUnderflow)},
+		{"udhi empty ud",
+			[]byte{0x40, 0x00, 0x80, 0x00, 0x00, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x05},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x40",
+				"oa@1+2=",
+				"pid@3+1=sme-to-sme",
+				"dcs@4+1=general, 7bit",
+				"scts@5+7=2011-11-11 11:11:11 +02:45",
+				"udl@12+1=5 septets",
+				"ud@13+0= !tpdu: error decoding udh at octet 0: underflow",
+			},
+			tpdu.DecodeError("ud.udh", 13, tpdu.ErrUnderflow)},
+		{"short oa",
+			[]byte{0x04, 0x04, 0x91, 0x36},
+			tpdu.MT,
+			[]string{
+				"firstOctet@0+1=0x04",
+				"oa@1+3= !tpdu: error decoding addr at octet 2: underflow",
+			},
+			tpdu.DecodeError("oa.addr", 3, tpdu.ErrUnderflow)},
+		{"trailing",
+			[]byte{0x00, 0x00, 0x00, 0xff},
+			tpdu.MO,
+			[]string{
+				"firstOctet@0+1=0x00",
+				"fcs@1+1=reserved (0x00)",
+				"pi@2+1=0x00",
+				"trailing@3+1= !overlength",
+			},
+			tpdu.DecodeError("trailing", 3, tpdu.ErrOverlength)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			fields, err := tpdu.Dissect(p.in, p.drn)
+			assert.Equal(t, p.err, err)
+			for i := range fields {
+				fields[i].Children = nil
+			}
+			assert.Equal(t, p.out, flatten(fields, ""))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestBitRangeMask(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.BitRange
+		out byte
+	}{
+		{tpdu.BitRange{Low: 0, High: 0}, 0x01},
+		{tpdu.BitRange{Low: 0, High: 1}, 0x03},
+		{tpdu.BitRange{Low: 3, High: 4}, 0x18},
+		{tpdu.BitRange{Low: 4, High: 6}, 0x70},
+		{tpdu.BitRange{Low: 7, High: 7}, 0x80},
+		{tpdu.BitRange{Low: 0, High: 7}, 0xff},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.Mask())
+		}
+		t.Run(fmt.Sprintf("%d-%d", p.in.Low, p.in.High), f)
+	}
+}
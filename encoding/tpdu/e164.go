@@ -0,0 +1,270 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/semioctet"
+)
+
+// AddressClass is the classification of an address parsed by ParseAddress.
+type AddressClass int
+
+const (
+	// AddressUnknown indicates a number that could not be classified, as
+	// no home country was provided.
+	AddressUnknown AddressClass = iota
+	// AddressInternational indicates an international number, including
+	// the country calling code.
+	AddressInternational
+	// AddressNational indicates a number within the home country.
+	AddressNational
+	// AddressShortCode indicates a short code within the home network.
+	AddressShortCode
+	// AddressAlphanumeric indicates an alphanumeric sender identity.
+	AddressAlphanumeric
+)
+
+var addressClassNames = map[AddressClass]string{
+	AddressUnknown:       "unknown",
+	AddressInternational: "international",
+	AddressNational:      "national",
+	AddressShortCode:     "shortCode",
+	AddressAlphanumeric:  "alphanumeric",
+}
+
+func (c AddressClass) String() string {
+	if s, ok := addressClassNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("AddressClass(%d)", int(c))
+}
+
+const (
+	// MaxAlphanumericLength is the maximum number of characters in an
+	// alphanumeric address.
+	MaxAlphanumericLength = 11
+
+	// maxE164Length is the maximum number of digits in an international
+	// number, as per ITU-T E.164.
+	maxE164Length = 15
+
+	// defaultMaxShortCodeLength is the default maximum number of digits in
+	// a short code.
+	defaultMaxShortCodeLength = 8
+)
+
+// addressParser contains the configuration for ParseAddress.
+type addressParser struct {
+	home         string
+	normalize    bool
+	maxShortCode int
+}
+
+// ParseOption modifies the behaviour of ParseAddress.
+type ParseOption func(*addressParser)
+
+// WithHomeCountry provides the country calling code of the home network,
+// e.g. "61", which is used to identify national numbers.
+func WithHomeCountry(code string) ParseOption {
+	return func(p *addressParser) {
+		p.home = code
+	}
+}
+
+// WithNormalization converts national numbers to international, using the
+// country calling code provided by WithHomeCountry.
+func WithNormalization() ParseOption {
+	return func(p *addressParser) {
+		p.normalize = true
+	}
+}
+
+// WithMaxShortCodeLength sets the maximum number of digits in a short code.
+// The default is 8.
+func WithMaxShortCodeLength(n int) ParseOption {
+	return func(p *addressParser) {
+		p.maxShortCode = n
+	}
+}
+
+// ParseAddress parses and classifies a user provided address, and returns
+// the corresponding Address and its AddressClass.
+//
+// The address is classified as:
+//   - international, if it is prefixed with '+', or the international
+//     prefix of the home country, or "00" if no home country is provided,
+//     and begins with an assigned country calling code.
+//   - national, if a home country is provided, and the address is prefixed
+//     with the trunk prefix and is a valid length for a national number.
+//   - short code, if it contains no more than the maximum number of digits
+//     for a short code.
+//   - national, if a home country is provided, and the address is a valid
+//     length for a national number.
+//   - alphanumeric, if it contains characters other than digits and
+//     formatting characters.
+//   - unknown, otherwise.
+//
+// Spaces, hyphens, dots and parentheses are ignored in numbers.
+// Alphanumeric addresses are limited to 11 characters from the GSM7 default
+// character set, excluding the extension table.
+//
+// National numbers are returned with the trunk prefix removed, or, if
+// WithNormalization is provided, are converted to international numbers.
+// The returned AddressClass reflects the address as provided, so is
+// AddressNational in either case.
+func ParseAddress(s string, opts ...ParseOption) (Address, AddressClass, error) {
+	p := addressParser{maxShortCode: defaultMaxShortCodeLength}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	var home CountryCode
+	if p.home != "" {
+		var ok bool
+		if home, ok = LookupCountryCode(p.home); !ok {
+			return Address{}, AddressUnknown, ErrUnknownCountryCode
+		}
+	}
+	if isAlphanumeric(s) {
+		a, err := parseAlphanumeric(s)
+		return a, AddressAlphanumeric, err
+	}
+	number := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -.()", r) {
+			return -1
+		}
+		return r
+	}, s)
+	if len(number) == 0 {
+		return Address{}, AddressUnknown, ErrUnderflow
+	}
+	if intl, ok := stripIntlPrefix(number, home); ok {
+		a, err := parseInternational(intl)
+		return a, AddressInternational, err
+	}
+	if i := strings.IndexAny(number, "+"); i != -1 {
+		return Address{}, AddressUnknown, semioctet.ErrInvalidDigit(number[i])
+	}
+	digits := strings.IndexAny(number, "*#") == -1
+	if digits && home.Code != "" && home.Trunk != "" && strings.HasPrefix(number, home.Trunk) {
+		if nsn := number[len(home.Trunk):]; home.validNSN(nsn) {
+			return p.national(home, nsn), AddressNational, nil
+		}
+	}
+	if len(number) <= p.maxShortCode {
+		return Address{TOA: 0x80 | byte(TonUnknown<<4) | byte(NpISDN), Addr: number},
+			AddressShortCode, nil
+	}
+	if !digits {
+		return Address{}, AddressUnknown, ErrInvalid
+	}
+	if home.Code != "" {
+		if !home.validNSN(number) {
+			return Address{}, AddressNational, ErrInvalid
+		}
+		return p.national(home, number), AddressNational, nil
+	}
+	if len(number) > maxE164Length {
+		return Address{}, AddressUnknown, ErrOverlength
+	}
+	return Address{TOA: 0x80 | byte(TonUnknown<<4) | byte(NpISDN), Addr: number},
+		AddressUnknown, nil
+}
+
+// national returns the Address for the national significant number.
+func (p *addressParser) national(home CountryCode, nsn string) Address {
+	if p.normalize {
+		return intlAddress(home.Code + nsn)
+	}
+	return Address{TOA: 0x80 | byte(TonNational<<4) | byte(NpISDN), Addr: nsn}
+}
+
+// isAlphanumeric returns true if s contains characters other than those
+// permitted in a number.
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("+0123456789*# -.()", r) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAlphanumeric(s string) (Address, error) {
+	if utf8.RuneCountInString(s) > MaxAlphanumericLength {
+		return Address{}, ErrOverlength
+	}
+	e := gsm7.NewEncoder().WithExtCharset(nil) // without escapes
+	if _, err := e.Encode([]byte(s)); err != nil {
+		return Address{}, err
+	}
+	return Address{TOA: 0x80 | byte(TonAlphanumeric<<4) | byte(NpUnknown), Addr: s}, nil
+}
+
+// stripIntlPrefix returns the number without its international prefix.
+// The "00" prefix is only assumed if there is no home country, as otherwise
+// the home country determines the prefix.
+// Returns false if the number has no international prefix.
+func stripIntlPrefix(number string, home CountryCode) (string, bool) {
+	if strings.HasPrefix(number, "+") {
+		return number[1:], true
+	}
+	if home.Code != "" {
+		if strings.HasPrefix(number, home.IntlPrefix) {
+			return number[len(home.IntlPrefix):], true
+		}
+		return "", false
+	}
+	if strings.HasPrefix(number, "00") {
+		return number[2:], true
+	}
+	return "", false
+}
+
+func parseInternational(number string) (Address, error) {
+	for i := 0; i < len(number); i++ {
+		if number[i] < '0' || number[i] > '9' {
+			return Address{}, semioctet.ErrInvalidDigit(number[i])
+		}
+	}
+	if len(number) == 0 {
+		return Address{}, ErrUnderflow
+	}
+	cc, ok := MatchCountryCode(number)
+	if !ok {
+		return Address{}, ErrUnknownCountryCode
+	}
+	nsn := len(number) - len(cc.Code)
+	if nsn < cc.MinNSN {
+		return Address{}, ErrUnderflow
+	}
+	if nsn > cc.MaxNSN || len(number) > maxE164Length {
+		return Address{}, ErrOverlength
+	}
+	return intlAddress(number), nil
+}
+
+func intlAddress(number string) Address {
+	return Address{TOA: 0x80 | byte(TonInternational<<4) | byte(NpISDN), Addr: number}
+}
+
+// validNSN returns true if the national significant number is a valid
+// length for the country.
+func (cc CountryCode) validNSN(nsn string) bool {
+	return len(nsn) >= cc.MinNSN && len(nsn) <= cc.MaxNSN
+}
+
+var (
+	// ErrUnknownCountryCode indicates the number does not begin with an
+	// assigned country calling code, or the home country code is not
+	// assigned.
+	ErrUnknownCountryCode = errors.New("unknown country code")
+)
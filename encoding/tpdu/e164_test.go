@@ -0,0 +1,265 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestParseAddress(t *testing.T) {
+	patterns := []struct {
+		name  string
+		in    string
+		opts  []tpdu.ParseOption
+		out   tpdu.Address
+		class tpdu.AddressClass
+		err   error
+	}{
+		{"international",
+			"+61 409 865 629",
+			nil,
+			tpdu.Address{TOA: 0x91, Addr: "61409865629"},
+			tpdu.AddressInternational,
+			nil},
+		{"international 00",
+			"0044 20 7946 0123",
+			nil,
+			tpdu.Address{TOA: 0x91, Addr: "442079460123"},
+			tpdu.AddressInternational,
+			nil},
+		{"international home prefix",
+			"011 61 409 865 629",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("1")},
+			tpdu.Address{TOA: 0x91, Addr: "61409865629"},
+			tpdu.AddressInternational,
+			nil},
+		{"00 not home prefix",
+			"0044 20 7946 0123",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("1")},
+			tpdu.Address{},
+			tpdu.AddressNational,
+			tpdu.ErrInvalid},
+		{"international formatted",
+			"+1 (415) 555-0123",
+			nil,
+			tpdu.Address{TOA: 0x91, Addr: "14155550123"},
+			tpdu.AddressInternational,
+			nil},
+		{"international unknown cc",
+			"+999 1234 5678",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressInternational,
+			tpdu.ErrUnknownCountryCode},
+		{"international short",
+			"+61 4098",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressInternational,
+			tpdu.ErrUnderflow},
+		{"international long",
+			"+61 409 865 6290",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressInternational,
+			tpdu.ErrOverlength},
+		{"international invalid digit",
+			"+61 409 865 62#",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressInternational,
+			semioctet.ErrInvalidDigit('#')},
+		{"international empty",
+			"+",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressInternational,
+			tpdu.ErrUnderflow},
+		{"national trunk",
+			"0409 865 629",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("61")},
+			tpdu.Address{TOA: 0xa1, Addr: "409865629"},
+			tpdu.AddressNational,
+			nil},
+		{"national",
+			"409865629",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("61")},
+			tpdu.Address{TOA: 0xa1, Addr: "409865629"},
+			tpdu.AddressNational,
+			nil},
+		{"national normalized",
+			"0409 865 629",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("61"), tpdu.WithNormalization()},
+			tpdu.Address{TOA: 0x91, Addr: "61409865629"},
+			tpdu.AddressNational,
+			nil},
+		{"national nanp",
+			"1 415 555 0123",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("1"), tpdu.WithNormalization()},
+			tpdu.Address{TOA: 0x91, Addr: "14155550123"},
+			tpdu.AddressNational,
+			nil},
+		{"national invalid length",
+			"04098656291",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("61")},
+			tpdu.Address{},
+			tpdu.AddressNational,
+			tpdu.ErrInvalid},
+		{"unknown home",
+			"0409 865 629",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("999")},
+			tpdu.Address{},
+			tpdu.AddressUnknown,
+			tpdu.ErrUnknownCountryCode},
+		{"short code",
+			"131313",
+			[]tpdu.ParseOption{tpdu.WithHomeCountry("61")},
+			tpdu.Address{TOA: 0x81, Addr: "131313"},
+			tpdu.AddressShortCode,
+			nil},
+		{"short code service",
+			"*100#",
+			nil,
+			tpdu.Address{TOA: 0x81, Addr: "*100#"},
+			tpdu.AddressShortCode,
+			nil},
+		{"short code max",
+			"1234567",
+			[]tpdu.ParseOption{tpdu.WithMaxShortCodeLength(6)},
+			tpdu.Address{TOA: 0x81, Addr: "1234567"},
+			tpdu.AddressUnknown,
+			nil},
+		{"unknown",
+			"0409865629",
+			nil,
+			tpdu.Address{TOA: 0x81, Addr: "0409865629"},
+			tpdu.AddressUnknown,
+			nil},
+		{"unknown long",
+			"1234567890123456",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressUnknown,
+			tpdu.ErrOverlength},
+		{"unknown invalid",
+			"12345*67890",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressUnknown,
+			tpdu.ErrInvalid},
+		{"embedded plus",
+			"0409+865629",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressUnknown,
+			semioctet.ErrInvalidDigit('+')},
+		{"empty",
+			" - ",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressUnknown,
+			tpdu.ErrUnderflow},
+		{"alphanumeric",
+			"Vodafone",
+			nil,
+			tpdu.Address{TOA: 0xd0, Addr: "Vodafone"},
+			tpdu.AddressAlphanumeric,
+			nil},
+		{"alphanumeric max",
+			"Hello World",
+			nil,
+			tpdu.Address{TOA: 0xd0, Addr: "Hello World"},
+			tpdu.AddressAlphanumeric,
+			nil},
+		{"alphanumeric long",
+			"Hello World!",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressAlphanumeric,
+			tpdu.ErrOverlength},
+		{"alphanumeric ext",
+			"Euro€",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressAlphanumeric,
+			gsm7.ErrInvalidUTF8('€')},
+		{"alphanumeric invalid",
+			"Привет",
+			nil,
+			tpdu.Address{},
+			tpdu.AddressAlphanumeric,
+			gsm7.ErrInvalidUTF8('П')},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			a, c, err := tpdu.ParseAddress(p.in, p.opts...)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.class, c)
+			assert.Equal(t, p.out, a)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestAddressClassString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.AddressClass
+		out string
+	}{
+		{tpdu.AddressUnknown, "unknown"},
+		{tpdu.AddressInternational, "international"},
+		{tpdu.AddressNational, "national"},
+		{tpdu.AddressShortCode, "shortCode"},
+		{tpdu.AddressAlphanumeric, "alphanumeric"},
+		{tpdu.AddressClass(42), "AddressClass(42)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
+
+func TestLookupCountryCode(t *testing.T) {
+	cc, ok := tpdu.LookupCountryCode("61")
+	assert.True(t, ok)
+	assert.Equal(t, "61", cc.Code)
+	assert.Contains(t, cc.Regions, "AU")
+	assert.Equal(t, "0", cc.Trunk)
+	assert.Equal(t, "0011", cc.IntlPrefix)
+	cc, ok = tpdu.LookupCountryCode("999")
+	assert.False(t, ok)
+	assert.Equal(t, tpdu.CountryCode{}, cc)
+}
+
+func TestMatchCountryCode(t *testing.T) {
+	patterns := []struct {
+		in   string
+		code string
+		ok   bool
+	}{
+		{"14155550123", "1", true},
+		{"61409865629", "61", true},
+		{"35312345678", "353", true},
+		{"79161234567", "7", true},
+		{"99912345678", "", false},
+		{"", "", false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			cc, ok := tpdu.MatchCountryCode(p.in)
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.code, cc.Code)
+		}
+		t.Run(p.in, f)
+	}
+}
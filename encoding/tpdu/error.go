@@ -77,6 +77,9 @@ var (
 	// ErrNonZero indicates a field which is expected to be zeroed, but contains
 	// non-zero data.
 	ErrNonZero = errors.New("non-zero fill")
+	// ErrNotPDU indicates a registered ConcreteDecoder returned a TPDU that
+	// does not implement the PDU interface.
+	ErrNotPDU = errors.New("not a PDU")
 	// ErrUnderflow indicates the binary provided does not contain
 	// sufficient bytes to correctly decode the TPDU.
 	ErrUnderflow = errors.New("underflow")
@@ -0,0 +1,977 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/warthog618/sms/encoding/gsm7/charset"
+)
+
+// Information Element Identifiers, as defined in 3GPP TS 23.040 Section 9.2.3.24.
+const (
+	// IEIConcatenated8 identifies a concatenated short message with an 8bit reference.
+	IEIConcatenated8 byte = 0x00
+	// IEISpecialSMSIndication identifies a special SMS message indication.
+	IEISpecialSMSIndication byte = 0x01
+	// IEIPort8 identifies application port addressing with 8bit ports.
+	IEIPort8 byte = 0x04
+	// IEIPort16 identifies application port addressing with 16bit ports.
+	IEIPort16 byte = 0x05
+	// IEISMSCControlParameters identifies SMSC control parameters.
+	IEISMSCControlParameters byte = 0x06
+	// IEIUDHSourceIndicator identifies the source of the subsequent IEs.
+	IEIUDHSourceIndicator byte = 0x07
+	// IEIConcatenated16 identifies a concatenated short message with a 16bit reference.
+	IEIConcatenated16 byte = 0x08
+	// IEIWirelessControlMessageProtocol identifies a WCMP message.
+	IEIWirelessControlMessageProtocol byte = 0x09
+	// IEITextFormatting identifies EMS text formatting.
+	IEITextFormatting byte = 0x0a
+	// IEIPredefinedSound identifies an EMS predefined sound.
+	IEIPredefinedSound byte = 0x0b
+	// IEIUserDefinedSound identifies an EMS user defined sound (iMelody).
+	IEIUserDefinedSound byte = 0x0c
+	// IEIPredefinedAnimation identifies an EMS predefined animation.
+	IEIPredefinedAnimation byte = 0x0d
+	// IEILargeAnimation identifies an EMS large animation (4 x 32x32 pixels).
+	IEILargeAnimation byte = 0x0e
+	// IEISmallAnimation identifies an EMS small animation (4 x 16x16 pixels).
+	IEISmallAnimation byte = 0x0f
+	// IEILargePicture identifies an EMS large picture (32x32 pixels).
+	IEILargePicture byte = 0x10
+	// IEISmallPicture identifies an EMS small picture (16x16 pixels).
+	IEISmallPicture byte = 0x11
+	// IEIVariablePicture identifies an EMS variable sized picture.
+	IEIVariablePicture byte = 0x12
+	// IEIUserPromptIndicator identifies an EMS user prompt indicator.
+	IEIUserPromptIndicator byte = 0x13
+	// IEIExtendedObject identifies an EMS extended object.
+	IEIExtendedObject byte = 0x14
+	// IEIReusedExtendedObject identifies an EMS reused extended object.
+	IEIReusedExtendedObject byte = 0x15
+	// IEICompressionControl identifies EMS compression control.
+	IEICompressionControl byte = 0x16
+	// IEIObjectDistributionIndicator identifies an EMS object distribution indicator.
+	IEIObjectDistributionIndicator byte = 0x17
+	// IEIStandardWVGObject identifies an EMS standard WVG object.
+	IEIStandardWVGObject byte = 0x18
+	// IEICharacterSizeWVGObject identifies an EMS character size WVG object.
+	IEICharacterSizeWVGObject byte = 0x19
+	// IEIExtendedObjectDataRequest identifies an EMS extended object data request command.
+	IEIExtendedObjectDataRequest byte = 0x1a
+	// IEIRFC822EmailHeader identifies an RFC 822 E-Mail header.
+	IEIRFC822EmailHeader byte = 0x20
+	// IEIHyperlinkFormat identifies a hyperlink format element.
+	IEIHyperlinkFormat byte = 0x21
+	// IEIReplyAddress identifies a reply address element.
+	IEIReplyAddress byte = 0x22
+	// IEIEnhancedVoiceMailInformation identifies enhanced voice mail information.
+	IEIEnhancedVoiceMailInformation byte = 0x23
+	// IEINationalLanguageSingleShift identifies the national language single
+	// shift table used to encode GSM7 user data.
+	IEINationalLanguageSingleShift byte = 0x24
+	// IEINationalLanguageLockingShift identifies the national language locking
+	// shift table used to encode GSM7 user data.
+	IEINationalLanguageLockingShift byte = 0x25
+	// IEICommandPacket identifies a (U)SIM toolkit secured command packet,
+	// i.e. the Command Packet Identifier (CPI) of 3GPP TS 31.115.
+	IEICommandPacket byte = 0x70
+	// IEIResponsePacket identifies a (U)SIM toolkit secured response packet,
+	// i.e. the Response Packet Identifier (RPI) of 3GPP TS 31.115.
+	IEIResponsePacket byte = 0x71
+)
+
+// TypedIE is an Information Element decoded from the raw IED into a concrete type.
+//
+// MarshalIED and UnmarshalIED convert to and from the IED only, i.e. excluding
+// the IEI and IEDL which are added by the UserDataHeader.
+type TypedIE interface {
+	IEI() byte
+	MarshalIED() ([]byte, error)
+	UnmarshalIED(src []byte) error
+}
+
+// IEI returns the identifier of the InformationElement.
+// This allows InformationElement to be used as a TypedIE, which is how
+// unrecognised IEs are preserved.
+func (ie InformationElement) IEI() byte {
+	return ie.ID
+}
+
+// MarshalIED returns the raw IED.
+func (ie InformationElement) MarshalIED() ([]byte, error) {
+	return ie.Data, nil
+}
+
+// UnmarshalIED copies the raw IED into the InformationElement.
+func (ie *InformationElement) UnmarshalIED(src []byte) error {
+	ie.Data = append([]byte(nil), src...)
+	return nil
+}
+
+// NewInformationElement marshals a TypedIE into a raw InformationElement.
+func NewInformationElement(t TypedIE) (InformationElement, error) {
+	d, err := t.MarshalIED()
+	if err != nil {
+		return InformationElement{}, err
+	}
+	if len(d) > 0xff {
+		return InformationElement{}, ErrOverlength
+	}
+	return InformationElement{ID: t.IEI(), Data: d}, nil
+}
+
+// NewUserDataHeader marshals a set of TypedIEs into a UserDataHeader.
+// The IEs are added to the UDH in the order provided.
+func NewUserDataHeader(ies ...TypedIE) (UserDataHeader, error) {
+	if len(ies) == 0 {
+		return nil, nil
+	}
+	udh := make(UserDataHeader, 0, len(ies))
+	for _, t := range ies {
+		ie, err := NewInformationElement(t)
+		if err != nil {
+			return nil, EncodeError("ie", err)
+		}
+		udh = append(udh, ie)
+	}
+	return udh, nil
+}
+
+// IEConstructor returns a new zeroed instance of a TypedIE, ready to be
+// unmarshalled.
+type IEConstructor func() TypedIE
+
+// IERegistry maps IEIs to the TypedIEs used to decode them.
+type IERegistry struct {
+	mu sync.RWMutex // covers c
+	c  map[byte]IEConstructor
+}
+
+// NewIERegistry creates an IERegistry populated with the TypedIEs provided
+// by this package.
+func NewIERegistry() *IERegistry {
+	r := &IERegistry{c: make(map[byte]IEConstructor, len(standardIEs))}
+	for id, c := range standardIEs {
+		r.c[id] = c
+	}
+	return r
+}
+
+// Register adds a TypedIE to the registry, replacing any existing
+// TypedIE for that IEI.
+func (r *IERegistry) Register(id byte, c IEConstructor) {
+	r.mu.Lock()
+	r.c[id] = c
+	r.mu.Unlock()
+}
+
+// Unregister removes the TypedIE for the IEI from the registry, so that IEs
+// with that IEI are subsequently decoded as raw InformationElements.
+func (r *IERegistry) Unregister(id byte) {
+	r.mu.Lock()
+	delete(r.c, id)
+	r.mu.Unlock()
+}
+
+// DecodeIE converts a raw InformationElement into the corresponding TypedIE.
+// If the IEI is not registered then a copy of the InformationElement is
+// returned.
+func (r *IERegistry) DecodeIE(ie InformationElement) (TypedIE, error) {
+	r.mu.RLock()
+	c, ok := r.c[ie.ID]
+	r.mu.RUnlock()
+	var t TypedIE = &InformationElement{ID: ie.ID}
+	if ok {
+		t = c()
+	}
+	if err := t.UnmarshalIED(ie.Data); err != nil {
+		return nil, DecodeError("ied", 0, err)
+	}
+	return t, nil
+}
+
+// Decode converts all the IEs in the UserDataHeader into the corresponding
+// TypedIEs, in the order they appear in the UDH.
+func (r *IERegistry) Decode(udh UserDataHeader) ([]TypedIE, error) {
+	if len(udh) == 0 {
+		return nil, nil
+	}
+	ties := make([]TypedIE, 0, len(udh))
+	for i, ie := range udh {
+		t, err := r.DecodeIE(ie)
+		if err != nil {
+			return nil, DecodeError("ie", i, err)
+		}
+		ties = append(ties, t)
+	}
+	return ties, nil
+}
+
+var defaultIERegistry = NewIERegistry()
+
+// TypedIEs converts the IEs in the UDH into TypedIEs using the standard
+// set of TypedIEs provided by this package.
+// Unrecognised IEs are returned as *InformationElement.
+func (udh UserDataHeader) TypedIEs() ([]TypedIE, error) {
+	return defaultIERegistry.Decode(udh)
+}
+
+var standardIEs = map[byte]IEConstructor{
+	IEIConcatenated8:                func() TypedIE { return &Concatenated8IE{} },
+	IEISpecialSMSIndication:         func() TypedIE { return &SpecialSMSIndicationIE{} },
+	IEIPort8:                        func() TypedIE { return &Port8IE{} },
+	IEIPort16:                       func() TypedIE { return &Port16IE{} },
+	IEISMSCControlParameters:        func() TypedIE { return &SMSCControlParametersIE{} },
+	IEIUDHSourceIndicator:           func() TypedIE { return &UDHSourceIndicatorIE{} },
+	IEIConcatenated16:               func() TypedIE { return &Concatenated16IE{} },
+	IEITextFormatting:               func() TypedIE { return &TextFormattingIE{} },
+	IEIPredefinedSound:              func() TypedIE { return &PredefinedSoundIE{} },
+	IEIUserDefinedSound:             func() TypedIE { return &UserDefinedSoundIE{} },
+	IEIPredefinedAnimation:          func() TypedIE { return &PredefinedAnimationIE{} },
+	IEILargeAnimation:               func() TypedIE { return &LargeAnimationIE{} },
+	IEISmallAnimation:               func() TypedIE { return &SmallAnimationIE{} },
+	IEILargePicture:                 func() TypedIE { return &LargePictureIE{} },
+	IEISmallPicture:                 func() TypedIE { return &SmallPictureIE{} },
+	IEIVariablePicture:              func() TypedIE { return &VariablePictureIE{} },
+	IEIUserPromptIndicator:          func() TypedIE { return &UserPromptIndicatorIE{} },
+	IEIRFC822EmailHeader:            func() TypedIE { return &RFC822EmailHeaderIE{} },
+	IEIHyperlinkFormat:              func() TypedIE { return &HyperlinkFormatIE{} },
+	IEIReplyAddress:                 func() TypedIE { return &ReplyAddressIE{} },
+	IEINationalLanguageSingleShift:  func() TypedIE { return &NationalLanguageSingleShiftIE{} },
+	IEINationalLanguageLockingShift: func() TypedIE { return &NationalLanguageLockingShiftIE{} },
+}
+
+// checkIEDL returns an error if the length of the IED is not l.
+func checkIEDL(src []byte, l int) error {
+	if len(src) < l {
+		return ErrUnderflow
+	}
+	if len(src) > l {
+		return ErrOverlength
+	}
+	return nil
+}
+
+// Concatenated8IE is a concatenated short message IE with an 8bit reference
+// number, as defined in 3GPP TS 23.040 Section 9.2.3.24.1.
+type Concatenated8IE struct {
+	MR       uint8 `json:"mr"`
+	Segments int   `json:"segments"`
+	SeqNo    int   `json:"seqNo"`
+}
+
+// IEI returns IEIConcatenated8.
+func (c Concatenated8IE) IEI() byte {
+	return IEIConcatenated8
+}
+
+// MarshalIED marshals the IE into binary.
+func (c Concatenated8IE) MarshalIED() ([]byte, error) {
+	if c.Segments < 0 || c.Segments > 0xff || c.SeqNo < 0 || c.SeqNo > 0xff {
+		return nil, ErrInvalid
+	}
+	return []byte{c.MR, byte(c.Segments), byte(c.SeqNo)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (c *Concatenated8IE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 3); err != nil {
+		return err
+	}
+	c.MR = src[0]
+	c.Segments = int(src[1])
+	c.SeqNo = int(src[2])
+	return nil
+}
+
+// Concatenated16IE is a concatenated short message IE with a 16bit reference
+// number, as defined in 3GPP TS 23.040 Section 9.2.3.24.8.
+type Concatenated16IE struct {
+	MR       uint16 `json:"mr"`
+	Segments int    `json:"segments"`
+	SeqNo    int    `json:"seqNo"`
+}
+
+// IEI returns IEIConcatenated16.
+func (c Concatenated16IE) IEI() byte {
+	return IEIConcatenated16
+}
+
+// MarshalIED marshals the IE into binary.
+func (c Concatenated16IE) MarshalIED() ([]byte, error) {
+	if c.Segments < 0 || c.Segments > 0xff || c.SeqNo < 0 || c.SeqNo > 0xff {
+		return nil, ErrInvalid
+	}
+	b := []byte{0, 0, byte(c.Segments), byte(c.SeqNo)}
+	binary.BigEndian.PutUint16(b, c.MR)
+	return b, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (c *Concatenated16IE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 4); err != nil {
+		return err
+	}
+	c.MR = binary.BigEndian.Uint16(src)
+	c.Segments = int(src[2])
+	c.SeqNo = int(src[3])
+	return nil
+}
+
+// SpecialSMSIndicationIE indicates messages waiting, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.2.
+type SpecialSMSIndicationIE struct {
+	// Store indicates the message should be stored rather than discarded
+	// after the indication has been updated.
+	Store bool `json:"store"`
+	// ProfileID identifies the multiple subscriber profile (0-3).
+	ProfileID int `json:"profileID"`
+	// ExtendedType is the extended message indication type, where 0 means
+	// no extended type and 1 is a video message.
+	ExtendedType int `json:"extendedType"`
+	// Type is the basic message indication type - 0 voice, 1 fax,
+	// 2 electronic mail, 3 other.
+	Type int `json:"type"`
+	// Count is the number of messages waiting.
+	Count int `json:"count"`
+}
+
+// IEI returns IEISpecialSMSIndication.
+func (s SpecialSMSIndicationIE) IEI() byte {
+	return IEISpecialSMSIndication
+}
+
+// MarshalIED marshals the IE into binary.
+func (s SpecialSMSIndicationIE) MarshalIED() ([]byte, error) {
+	if s.ProfileID < 0 || s.ProfileID > 3 ||
+		s.ExtendedType < 0 || s.ExtendedType > 7 ||
+		s.Type < 0 || s.Type > 3 ||
+		s.Count < 0 || s.Count > 0xff {
+		return nil, ErrInvalid
+	}
+	ind := byte(s.ProfileID<<5 | s.ExtendedType<<2 | s.Type)
+	if s.Store {
+		ind |= 0x80
+	}
+	return []byte{ind, byte(s.Count)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (s *SpecialSMSIndicationIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 2); err != nil {
+		return err
+	}
+	s.Store = src[0]&0x80 != 0
+	s.ProfileID = int(src[0]>>5) & 0x3
+	s.ExtendedType = int(src[0]>>2) & 0x7
+	s.Type = int(src[0] & 0x3)
+	s.Count = int(src[1])
+	return nil
+}
+
+// Port8IE is an application port addressing IE with 8bit ports, as defined
+// in 3GPP TS 23.040 Section 9.2.3.24.3.
+type Port8IE struct {
+	Dst uint8 `json:"dst"`
+	Src uint8 `json:"src"`
+}
+
+// IEI returns IEIPort8.
+func (p Port8IE) IEI() byte {
+	return IEIPort8
+}
+
+// MarshalIED marshals the IE into binary.
+func (p Port8IE) MarshalIED() ([]byte, error) {
+	return []byte{p.Dst, p.Src}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (p *Port8IE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 2); err != nil {
+		return err
+	}
+	p.Dst = src[0]
+	p.Src = src[1]
+	return nil
+}
+
+// Port16IE is an application port addressing IE with 16bit ports, as defined
+// in 3GPP TS 23.040 Section 9.2.3.24.4.
+type Port16IE struct {
+	Dst uint16 `json:"dst"`
+	Src uint16 `json:"src"`
+}
+
+// IEI returns IEIPort16.
+func (p Port16IE) IEI() byte {
+	return IEIPort16
+}
+
+// MarshalIED marshals the IE into binary.
+func (p Port16IE) MarshalIED() ([]byte, error) {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b, p.Dst)
+	binary.BigEndian.PutUint16(b[2:], p.Src)
+	return b, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (p *Port16IE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 4); err != nil {
+		return err
+	}
+	p.Dst = binary.BigEndian.Uint16(src)
+	p.Src = binary.BigEndian.Uint16(src[2:])
+	return nil
+}
+
+// SMSCControlParametersIE contains the selective status report flags, as
+// defined in 3GPP TS 23.040 Section 9.2.3.24.6.
+type SMSCControlParametersIE struct {
+	SelectiveStatusReport byte `json:"selectiveStatusReport"`
+}
+
+// IEI returns IEISMSCControlParameters.
+func (s SMSCControlParametersIE) IEI() byte {
+	return IEISMSCControlParameters
+}
+
+// MarshalIED marshals the IE into binary.
+func (s SMSCControlParametersIE) MarshalIED() ([]byte, error) {
+	return []byte{s.SelectiveStatusReport}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (s *SMSCControlParametersIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	s.SelectiveStatusReport = src[0]
+	return nil
+}
+
+// UDHSourceIndicatorIE identifies the originator of the IEs that follow it,
+// as defined in 3GPP TS 23.040 Section 9.2.3.24.7.
+type UDHSourceIndicatorIE struct {
+	// Source is 1 for the sender, 2 for the receiver and 3 for the SMSC.
+	Source byte `json:"source"`
+}
+
+// IEI returns IEIUDHSourceIndicator.
+func (s UDHSourceIndicatorIE) IEI() byte {
+	return IEIUDHSourceIndicator
+}
+
+// MarshalIED marshals the IE into binary.
+func (s UDHSourceIndicatorIE) MarshalIED() ([]byte, error) {
+	return []byte{s.Source}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (s *UDHSourceIndicatorIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	s.Source = src[0]
+	return nil
+}
+
+// TextFormattingIE is the EMS text formatting IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.1.
+type TextFormattingIE struct {
+	// Start is the position of the first formatted character in the SM.
+	Start int `json:"start"`
+	// Length is the number of formatted characters.
+	Length int `json:"length"`
+	// Format contains the alignment, font size and style bits.
+	Format byte `json:"format"`
+	// Colour is the optional foreground (bits 0-3) and background (bits 4-7)
+	// colour, and is only encoded if HasColour is set.
+	Colour    byte `json:"colour"`
+	HasColour bool `json:"hasColour"`
+}
+
+// IEI returns IEITextFormatting.
+func (f TextFormattingIE) IEI() byte {
+	return IEITextFormatting
+}
+
+// MarshalIED marshals the IE into binary.
+func (f TextFormattingIE) MarshalIED() ([]byte, error) {
+	if f.Start < 0 || f.Start > 0xff || f.Length < 0 || f.Length > 0xff {
+		return nil, ErrInvalid
+	}
+	b := []byte{byte(f.Start), byte(f.Length), f.Format}
+	if f.HasColour {
+		b = append(b, f.Colour)
+	}
+	return b, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (f *TextFormattingIE) UnmarshalIED(src []byte) error {
+	if len(src) < 3 {
+		return ErrUnderflow
+	}
+	if len(src) > 4 {
+		return ErrOverlength
+	}
+	f.Start = int(src[0])
+	f.Length = int(src[1])
+	f.Format = src[2]
+	f.HasColour = len(src) == 4
+	f.Colour = 0
+	if f.HasColour {
+		f.Colour = src[3]
+	}
+	return nil
+}
+
+// PredefinedSoundIE is the EMS predefined sound IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.2.
+type PredefinedSoundIE struct {
+	Position int `json:"position"`
+	Sound    int `json:"sound"`
+}
+
+// IEI returns IEIPredefinedSound.
+func (s PredefinedSoundIE) IEI() byte {
+	return IEIPredefinedSound
+}
+
+// MarshalIED marshals the IE into binary.
+func (s PredefinedSoundIE) MarshalIED() ([]byte, error) {
+	return marshalEMSPredefined(s.Position, s.Sound)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (s *PredefinedSoundIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 2); err != nil {
+		return err
+	}
+	s.Position = int(src[0])
+	s.Sound = int(src[1])
+	return nil
+}
+
+// PredefinedAnimationIE is the EMS predefined animation IE, as defined in
+// 3GPP TS 23.040 Section 9.2.3.24.10.1.4.
+type PredefinedAnimationIE struct {
+	Position  int `json:"position"`
+	Animation int `json:"animation"`
+}
+
+// IEI returns IEIPredefinedAnimation.
+func (a PredefinedAnimationIE) IEI() byte {
+	return IEIPredefinedAnimation
+}
+
+// MarshalIED marshals the IE into binary.
+func (a PredefinedAnimationIE) MarshalIED() ([]byte, error) {
+	return marshalEMSPredefined(a.Position, a.Animation)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (a *PredefinedAnimationIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 2); err != nil {
+		return err
+	}
+	a.Position = int(src[0])
+	a.Animation = int(src[1])
+	return nil
+}
+
+func marshalEMSPredefined(pos, n int) ([]byte, error) {
+	if pos < 0 || pos > 0xff || n < 0 || n > 0xff {
+		return nil, ErrInvalid
+	}
+	return []byte{byte(pos), byte(n)}, nil
+}
+
+// UserDefinedSoundIE is the EMS user defined sound IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.3.
+// The Data contains the sound in iMelody format, and is limited to 128 octets.
+type UserDefinedSoundIE struct {
+	Position int    `json:"position"`
+	Data     []byte `json:"data"`
+}
+
+// IEI returns IEIUserDefinedSound.
+func (s UserDefinedSoundIE) IEI() byte {
+	return IEIUserDefinedSound
+}
+
+// MarshalIED marshals the IE into binary.
+func (s UserDefinedSoundIE) MarshalIED() ([]byte, error) {
+	if len(s.Data) > 128 {
+		return nil, ErrOverlength
+	}
+	return marshalEMSObject(s.Position, s.Data, len(s.Data))
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (s *UserDefinedSoundIE) UnmarshalIED(src []byte) error {
+	if len(src) < 1 {
+		return ErrUnderflow
+	}
+	if len(src) > 129 {
+		return ErrOverlength
+	}
+	s.Position = int(src[0])
+	s.Data = append([]byte(nil), src[1:]...)
+	return nil
+}
+
+// LargeAnimationIE is the EMS large animation IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.5.
+// The Data contains four 32x32 pixel bitmaps, and so is 128 octets.
+type LargeAnimationIE struct {
+	Position int    `json:"position"`
+	Data     []byte `json:"data"`
+}
+
+// IEI returns IEILargeAnimation.
+func (a LargeAnimationIE) IEI() byte {
+	return IEILargeAnimation
+}
+
+// MarshalIED marshals the IE into binary.
+func (a LargeAnimationIE) MarshalIED() ([]byte, error) {
+	return marshalEMSObject(a.Position, a.Data, 128)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (a *LargeAnimationIE) UnmarshalIED(src []byte) (err error) {
+	a.Position, a.Data, err = unmarshalEMSObject(src, 128)
+	return
+}
+
+// SmallAnimationIE is the EMS small animation IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.6.
+// The Data contains four 16x16 pixel bitmaps, and so is 32 octets.
+type SmallAnimationIE struct {
+	Position int    `json:"position"`
+	Data     []byte `json:"data"`
+}
+
+// IEI returns IEISmallAnimation.
+func (a SmallAnimationIE) IEI() byte {
+	return IEISmallAnimation
+}
+
+// MarshalIED marshals the IE into binary.
+func (a SmallAnimationIE) MarshalIED() ([]byte, error) {
+	return marshalEMSObject(a.Position, a.Data, 32)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (a *SmallAnimationIE) UnmarshalIED(src []byte) (err error) {
+	a.Position, a.Data, err = unmarshalEMSObject(src, 32)
+	return
+}
+
+// LargePictureIE is the EMS large picture IE, as defined in 3GPP TS 23.040
+// Section 9.2.3.24.10.1.7.
+// The Data contains a 32x32 pixel bitmap, and so is 128 octets.
+type LargePictureIE struct {
+	Position int    `json:"position"`
+	Data     []byte `json:"data"`
+}
+
+// IEI returns IEILargePicture.
+func (p LargePictureIE) IEI() byte {
+	return IEILargePicture
+}
+
+// MarshalIED marshals the IE into binary.
+func (p LargePictureIE) MarshalIED() ([]byte, error) {
+	return marshalEMSObject(p.Position, p.Data, 128)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (p *LargePictureIE) UnmarshalIED(src []byte) (err error) {
+	p.Position, p.Data, err = unmarshalEMSObject(src, 128)
+	return
+}
+
+// SmallPictureIE is the EMS small picture IE, as defined in 3GPP TS 23.040
+// Section 9.2.3.24.10.1.8.
+// The Data contains a 16x16 pixel bitmap, and so is 32 octets.
+type SmallPictureIE struct {
+	Position int    `json:"position"`
+	Data     []byte `json:"data"`
+}
+
+// IEI returns IEISmallPicture.
+func (p SmallPictureIE) IEI() byte {
+	return IEISmallPicture
+}
+
+// MarshalIED marshals the IE into binary.
+func (p SmallPictureIE) MarshalIED() ([]byte, error) {
+	return marshalEMSObject(p.Position, p.Data, 32)
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (p *SmallPictureIE) UnmarshalIED(src []byte) (err error) {
+	p.Position, p.Data, err = unmarshalEMSObject(src, 32)
+	return
+}
+
+func marshalEMSObject(pos int, data []byte, l int) ([]byte, error) {
+	if pos < 0 || pos > 0xff {
+		return nil, ErrInvalid
+	}
+	if len(data) < l {
+		return nil, ErrUnderflow
+	}
+	if len(data) > l {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, 0, 1+l)
+	b = append(b, byte(pos))
+	return append(b, data...), nil
+}
+
+func unmarshalEMSObject(src []byte, l int) (int, []byte, error) {
+	if err := checkIEDL(src, 1+l); err != nil {
+		return 0, nil, err
+	}
+	return int(src[0]), append([]byte(nil), src[1:]...), nil
+}
+
+// VariablePictureIE is the EMS variable picture IE, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.10.1.9.
+type VariablePictureIE struct {
+	Position int `json:"position"`
+	// Width is the horizontal dimension of the picture, in octets, i.e. units
+	// of 8 pixels.
+	Width int `json:"width"`
+	// Height is the vertical dimension of the picture, in pixels.
+	Height int `json:"height"`
+	// Data is the bitmap, and contains Width*Height octets.
+	Data []byte `json:"data"`
+}
+
+// IEI returns IEIVariablePicture.
+func (p VariablePictureIE) IEI() byte {
+	return IEIVariablePicture
+}
+
+// MarshalIED marshals the IE into binary.
+func (p VariablePictureIE) MarshalIED() ([]byte, error) {
+	if p.Position < 0 || p.Position > 0xff ||
+		p.Width < 0 || p.Width > 0xff ||
+		p.Height < 0 || p.Height > 0xff {
+		return nil, ErrInvalid
+	}
+	if len(p.Data) < p.Width*p.Height {
+		return nil, ErrUnderflow
+	}
+	if len(p.Data) > p.Width*p.Height {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, 0, 3+len(p.Data))
+	b = append(b, byte(p.Position), byte(p.Width), byte(p.Height))
+	return append(b, p.Data...), nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (p *VariablePictureIE) UnmarshalIED(src []byte) error {
+	if len(src) < 3 {
+		return ErrUnderflow
+	}
+	w := int(src[1])
+	h := int(src[2])
+	if err := checkIEDL(src, 3+w*h); err != nil {
+		return err
+	}
+	p.Position = int(src[0])
+	p.Width = w
+	p.Height = h
+	p.Data = append([]byte(nil), src[3:]...)
+	return nil
+}
+
+// UserPromptIndicatorIE is the EMS user prompt indicator IE, as defined in
+// 3GPP TS 23.040 Section 9.2.3.24.10.1.10.
+type UserPromptIndicatorIE struct {
+	// Objects is the number of corresponding objects that follow.
+	Objects int `json:"objects"`
+}
+
+// IEI returns IEIUserPromptIndicator.
+func (u UserPromptIndicatorIE) IEI() byte {
+	return IEIUserPromptIndicator
+}
+
+// MarshalIED marshals the IE into binary.
+func (u UserPromptIndicatorIE) MarshalIED() ([]byte, error) {
+	if u.Objects < 0 || u.Objects > 0xff {
+		return nil, ErrInvalid
+	}
+	return []byte{byte(u.Objects)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (u *UserPromptIndicatorIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	u.Objects = int(src[0])
+	return nil
+}
+
+// RFC822EmailHeaderIE indicates the SM contains an RFC 822 E-Mail header, as
+// defined in 3GPP TS 23.040 Section 9.2.3.24.11.
+type RFC822EmailHeaderIE struct {
+	// Length is the length of the header in the SM, in characters.
+	Length int `json:"length"`
+}
+
+// IEI returns IEIRFC822EmailHeader.
+func (e RFC822EmailHeaderIE) IEI() byte {
+	return IEIRFC822EmailHeader
+}
+
+// MarshalIED marshals the IE into binary.
+func (e RFC822EmailHeaderIE) MarshalIED() ([]byte, error) {
+	if e.Length < 0 || e.Length > 0xff {
+		return nil, ErrInvalid
+	}
+	return []byte{byte(e.Length)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (e *RFC822EmailHeaderIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	e.Length = int(src[0])
+	return nil
+}
+
+// HyperlinkFormatIE identifies a hyperlink in the SM, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.12.
+type HyperlinkFormatIE struct {
+	// Position is the absolute position of the hyperlink title in the
+	// concatenated message.
+	Position    int `json:"position"`
+	TitleLength int `json:"titleLength"`
+	URLLength   int `json:"urlLength"`
+}
+
+// IEI returns IEIHyperlinkFormat.
+func (h HyperlinkFormatIE) IEI() byte {
+	return IEIHyperlinkFormat
+}
+
+// MarshalIED marshals the IE into binary.
+func (h HyperlinkFormatIE) MarshalIED() ([]byte, error) {
+	if h.Position < 0 || h.Position > 0xffff ||
+		h.TitleLength < 0 || h.TitleLength > 0xff ||
+		h.URLLength < 0 || h.URLLength > 0xff {
+		return nil, ErrInvalid
+	}
+	b := []byte{0, 0, byte(h.TitleLength), byte(h.URLLength)}
+	binary.BigEndian.PutUint16(b, uint16(h.Position))
+	return b, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (h *HyperlinkFormatIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 4); err != nil {
+		return err
+	}
+	h.Position = int(binary.BigEndian.Uint16(src))
+	h.TitleLength = int(src[2])
+	h.URLLength = int(src[3])
+	return nil
+}
+
+// ReplyAddressIE provides an alternate reply address, as defined in 3GPP TS
+// 23.040 Section 9.2.3.24.14.
+type ReplyAddressIE struct {
+	Address Address `json:"address"`
+}
+
+// IEI returns IEIReplyAddress.
+func (r ReplyAddressIE) IEI() byte {
+	return IEIReplyAddress
+}
+
+// MarshalIED marshals the IE into binary.
+func (r ReplyAddressIE) MarshalIED() ([]byte, error) {
+	return r.Address.MarshalBinary()
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (r *ReplyAddressIE) UnmarshalIED(src []byte) error {
+	n, err := r.Address.UnmarshalBinary(src)
+	if err != nil {
+		return err
+	}
+	if n != len(src) {
+		return ErrOverlength
+	}
+	return nil
+}
+
+// NationalLanguageSingleShiftIE identifies the national language shift table
+// used to encode GSM7 user data, as defined in 3GPP TS 23.040 Section
+// 9.2.3.24.15.
+type NationalLanguageSingleShiftIE struct {
+	Language charset.NationalLanguageIdentifier `json:"language"`
+}
+
+// IEI returns IEINationalLanguageSingleShift.
+func (n NationalLanguageSingleShiftIE) IEI() byte {
+	return IEINationalLanguageSingleShift
+}
+
+// MarshalIED marshals the IE into binary.
+func (n NationalLanguageSingleShiftIE) MarshalIED() ([]byte, error) {
+	return []byte{byte(n.Language)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (n *NationalLanguageSingleShiftIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	n.Language = charset.NationalLanguageIdentifier(src[0])
+	return nil
+}
+
+// NationalLanguageLockingShiftIE identifies the national language locking
+// table used to encode GSM7 user data, as defined in 3GPP TS 23.040 Section
+// 9.2.3.24.16.
+type NationalLanguageLockingShiftIE struct {
+	Language charset.NationalLanguageIdentifier `json:"language"`
+}
+
+// IEI returns IEINationalLanguageLockingShift.
+func (n NationalLanguageLockingShiftIE) IEI() byte {
+	return IEINationalLanguageLockingShift
+}
+
+// MarshalIED marshals the IE into binary.
+func (n NationalLanguageLockingShiftIE) MarshalIED() ([]byte, error) {
+	return []byte{byte(n.Language)}, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (n *NationalLanguageLockingShiftIE) UnmarshalIED(src []byte) error {
+	if err := checkIEDL(src, 1); err != nil {
+		return err
+	}
+	n.Language = charset.NationalLanguageIdentifier(src[0])
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/gsm7/charset"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestTypedIEs(t *testing.T) {
+	large := bytes.Repeat([]byte{0xa5}, 128)
+	small := bytes.Repeat([]byte{0x5a}, 32)
+	patterns := []struct {
+		name string
+		in   tpdu.InformationElement
+		out  tpdu.TypedIE
+	}{
+		{"concat8",
+			tpdu.InformationElement{ID: 0x00, Data: []byte{3, 2, 1}},
+			&tpdu.Concatenated8IE{MR: 3, Segments: 2, SeqNo: 1}},
+		{"concat16",
+			tpdu.InformationElement{ID: 0x08, Data: []byte{4, 3, 2, 1}},
+			&tpdu.Concatenated16IE{MR: 1027, Segments: 2, SeqNo: 1}},
+		{"special sms",
+			tpdu.InformationElement{ID: 0x01, Data: []byte{0xa5, 4}},
+			&tpdu.SpecialSMSIndicationIE{Store: true, ProfileID: 1, ExtendedType: 1, Type: 1, Count: 4}},
+		{"port8",
+			tpdu.InformationElement{ID: 0x04, Data: []byte{0xf5, 0x01}},
+			&tpdu.Port8IE{Dst: 0xf5, Src: 1}},
+		{"port16",
+			tpdu.InformationElement{ID: 0x05, Data: []byte{0x0b, 0x84, 0x23, 0xf0}},
+			&tpdu.Port16IE{Dst: 2948, Src: 9200}},
+		{"smsc control",
+			tpdu.InformationElement{ID: 0x06, Data: []byte{0x43}},
+			&tpdu.SMSCControlParametersIE{SelectiveStatusReport: 0x43}},
+		{"udh source",
+			tpdu.InformationElement{ID: 0x07, Data: []byte{0x02}},
+			&tpdu.UDHSourceIndicatorIE{Source: 2}},
+		{"text formatting",
+			tpdu.InformationElement{ID: 0x0a, Data: []byte{1, 5, 0x10}},
+			&tpdu.TextFormattingIE{Start: 1, Length: 5, Format: 0x10}},
+		{"text formatting colour",
+			tpdu.InformationElement{ID: 0x0a, Data: []byte{1, 5, 0x10, 0x2f}},
+			&tpdu.TextFormattingIE{Start: 1, Length: 5, Format: 0x10, Colour: 0x2f, HasColour: true}},
+		{"predefined sound",
+			tpdu.InformationElement{ID: 0x0b, Data: []byte{7, 3}},
+			&tpdu.PredefinedSoundIE{Position: 7, Sound: 3}},
+		{"user defined sound",
+			tpdu.InformationElement{ID: 0x0c, Data: []byte{7, 'a', 'b'}},
+			&tpdu.UserDefinedSoundIE{Position: 7, Data: []byte("ab")}},
+		{"predefined animation",
+			tpdu.InformationElement{ID: 0x0d, Data: []byte{8, 2}},
+			&tpdu.PredefinedAnimationIE{Position: 8, Animation: 2}},
+		{"large animation",
+			tpdu.InformationElement{ID: 0x0e, Data: append([]byte{9}, large...)},
+			&tpdu.LargeAnimationIE{Position: 9, Data: large}},
+		{"small animation",
+			tpdu.InformationElement{ID: 0x0f, Data: append([]byte{9}, small...)},
+			&tpdu.SmallAnimationIE{Position: 9, Data: small}},
+		{"large picture",
+			tpdu.InformationElement{ID: 0x10, Data: append([]byte{10}, large...)},
+			&tpdu.LargePictureIE{Position: 10, Data: large}},
+		{"small picture",
+			tpdu.InformationElement{ID: 0x11, Data: append([]byte{10}, small...)},
+			&tpdu.SmallPictureIE{Position: 10, Data: small}},
+		{"variable picture",
+			tpdu.InformationElement{ID: 0x12, Data: []byte{11, 2, 3, 1, 2, 3, 4, 5, 6}},
+			&tpdu.VariablePictureIE{Position: 11, Width: 2, Height: 3, Data: []byte{1, 2, 3, 4, 5, 6}}},
+		{"user prompt",
+			tpdu.InformationElement{ID: 0x13, Data: []byte{2}},
+			&tpdu.UserPromptIndicatorIE{Objects: 2}},
+		{"email header",
+			tpdu.InformationElement{ID: 0x20, Data: []byte{42}},
+			&tpdu.RFC822EmailHeaderIE{Length: 42}},
+		{"hyperlink",
+			tpdu.InformationElement{ID: 0x21, Data: []byte{1, 2, 3, 4}},
+			&tpdu.HyperlinkFormatIE{Position: 258, TitleLength: 3, URLLength: 4}},
+		{"reply address",
+			tpdu.InformationElement{ID: 0x22, Data: []byte{4, 0x91, 0x21, 0x43}},
+			&tpdu.ReplyAddressIE{Address: tpdu.Address{TOA: 0x91, Addr: "1234"}}},
+		{"single shift",
+			tpdu.InformationElement{ID: 0x24, Data: []byte{7}},
+			&tpdu.NationalLanguageSingleShiftIE{Language: charset.Kannada}},
+		{"locking shift",
+			tpdu.InformationElement{ID: 0x25, Data: []byte{1}},
+			&tpdu.NationalLanguageLockingShiftIE{Language: charset.Turkish}},
+		{"unknown",
+			tpdu.InformationElement{ID: 0x70, Data: []byte{1, 2}},
+			&tpdu.InformationElement{ID: 0x70, Data: []byte{1, 2}}},
+	}
+	r := tpdu.NewIERegistry()
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			out, err := r.DecodeIE(p.in)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, out)
+			ie, err := tpdu.NewInformationElement(out)
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, ie)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestTypedIEDecodeErrors(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   tpdu.InformationElement
+		err  error
+	}{
+		{"concat8 short", tpdu.InformationElement{ID: 0x00, Data: []byte{3, 2}}, tpdu.ErrUnderflow},
+		{"concat8 long", tpdu.InformationElement{ID: 0x00, Data: []byte{3, 2, 1, 0}}, tpdu.ErrOverlength},
+		{"concat16 short", tpdu.InformationElement{ID: 0x08, Data: []byte{3, 2, 1}}, tpdu.ErrUnderflow},
+		{"port16 short", tpdu.InformationElement{ID: 0x05, Data: []byte{3, 2, 1}}, tpdu.ErrUnderflow},
+		{"text formatting short", tpdu.InformationElement{ID: 0x0a, Data: []byte{1, 2}}, tpdu.ErrUnderflow},
+		{"text formatting long", tpdu.InformationElement{ID: 0x0a, Data: []byte{1, 2, 3, 4, 5}}, tpdu.ErrOverlength},
+		{"small picture short", tpdu.InformationElement{ID: 0x11, Data: []byte{1, 2}}, tpdu.ErrUnderflow},
+		{"variable picture short", tpdu.InformationElement{ID: 0x12, Data: []byte{1, 2, 2, 1, 2, 3}}, tpdu.ErrUnderflow},
+		{"reply address long", tpdu.InformationElement{ID: 0x22, Data: []byte{4, 0x91, 0x21, 0x43, 0}}, tpdu.ErrOverlength},
+	}
+	r := tpdu.NewIERegistry()
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			out, err := r.DecodeIE(p.in)
+			assert.Equal(t, tpdu.DecodeError("ied", 0, p.err), err)
+			assert.Nil(t, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestTypedIEMarshalErrors(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   tpdu.TypedIE
+		err  error
+	}{
+		{"concat8 segments", &tpdu.Concatenated8IE{Segments: 256, SeqNo: 1}, tpdu.ErrInvalid},
+		{"concat8 seqno", &tpdu.Concatenated8IE{Segments: 2, SeqNo: -1}, tpdu.ErrInvalid},
+		{"concat16 segments", &tpdu.Concatenated16IE{Segments: -1, SeqNo: 1}, tpdu.ErrInvalid},
+		{"concat16 seqno", &tpdu.Concatenated16IE{Segments: 2, SeqNo: 256}, tpdu.ErrInvalid},
+		{"special sms type", &tpdu.SpecialSMSIndicationIE{Type: 4}, tpdu.ErrInvalid},
+		{"special sms count", &tpdu.SpecialSMSIndicationIE{Count: 256}, tpdu.ErrInvalid},
+		{"large picture short", &tpdu.LargePictureIE{Data: []byte{1}}, tpdu.ErrUnderflow},
+		{"small picture long", &tpdu.SmallPictureIE{Data: make([]byte, 33)}, tpdu.ErrOverlength},
+		{"user defined sound long", &tpdu.UserDefinedSoundIE{Data: make([]byte, 129)}, tpdu.ErrOverlength},
+		{"variable picture mismatch", &tpdu.VariablePictureIE{Width: 1, Height: 2, Data: []byte{1}}, tpdu.ErrUnderflow},
+		{"hyperlink position", &tpdu.HyperlinkFormatIE{Position: 0x10000}, tpdu.ErrInvalid},
+		{"raw long", &tpdu.InformationElement{Data: make([]byte, 256)}, tpdu.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			_, err := tpdu.NewInformationElement(p.in)
+			assert.Equal(t, p.err, err)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestUserDataHeaderTypedIEs(t *testing.T) {
+	udh := tpdu.UserDataHeader{
+		tpdu.InformationElement{ID: 0x05, Data: []byte{0x0b, 0x84, 0x23, 0xf0}},
+		tpdu.InformationElement{ID: 0x00, Data: []byte{3, 2, 1}},
+		tpdu.InformationElement{ID: 0x70, Data: []byte{1}},
+	}
+	expected := []tpdu.TypedIE{
+		&tpdu.Port16IE{Dst: 2948, Src: 9200},
+		&tpdu.Concatenated8IE{MR: 3, Segments: 2, SeqNo: 1},
+		&tpdu.InformationElement{ID: 0x70, Data: []byte{1}},
+	}
+	ties, err := udh.TypedIEs()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ties)
+	out, err := tpdu.NewUserDataHeader(ties...)
+	assert.Nil(t, err)
+	assert.Equal(t, udh, out)
+
+	// empty
+	ties, err = tpdu.UserDataHeader(nil).TypedIEs()
+	assert.Nil(t, err)
+	assert.Nil(t, ties)
+	out, err = tpdu.NewUserDataHeader()
+	assert.Nil(t, err)
+	assert.Nil(t, out)
+
+	// errors
+	udh = append(udh, tpdu.InformationElement{ID: 0x04, Data: []byte{1}})
+	ties, err = udh.TypedIEs()
+	assert.Equal(t, tpdu.DecodeError("ie.ied", 3, tpdu.ErrUnderflow), err)
+	assert.Nil(t, ties)
+	out, err = tpdu.NewUserDataHeader(&tpdu.LargePictureIE{})
+	assert.Equal(t, tpdu.EncodeError("ie", tpdu.ErrUnderflow), err)
+	assert.Nil(t, out)
+}
+
+type customIE struct {
+	v byte
+}
+
+func (c customIE) IEI() byte {
+	return 0x70
+}
+
+func (c customIE) MarshalIED() ([]byte, error) {
+	return []byte{c.v}, nil
+}
+
+func (c *customIE) UnmarshalIED(src []byte) error {
+	if len(src) != 1 {
+		return tpdu.ErrInvalid
+	}
+	c.v = src[0]
+	return nil
+}
+
+func TestIERegistryRegister(t *testing.T) {
+	r := tpdu.NewIERegistry()
+	ie := tpdu.InformationElement{ID: 0x70, Data: []byte{5}}
+	out, err := r.DecodeIE(ie)
+	assert.Nil(t, err)
+	assert.Equal(t, &ie, out)
+	r.Register(0x70, func() tpdu.TypedIE { return &customIE{} })
+	out, err = r.DecodeIE(ie)
+	assert.Nil(t, err)
+	assert.Equal(t, &customIE{5}, out)
+	r.Unregister(0x70)
+	out, err = r.DecodeIE(ie)
+	assert.Nil(t, err)
+	assert.Equal(t, &ie, out)
+	// unregistering standard IEs results in raw decoding
+	r.Unregister(tpdu.IEIPort8)
+	ie = tpdu.InformationElement{ID: tpdu.IEIPort8, Data: []byte{1, 2}}
+	out, err = r.DecodeIE(ie)
+	assert.Nil(t, err)
+	assert.Equal(t, &ie, out)
+}
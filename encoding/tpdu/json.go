@@ -0,0 +1,546 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// The JSON forms of the TPDUs contain the raw value of each field, which is
+// sufficient to reproduce the binary form, supplemented with symbolic forms,
+// such as the alphabet and message class indicated by the DCS, the decoded
+// IEs and the text of the UD.
+// The symbolic forms are informational and are ignored when unmarshalling.
+
+// hexOctets is marshalled to JSON as a hex string.
+type hexOctets []byte
+
+func (h hexOctets) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *hexOctets) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(d) == 0 {
+		d = nil
+	}
+	*h = d
+	return nil
+}
+
+type jsonAddress struct {
+	TOA  *byte  `json:"toa,omitempty"`
+	TON  string `json:"ton"`
+	NPI  string `json:"npi"`
+	Addr string `json:"addr"`
+}
+
+// MarshalJSON marshals the Address into JSON, with the TOA split into its
+// symbolic TON and NPI.
+// The raw TOA is only included if it cannot be reproduced from the symbolic
+// TON and NPI, i.e. if bit 7 is clear or the NPI is reserved.
+func (a Address) MarshalJSON() ([]byte, error) {
+	np := a.NumberingPlan()
+	j := jsonAddress{
+		TON:  a.TypeOfNumber().String(),
+		NPI:  np.String(),
+		Addr: a.Addr,
+	}
+	if _, ok := npiNames[np]; !ok || a.TOA&0x80 == 0 {
+		toa := a.TOA
+		j.TOA = &toa
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON unmarshals the Address from JSON.
+// The TOA is taken from the raw TOA, if present, else it is built from the
+// symbolic TON and NPI.
+func (a *Address) UnmarshalJSON(b []byte) error {
+	j := jsonAddress{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	v := Address{Addr: j.Addr}
+	if j.TOA != nil {
+		v.TOA = *j.TOA
+	} else {
+		v.TOA = 0x80
+		if j.TON != "" {
+			ton, ok := parseTON(j.TON)
+			if !ok {
+				return DecodeError("ton", 0, ErrInvalid)
+			}
+			v.TOA |= byte(ton) << 4
+		}
+		if j.NPI != "" {
+			np, ok := parseNPI(j.NPI)
+			if !ok {
+				return DecodeError("npi", 0, ErrInvalid)
+			}
+			v.TOA |= byte(np)
+		}
+	}
+	*a = v
+	return nil
+}
+
+// MarshalJSON marshals the Timestamp into JSON in RFC 3339 format.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format(time.RFC3339))
+}
+
+// UnmarshalJSON unmarshals the Timestamp from JSON in RFC 3339 format.
+// As per UnmarshalBinary, the location of the Timestamp is UTC if there is
+// no offset from UTC, else a fixed zone.
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	tt, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	loc := time.UTC
+	if _, offset := tt.Zone(); offset != 0 {
+		loc = time.FixedZone("SCTS", offset)
+	}
+	t.Time = tt.In(loc)
+	return nil
+}
+
+type jsonValidityPeriod struct {
+	Format   string     `json:"format"`
+	Time     *Timestamp `json:"time,omitempty"`
+	Duration string     `json:"duration,omitempty"`
+	EFI      byte       `json:"efi,omitempty"`
+	EFIExt   hexOctets  `json:"efiExt,omitempty"`
+}
+
+// MarshalJSON marshals the ValidityPeriod into JSON.
+// Only the fields relevant to the Format are included.
+func (v ValidityPeriod) MarshalJSON() ([]byte, error) {
+	j := jsonValidityPeriod{Format: v.Format.String()}
+	switch v.Format {
+	case VpfAbsolute:
+		t := v.Time
+		j.Time = &t
+	case VpfEnhanced:
+		j.EFI = v.EFI
+		j.EFIExt = hexOctets(v.EFIExt)
+		fallthrough
+	case VpfRelative:
+		j.Duration = v.Duration.String()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON unmarshals the ValidityPeriod from JSON.
+func (v *ValidityPeriod) UnmarshalJSON(b []byte) error {
+	j := jsonValidityPeriod{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	vp := ValidityPeriod{}
+	f, ok := parseVPF(j.Format)
+	if !ok {
+		return DecodeError("format", 0, ErrInvalid)
+	}
+	vp.Format = f
+	if j.Time != nil {
+		vp.Time = *j.Time
+	}
+	if j.Duration != "" {
+		d, err := time.ParseDuration(j.Duration)
+		if err != nil {
+			return DecodeError("duration", 0, err)
+		}
+		vp.Duration = d
+	}
+	vp.EFI = j.EFI
+	if len(j.EFIExt) > 0 {
+		vp.EFIExt = []byte(j.EFIExt)
+	}
+	*v = vp
+	return nil
+}
+
+type jsonIE struct {
+	IEI     byte            `json:"iei"`
+	Name    string          `json:"name,omitempty"`
+	Data    hexOctets       `json:"data"`
+	Decoded json.RawMessage `json:"decoded,omitempty"`
+}
+
+var ieNames = map[byte]string{
+	IEIConcatenated8:                  "concatenated8",
+	IEISpecialSMSIndication:           "specialSMSIndication",
+	IEIPort8:                          "port8",
+	IEIPort16:                         "port16",
+	IEISMSCControlParameters:          "smscControlParameters",
+	IEIUDHSourceIndicator:             "udhSourceIndicator",
+	IEIConcatenated16:                 "concatenated16",
+	IEIWirelessControlMessageProtocol: "wirelessControlMessageProtocol",
+	IEITextFormatting:                 "textFormatting",
+	IEIPredefinedSound:                "predefinedSound",
+	IEIUserDefinedSound:               "userDefinedSound",
+	IEIPredefinedAnimation:            "predefinedAnimation",
+	IEILargeAnimation:                 "largeAnimation",
+	IEISmallAnimation:                 "smallAnimation",
+	IEILargePicture:                   "largePicture",
+	IEISmallPicture:                   "smallPicture",
+	IEIVariablePicture:                "variablePicture",
+	IEIUserPromptIndicator:            "userPromptIndicator",
+	IEIExtendedObject:                 "extendedObject",
+	IEIReusedExtendedObject:           "reusedExtendedObject",
+	IEICompressionControl:             "compressionControl",
+	IEIObjectDistributionIndicator:    "objectDistributionIndicator",
+	IEIStandardWVGObject:              "standardWVGObject",
+	IEICharacterSizeWVGObject:         "characterSizeWVGObject",
+	IEIExtendedObjectDataRequest:      "extendedObjectDataRequest",
+	IEIRFC822EmailHeader:              "rfc822EmailHeader",
+	IEIHyperlinkFormat:                "hyperlinkFormat",
+	IEIReplyAddress:                   "replyAddress",
+	IEIEnhancedVoiceMailInformation:   "enhancedVoiceMailInformation",
+	IEINationalLanguageSingleShift:    "nationalLanguageSingleShift",
+	IEINationalLanguageLockingShift:   "nationalLanguageLockingShift",
+}
+
+// MarshalJSON marshals the UserDataHeader into JSON.
+// Each IE is marshalled with its raw IED and, if it can be decoded by the
+// standard set of TypedIEs, the decoded IE.
+func (udh UserDataHeader) MarshalJSON() ([]byte, error) {
+	if udh == nil {
+		return []byte("null"), nil
+	}
+	jies := make([]jsonIE, len(udh))
+	for i, ie := range udh {
+		jies[i] = jsonIE{IEI: ie.ID, Name: ieNames[ie.ID], Data: ie.Data}
+		t, err := defaultIERegistry.DecodeIE(ie)
+		if err != nil {
+			continue
+		}
+		if _, ok := t.(*InformationElement); ok {
+			continue
+		}
+		if d, err := json.Marshal(t); err == nil {
+			jies[i].Decoded = d
+		}
+	}
+	return json.Marshal(jies)
+}
+
+// UnmarshalJSON unmarshals the UserDataHeader from JSON.
+// The IEs are built from the raw IED.
+func (udh *UserDataHeader) UnmarshalJSON(b []byte) error {
+	var jies []jsonIE
+	if err := json.Unmarshal(b, &jies); err != nil {
+		return err
+	}
+	if jies == nil {
+		*udh = nil
+		return nil
+	}
+	u := make(UserDataHeader, len(jies))
+	for i, jie := range jies {
+		u[i] = InformationElement{ID: jie.IEI, Data: jie.Data}
+	}
+	*udh = u
+	return nil
+}
+
+// jsonTPDU contains the fields common to all TPDUs.
+type jsonTPDU struct {
+	FirstOctet byte           `json:"firstOctet"`
+	PID        byte           `json:"pid"`
+	DCS        byte           `json:"dcs"`
+	Alphabet   string         `json:"alphabet,omitempty"`
+	Class      string         `json:"class,omitempty"`
+	Compressed bool           `json:"compressed,omitempty"`
+	UDH        UserDataHeader `json:"udh,omitempty"`
+	UD         hexOctets      `json:"ud,omitempty"`
+	Text       string         `json:"text,omitempty"`
+}
+
+func newJSONTPDU(p *TPDU) jsonTPDU {
+	j := jsonTPDU{
+		FirstOctet: p.FirstOctet,
+		PID:        p.PID,
+		DCS:        p.DCS,
+		UDH:        p.UDH,
+		UD:         hexOctets(p.UD),
+	}
+	dcs := DCS(p.DCS)
+	j.Compressed = dcs.Compressed()
+	alpha, err := dcs.Alphabet()
+	if err == nil {
+		j.Alphabet = alpha.String()
+		if len(p.UD) > 0 && !j.Compressed {
+			if d, err := NewUDDecoder(); err == nil {
+				d.AddAllCharsets()
+				if text, err := d.Decode(p.UD, p.UDH, alpha); err == nil {
+					j.Text = string(text)
+				}
+			}
+		}
+	}
+	if c, err := dcs.Class(); err == nil && c != MClassUnknown {
+		j.Class = c.String()
+	}
+	return j
+}
+
+func (j jsonTPDU) tpdu() TPDU {
+	return TPDU{
+		FirstOctet: j.FirstOctet,
+		PID:        j.PID,
+		DCS:        j.DCS,
+		UDH:        j.UDH,
+		UD:         UserData(j.UD),
+	}
+}
+
+type jsonSubmit struct {
+	Type string          `json:"type"`
+	MR   byte            `json:"mr"`
+	DA   Address         `json:"da"`
+	VP   *ValidityPeriod `json:"vp,omitempty"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the Submit into JSON.
+func (s Submit) MarshalJSON() ([]byte, error) {
+	j := jsonSubmit{
+		Type:     "submit",
+		MR:       s.MR,
+		DA:       s.DA,
+		jsonTPDU: newJSONTPDU(&s.TPDU),
+	}
+	if s.VP.Format != VpfNotPresent {
+		vp := s.VP
+		j.VP = &vp
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON unmarshals the Submit from JSON.
+func (s *Submit) UnmarshalJSON(b []byte) error {
+	j := jsonSubmit{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	v := Submit{TPDU: j.tpdu(), MR: j.MR, DA: j.DA}
+	if j.VP != nil {
+		v.VP = *j.VP
+	}
+	*s = v
+	return nil
+}
+
+type jsonDeliver struct {
+	Type string    `json:"type"`
+	OA   Address   `json:"oa"`
+	SCTS Timestamp `json:"scts"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the Deliver into JSON.
+func (d Deliver) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDeliver{
+		Type:     "deliver",
+		OA:       d.OA,
+		SCTS:     d.SCTS,
+		jsonTPDU: newJSONTPDU(&d.TPDU),
+	})
+}
+
+// UnmarshalJSON unmarshals the Deliver from JSON.
+func (d *Deliver) UnmarshalJSON(b []byte) error {
+	j := jsonDeliver{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*d = Deliver{TPDU: j.tpdu(), OA: j.OA, SCTS: j.SCTS}
+	return nil
+}
+
+type jsonStatusReport struct {
+	Type   string    `json:"type"`
+	MR     byte      `json:"mr"`
+	RA     Address   `json:"ra"`
+	SCTS   Timestamp `json:"scts"`
+	DT     Timestamp `json:"dt"`
+	ST     byte      `json:"st"`
+	Status string    `json:"status,omitempty"`
+	PI     byte      `json:"pi"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the StatusReport into JSON.
+func (s StatusReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonStatusReport{
+		Type:     "statusReport",
+		MR:       s.MR,
+		RA:       s.RA,
+		SCTS:     s.SCTS,
+		DT:       s.DT,
+		ST:       s.ST,
+		Status:   s.Status().String(),
+		PI:       s.PI,
+		jsonTPDU: newJSONTPDU(&s.TPDU),
+	})
+}
+
+// UnmarshalJSON unmarshals the StatusReport from JSON.
+func (s *StatusReport) UnmarshalJSON(b []byte) error {
+	j := jsonStatusReport{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*s = StatusReport{
+		TPDU: j.tpdu(),
+		MR:   j.MR,
+		RA:   j.RA,
+		SCTS: j.SCTS,
+		DT:   j.DT,
+		ST:   j.ST,
+		PI:   j.PI,
+	}
+	return nil
+}
+
+type jsonCommand struct {
+	Type        string  `json:"type"`
+	MR          byte    `json:"mr"`
+	CT          byte    `json:"ct"`
+	CommandType string  `json:"commandType,omitempty"`
+	MN          byte    `json:"mn"`
+	DA          Address `json:"da"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the Command into JSON.
+func (c Command) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonCommand{
+		Type:        "command",
+		MR:          c.MR,
+		CT:          c.CT,
+		CommandType: c.CommandType().String(),
+		MN:          c.MN,
+		DA:          c.DA,
+		jsonTPDU:    newJSONTPDU(&c.TPDU),
+	})
+}
+
+// UnmarshalJSON unmarshals the Command from JSON.
+func (c *Command) UnmarshalJSON(b []byte) error {
+	j := jsonCommand{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*c = Command{TPDU: j.tpdu(), MR: j.MR, CT: j.CT, MN: j.MN, DA: j.DA}
+	return nil
+}
+
+type jsonDeliverReport struct {
+	Type         string `json:"type"`
+	FCS          byte   `json:"fcs"`
+	FailureCause string `json:"failureCause,omitempty"`
+	PI           byte   `json:"pi"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the DeliverReport into JSON.
+func (d DeliverReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDeliverReport{
+		Type:         "deliverReport",
+		FCS:          d.FCS,
+		FailureCause: d.FailureCause().String(),
+		PI:           d.PI,
+		jsonTPDU:     newJSONTPDU(&d.TPDU),
+	})
+}
+
+// UnmarshalJSON unmarshals the DeliverReport from JSON.
+func (d *DeliverReport) UnmarshalJSON(b []byte) error {
+	j := jsonDeliverReport{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*d = DeliverReport{TPDU: j.tpdu(), FCS: j.FCS, PI: j.PI}
+	return nil
+}
+
+type jsonSubmitReport struct {
+	Type         string    `json:"type"`
+	FCS          byte      `json:"fcs"`
+	FailureCause string    `json:"failureCause,omitempty"`
+	PI           byte      `json:"pi"`
+	SCTS         Timestamp `json:"scts"`
+	jsonTPDU
+}
+
+// MarshalJSON marshals the SubmitReport into JSON.
+func (s SubmitReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSubmitReport{
+		Type:         "submitReport",
+		FCS:          s.FCS,
+		FailureCause: s.FailureCause().String(),
+		PI:           s.PI,
+		SCTS:         s.SCTS,
+		jsonTPDU:     newJSONTPDU(&s.TPDU),
+	})
+}
+
+// UnmarshalJSON unmarshals the SubmitReport from JSON.
+func (s *SubmitReport) UnmarshalJSON(b []byte) error {
+	j := jsonSubmitReport{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*s = SubmitReport{TPDU: j.tpdu(), FCS: j.FCS, PI: j.PI, SCTS: j.SCTS}
+	return nil
+}
+
+// parseTON returns the TypeOfNumber with the given name.
+func parseTON(name string) (TypeOfNumber, bool) {
+	for ton, n := range tonNames {
+		if n == name {
+			return ton, true
+		}
+	}
+	return TonUnknown, false
+}
+
+// parseNPI returns the NumberingPlan with the given name.
+func parseNPI(name string) (NumberingPlan, bool) {
+	for np, n := range npiNames {
+		if n == name {
+			return np, true
+		}
+	}
+	return NpUnknown, false
+}
+
+// parseVPF returns the ValidityPeriodFormat with the given name.
+func parseVPF(name string) (ValidityPeriodFormat, bool) {
+	for f, n := range vpfNames {
+		if n == name {
+			return f, true
+		}
+	}
+	return VpfNotPresent, false
+}
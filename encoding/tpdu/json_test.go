@@ -0,0 +1,279 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+type binaryTPDU interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(src []byte) error
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	scts := tpdu.Timestamp{Time: time.Date(2017, time.August, 31, 11, 21, 54, 0,
+		time.FixedZone("SCTS", 8*3600))}
+	concat, _ := tpdu.NewUserDataHeader(
+		&tpdu.Concatenated8IE{MR: 3, Segments: 2, SeqNo: 1},
+		&tpdu.NationalLanguageLockingShiftIE{Language: 1})
+	unknownIE := tpdu.UserDataHeader{{ID: 0x70, Data: []byte{1, 2, 3}}}
+
+	submit := tpdu.NewSubmit()
+	submit.MR = 0x23
+	submit.DA = tpdu.Address{TOA: 0x91, Addr: "61409123456"}
+	submit.UD = []byte("Hahahaha")
+	submitVP := *submit
+	submitVP.FirstOctet = 0x11
+	submitVP.VP.SetRelative(5 * time.Minute)
+	submitAbs := *submit
+	submitAbs.FirstOctet = 0x19
+	submitAbs.VP.SetAbsolute(scts)
+	submitEnh := *submit
+	submitEnh.FirstOctet = 0x09
+	submitEnh.VP.SetEnhanced(3*time.Hour+12*time.Minute+45*time.Second, byte(tpdu.EvpfRelativeHHMMSS))
+	submitUDH := *submit
+	submitUDH.SetUDH(concat)
+	submitUCS2 := *submit
+	submitUCS2.DCS = 0x18
+	submitUCS2.UD = []byte{0x00, 0x48, 0x00, 0x69}
+	deliver := tpdu.NewDeliver()
+	deliver.OA = tpdu.Address{TOA: 0xd0, Addr: "Telstra1"}
+	deliver.SCTS = scts
+	deliver.SetUDH(unknownIE)
+	deliver.UD = []byte("hello")
+	deliver8 := tpdu.NewDeliver()
+	deliver8.OA = tpdu.Address{TOA: 0x01, Addr: "1234"}
+	deliver8.DCS = 0xf4
+	deliver8.UD = []byte{0xde, 0xad, 0xbe, 0xef}
+	sr := tpdu.NewStatusReport()
+	sr.MR = 0x42
+	sr.RA = tpdu.Address{TOA: 0x91, Addr: "6391"}
+	sr.SCTS = scts
+	sr.DT = tpdu.Timestamp{Time: scts.Add(time.Hour).In(time.UTC)}
+	sr.ST = byte(tpdu.StatusSMEBusy)
+	sr.SetPID(0x89)
+	sr.SetDCS(0x04)
+	sr.SetUD([]byte("report"))
+	submitted := tpdu.NewSubmit()
+	submitted.MR = 0x05
+	submitted.DA = tpdu.Address{TOA: 0x81, Addr: "0409123456"}
+	cmd := tpdu.NewDeleteCommand(submitted)
+	cmd.MR = 0x06
+	cmd.UD = []byte{1, 2}
+	dr := tpdu.NewDeliverReport()
+	dr.FCS = byte(tpdu.FCSSIMStorageFull)
+	dr.SetPID(0xab)
+	srep := tpdu.NewSubmitReport()
+	srep.FCS = byte(tpdu.FCSSCBusy)
+	srep.SCTS = scts
+
+	patterns := []struct {
+		name string
+		in   binaryTPDU
+		out  binaryTPDU
+	}{
+		{"submit", submit, &tpdu.Submit{}},
+		{"submit relative", &submitVP, &tpdu.Submit{}},
+		{"submit absolute", &submitAbs, &tpdu.Submit{}},
+		{"submit enhanced", &submitEnh, &tpdu.Submit{}},
+		{"submit udh", &submitUDH, &tpdu.Submit{}},
+		{"submit ucs2", &submitUCS2, &tpdu.Submit{}},
+		{"deliver", deliver, &tpdu.Deliver{}},
+		{"deliver 8bit", deliver8, &tpdu.Deliver{}},
+		{"status report", sr, &tpdu.StatusReport{}},
+		{"command", cmd, &tpdu.Command{}},
+		{"deliver report", dr, &tpdu.DeliverReport{}},
+		{"submit report", srep, &tpdu.SubmitReport{}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			require.Nil(t, err)
+			j, err := json.Marshal(p.in)
+			require.Nil(t, err)
+			err = json.Unmarshal(j, p.out)
+			require.Nil(t, err)
+			ob, err := p.out.MarshalBinary()
+			require.Nil(t, err)
+			assert.Equal(t, b, ob)
+			// and again, from the decoded binary
+			err = p.out.UnmarshalBinary(b)
+			require.Nil(t, err)
+			j, err = json.Marshal(p.out)
+			require.Nil(t, err)
+			err = json.Unmarshal(j, p.out)
+			require.Nil(t, err)
+			ob, err = p.out.MarshalBinary()
+			require.Nil(t, err)
+			assert.Equal(t, b, ob)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSubmitMarshalJSON(t *testing.T) {
+	s := tpdu.NewSubmit()
+	s.FirstOctet = 0x51
+	s.MR = 0x23
+	s.DA = tpdu.Address{TOA: 0x91, Addr: "6391"}
+	s.DCS = 0x11
+	s.VP.SetRelative(24 * time.Hour)
+	s.UDH = tpdu.UserDataHeader{{ID: tpdu.IEIPort8, Data: []byte{0x10, 0x20}}}
+	s.UD = []byte("Hi")
+	j, err := json.Marshal(s)
+	require.Nil(t, err)
+	expected := `{"type":"submit","mr":35,` +
+		`"da":{"ton":"international","npi":"isdn","addr":"6391"},` +
+		`"vp":{"format":"relative","duration":"24h0m0s"},` +
+		`"firstOctet":81,"pid":0,"dcs":17,"alphabet":"7bit","class":"class1",` +
+		`"udh":[{"iei":4,"name":"port8","data":"1020","decoded":{"dst":16,"src":32}}],` +
+		`"ud":"4869","text":"Hi"}`
+	assert.Equal(t, expected, string(j))
+	// symbolic fields are ignored
+	d := tpdu.Submit{}
+	err = json.Unmarshal([]byte(`{"type":"deliver","firstOctet":1,"dcs":0,"alphabet":"ucs2","text":"ignored","ud":"4869"}`), &d)
+	require.Nil(t, err)
+	assert.Equal(t, tpdu.Submit{TPDU: tpdu.TPDU{FirstOctet: 1, UD: []byte("Hi")}}, d)
+}
+
+func TestAddressJSON(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   tpdu.Address
+		out  string
+	}{
+		{"international", tpdu.Address{TOA: 0x91, Addr: "6391"},
+			`{"ton":"international","npi":"isdn","addr":"6391"}`},
+		{"alphanumeric", tpdu.Address{TOA: 0xd0, Addr: "Telstra"},
+			`{"ton":"alphanumeric","npi":"unknown","addr":"Telstra"}`},
+		{"reserved npi", tpdu.Address{TOA: 0x82, Addr: "1234"},
+			`{"toa":130,"ton":"unknown","npi":"NumberingPlan(2)","addr":"1234"}`},
+		{"bit 7 clear", tpdu.Address{TOA: 0x21, Addr: "1234"},
+			`{"toa":33,"ton":"national","npi":"isdn","addr":"1234"}`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			j, err := json.Marshal(p.in)
+			require.Nil(t, err)
+			assert.Equal(t, p.out, string(j))
+			a := tpdu.Address{}
+			err = json.Unmarshal(j, &a)
+			require.Nil(t, err)
+			assert.Equal(t, p.in, a)
+		}
+		t.Run(p.name, f)
+	}
+	a := tpdu.Address{}
+	err := json.Unmarshal([]byte(`{"ton":"bogus","addr":"1234"}`), &a)
+	assert.Equal(t, tpdu.DecodeError("ton", 0, tpdu.ErrInvalid), err)
+	err = json.Unmarshal([]byte(`{"npi":"bogus","addr":"1234"}`), &a)
+	assert.Equal(t, tpdu.DecodeError("npi", 0, tpdu.ErrInvalid), err)
+}
+
+func TestTimestampJSON(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   tpdu.Timestamp
+		out  string
+	}{
+		{"utc", tpdu.Timestamp{Time: time.Date(2017, time.August, 31, 11, 21, 54, 0, time.UTC)},
+			`"2017-08-31T11:21:54Z"`},
+		{"zone", tpdu.Timestamp{Time: time.Date(2017, time.August, 31, 11, 21, 54, 0,
+			time.FixedZone("SCTS", -45*60))},
+			`"2017-08-31T11:21:54-00:45"`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			j, err := json.Marshal(p.in)
+			require.Nil(t, err)
+			assert.Equal(t, p.out, string(j))
+			ts := tpdu.Timestamp{}
+			err = json.Unmarshal(j, &ts)
+			require.Nil(t, err)
+			assert.Equal(t, p.in, ts)
+		}
+		t.Run(p.name, f)
+	}
+	ts := tpdu.Timestamp{}
+	err := json.Unmarshal([]byte(`"yesterday"`), &ts)
+	assert.NotNil(t, err)
+}
+
+func TestValidityPeriodJSON(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   tpdu.ValidityPeriod
+		out  string
+	}{
+		{"not present", tpdu.ValidityPeriod{}, `{"format":"notPresent"}`},
+		{"relative", tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: time.Hour},
+			`{"format":"relative","duration":"1h0m0s"}`},
+		{"enhanced", tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: time.Minute, EFI: 0x42},
+			`{"format":"enhanced","duration":"1m0s","efi":66}`},
+		{"extended", tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: time.Minute, EFI: 0x82,
+			EFIExt: []byte{0x01}},
+			`{"format":"enhanced","duration":"1m0s","efi":130,"efiExt":"01"}`},
+		{"absolute", tpdu.ValidityPeriod{Format: tpdu.VpfAbsolute,
+			Time: tpdu.Timestamp{Time: time.Date(2017, time.August, 31, 11, 21, 54, 0, time.UTC)}},
+			`{"format":"absolute","time":"2017-08-31T11:21:54Z"}`},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			j, err := json.Marshal(p.in)
+			require.Nil(t, err)
+			assert.Equal(t, p.out, string(j))
+			vp := tpdu.ValidityPeriod{}
+			err = json.Unmarshal(j, &vp)
+			require.Nil(t, err)
+			assert.Equal(t, p.in, vp)
+		}
+		t.Run(p.name, f)
+	}
+	vp := tpdu.ValidityPeriod{}
+	err := json.Unmarshal([]byte(`{"format":"bogus"}`), &vp)
+	assert.Equal(t, tpdu.DecodeError("format", 0, tpdu.ErrInvalid), err)
+	err = json.Unmarshal([]byte(`{"format":"relative","duration":"forever"}`), &vp)
+	assert.NotNil(t, err)
+}
+
+func TestUserDataHeaderJSON(t *testing.T) {
+	udh := tpdu.UserDataHeader{
+		{ID: tpdu.IEIConcatenated8, Data: []byte{3, 2, 1}},
+		{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x80, 0x05}},
+		{ID: 0x70, Data: []byte{0xab}},
+		{ID: tpdu.IEIPort16, Data: []byte{1}}, // invalid, so not decoded
+	}
+	j, err := json.Marshal(udh)
+	require.Nil(t, err)
+	expected := `[{"iei":0,"name":"concatenated8","data":"030201","decoded":{"mr":3,"segments":2,"seqNo":1}},` +
+		`{"iei":1,"name":"specialSMSIndication","data":"8005",` +
+		`"decoded":{"store":true,"profileID":0,"extendedType":0,"type":0,"count":5}},` +
+		`{"iei":112,"data":"ab"},` +
+		`{"iei":5,"name":"port16","data":"01"}]`
+	assert.Equal(t, expected, string(j))
+	u := tpdu.UserDataHeader{}
+	err = json.Unmarshal(j, &u)
+	require.Nil(t, err)
+	assert.Equal(t, udh, u)
+
+	var nudh tpdu.UserDataHeader
+	j, err = json.Marshal(nudh)
+	require.Nil(t, err)
+	assert.Equal(t, "null", string(j))
+	err = json.Unmarshal(j, &u)
+	require.Nil(t, err)
+	assert.Nil(t, u)
+
+	err = json.Unmarshal([]byte(`[{"iei":1,"data":"xyz"}]`), &u)
+	assert.NotNil(t, err)
+}
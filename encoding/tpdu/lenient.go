@@ -0,0 +1,209 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"fmt"
+
+	"github.com/warthog618/sms/encoding/semioctet"
+)
+
+// Warning describes a deviation from 3GPP TS 23.040 detected, and recovered
+// from, while decoding a TPDU in lenient mode.
+type Warning struct {
+	// Field is the name of the field containing the deviation.
+	Field string
+
+	// Offset is the offset of the field from the start of the TPDU.
+	Offset int
+
+	// Err is the error that would have been returned by strict decoding.
+	Err error
+
+	// Recovery describes the action taken to recover from the deviation.
+	Recovery string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("tpdu: %s at octet %d: %v - %s", w.Field, w.Offset, w.Err, w.Recovery)
+}
+
+// LenientDecoding is a DecoderOption that makes the Decoder recover from
+// common deviations in the TPDUs it decodes, rather than rejecting them.
+//
+// The deviations recovered are:
+//   - trailing octets beyond the end of the TPDU, which are ignored.
+//   - a UDL exceeding the UD provided, which is reduced to fit the UD.
+//   - an odd length UCS-2 UD, where the final octet is ignored.
+//   - an address with an odd number of digits that is missing the trailing
+//     fill, where the trailing semi-octet is ignored.
+//   - a DCS from a reserved coding group, where the UD is decoded using the
+//     GSM 7 bit default alphabet, as per 3GPP TS 23.038 Section 4, and the
+//     DCS is returned as is.
+//   - an SCTS, DT or absolute VP containing invalid BCD, where the invalid
+//     date or time component is replaced with its lowest valid value, or
+//     the time zone is taken to be UTC.
+//
+// Each deviation is reported as a Warning by DecodeWithWarnings.
+func LenientDecoding(d *Decoder) error {
+	d.lenient = true
+	return nil
+}
+
+// maxRepairs limits the number of repairs applied to a single TPDU.
+const maxRepairs = 16
+
+// decodeLenient decodes the TPDU, repairing any recoverable deviations
+// detected by Dissect before decoding the repaired TPDU with f.
+func decodeLenient(f ConcreteDecoder, src []byte, drn Direction) (PDU, []Warning, error) {
+	buf := append([]byte(nil), src...)
+	var warnings []Warning
+	var fixups []func(pdu PDU)
+	for i := 0; i < maxRepairs; i++ {
+		fields, err := Dissect(buf, drn)
+		if err == nil {
+			break
+		}
+		w, fixup, ok := repair(&buf, fields)
+		if !ok {
+			break
+		}
+		warnings = append(warnings, w)
+		if fixup != nil {
+			fixups = append(fixups, fixup)
+		}
+	}
+	pdu, err := decodePDU(f, buf)
+	if err != nil || pdu == nil {
+		return nil, warnings, err
+	}
+	for _, fixup := range fixups {
+		fixup(pdu)
+	}
+	return pdu, warnings, nil
+}
+
+// repair repairs the deviation in the first invalid field of the fields,
+// which are those returned by Dissect for the TPDU in buf.
+// Returns the Warning describing the deviation, a fixup to apply to the
+// decoded TPDU, if required, and true if the deviation was repaired.
+func repair(buf *[]byte, fields []Field) (Warning, func(pdu PDU), bool) {
+	idx := 0
+	for idx < len(fields)-1 && fields[idx].Err == nil {
+		idx++
+	}
+	f := fields[idx]
+	w := Warning{Field: f.Name, Offset: f.Offset, Err: cause(f.Err)}
+	b := *buf
+	switch f.Name {
+	case "trailing":
+		w.Recovery = fmt.Sprintf("ignored %d trailing octets", f.Length)
+		*buf = b[:f.Offset]
+		return w, nil, true
+	case "oa", "da", "ra":
+		if w.Err != semioctet.ErrMissingFill {
+			return w, nil, false
+		}
+		w.Recovery = "ignored trailing semi-octet"
+		b[f.Offset+f.Length-1] |= 0xf0
+		return w, nil, true
+	case "scts", "dt", "vp":
+		return repairTimestamp(buf, f, w)
+	case "ud", "cd":
+		if idx == 0 {
+			return w, nil, false
+		}
+		return repairUserData(buf, fields[:idx+1], w)
+	}
+	return w, nil, false
+}
+
+// repairUserData repairs deviations in the UD, which is the last of the
+// fields provided, and is preceded by the UDL.
+func repairUserData(buf *[]byte, fields []Field, w Warning) (Warning, func(pdu PDU), bool) {
+	f := fields[len(fields)-1]
+	udl := fields[len(fields)-2]
+	b := *buf
+	var dcs *Field
+	for i := range fields {
+		if fields[i].Name == "dcs" {
+			dcs = &fields[i]
+		}
+	}
+	switch w.Err {
+	case ErrInvalid:
+		if dcs == nil {
+			return w, nil, false
+		}
+		w.Field = "dcs"
+		w.Offset = dcs.Offset
+		w.Recovery = "decoded ud as 7bit"
+		orig := b[dcs.Offset]
+		b[dcs.Offset] = 0x00
+		return w, func(pdu PDU) {
+			pdu.BaseTPDU().DCS = orig
+		}, true
+	case ErrUnderflow, ErrOddUCS2Length:
+		alpha := Alpha8Bit
+		if dcs != nil {
+			p := TPDU{DCS: b[dcs.Offset]}
+			alpha, _ = p.udAlphabet()
+		}
+		l := len(f.Raw)
+		switch alpha {
+		case Alpha7Bit:
+			l = l * 8 / 7
+		case AlphaUCS2:
+			l = l &^ 0x01
+		}
+		if l >= int(udl.Raw[0]) {
+			return w, nil, false
+		}
+		w.Field = udl.Name
+		w.Offset = udl.Offset
+		w.Recovery = fmt.Sprintf("reduced %s from %d to %d", udl.Name, udl.Raw[0], l)
+		b[udl.Offset] = byte(l)
+		// drop any octet no longer covered by the UDL
+		if alpha == AlphaUCS2 {
+			*buf = b[:f.Offset+l]
+		}
+		return w, nil, true
+	}
+	return w, nil, false
+}
+
+// timestampMin contains the lowest valid value of each of the semi-octet
+// fields of a Timestamp.
+var timestampMin = map[string]int{
+	"year":   0,
+	"month":  1,
+	"day":    1,
+	"hour":   0,
+	"minute": 0,
+	"second": 0,
+	"tz":     0,
+}
+
+// repairTimestamp repairs the first invalid component of the timestamp f.
+func repairTimestamp(buf *[]byte, f Field, w Warning) (Warning, func(pdu PDU), bool) {
+	for _, c := range f.Children {
+		v, ok := timestampMin[c.Name]
+		if !ok || c.Err == nil {
+			continue
+		}
+		w.Offset = c.Offset
+		w.Err = c.Err
+		if c.Name == "tz" {
+			w.Recovery = fmt.Sprintf("assumed UTC for %s tz", f.Name)
+		} else {
+			w.Recovery = fmt.Sprintf("replaced %s %s with %02d", f.Name, c.Name, v)
+		}
+		// semi-octets are swapped
+		(*buf)[c.Offset] = byte(v%10)<<4 | byte(v/10)
+		return w, nil, true
+	}
+	return w, nil, false
+}
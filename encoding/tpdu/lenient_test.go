@@ -0,0 +1,192 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/bcd"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestDecodeLenient(t *testing.T) {
+	scts := tpdu.Timestamp{Time: time.Date(2015, time.May, 17, 23, 02, 50, 0,
+		time.FixedZone("SCTS", 8*3600))}
+	haha := []byte("Hahahaha")
+	oa := tpdu.Address{Addr: "6391", TOA: 0x91}
+	patterns := []struct {
+		name     string
+		in       []byte
+		drn      tpdu.Direction
+		out      tpdu.PDU
+		warnings []tpdu.Warning
+		err      error
+	}{
+		{"clean",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha}, OA: oa, SCTS: scts},
+			nil,
+			nil},
+		{"trailing",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3,
+				0xff, 0xff},
+			tpdu.MT,
+			&tpdu.Deliver{TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha}, OA: oa, SCTS: scts},
+			[]tpdu.Warning{
+				{"trailing", 22, tpdu.ErrOverlength, "ignored 2 trailing octets"},
+			},
+			nil},
+		{"udl",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x0a, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha}, OA: oa, SCTS: scts},
+			[]tpdu.Warning{
+				{"udl", 14, tpdu.ErrUnderflow, "reduced udl from 10 to 8"},
+			},
+			nil},
+		{"odd ucs2",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x08, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x03, 0x00, 0x48, 0x00},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x04, DCS: 0x08, UD: []byte{0x00, 0x48}},
+				OA:   oa,
+				SCTS: scts},
+			[]tpdu.Warning{
+				{"udl", 14, tpdu.ErrOddUCS2Length, "reduced udl from 3 to 2"},
+			},
+			nil},
+		{"missing fill",
+			[]byte{0x04, 0x03, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha},
+				OA:   tpdu.Address{Addr: "639", TOA: 0x91},
+				SCTS: scts},
+			[]tpdu.Warning{
+				{"oa", 1, semioctet.ErrMissingFill, "ignored trailing semi-octet"},
+			},
+			nil},
+		{"reserved dcs",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x80, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x04, DCS: 0x80, UD: haha},
+				OA:   oa,
+				SCTS: scts},
+			[]tpdu.Warning{
+				{"dcs", 6, tpdu.ErrInvalid, "decoded ud as 7bit"},
+			},
+			nil},
+		{"bad scts",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x2a, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha},
+				OA:   oa,
+				SCTS: tpdu.Timestamp{Time: time.Date(2015, time.May, 17, 23, 00, 50, 0,
+					time.FixedZone("SCTS", 8*3600))}},
+			[]tpdu.Warning{
+				{"scts", 11, bcd.ErrInvalidOctet(0x2a), "replaced scts minute with 00"},
+			},
+			nil},
+		{"bad scts tz",
+			[]byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+				0x20, 0x05, 0xa3, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x04, UD: haha},
+				OA:   oa,
+				SCTS: tpdu.Timestamp{Time: time.Date(2015, time.May, 17, 23, 02, 50, 0,
+					time.UTC)}},
+			[]tpdu.Warning{
+				{"scts", 13, bcd.ErrInvalidOctet(0xa3), "assumed UTC for scts tz"},
+			},
+			nil},
+		{"bad dt",
+			[]byte{0x06, 0x23, 0x04, 0x91, 0x36, 0x19,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x51, 0x50, 0xf1, 0x32, 0x20, 0x05, 0x23,
+				0x00},
+			tpdu.MT,
+			&tpdu.StatusReport{
+				TPDU: tpdu.TPDU{FirstOctet: 0x06},
+				MR:   0x23,
+				RA:   oa,
+				SCTS: scts,
+				DT: tpdu.Timestamp{Time: time.Date(2015, time.May, 1, 23, 02, 50, 0,
+					time.FixedZone("SCTS", 8*3600))}},
+			[]tpdu.Warning{
+				{"dt", 15, bcd.ErrInvalidOctet(0xf1), "replaced dt day with 01"},
+			},
+			nil},
+		{"udhi empty ud",
+			[]byte{0x40, 0x00, 0x80, 0x00, 0x00, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+				0x11, 0x05},
+			tpdu.MT,
+			&tpdu.Deliver{
+				TPDU: tpdu.TPDU{FirstOctet: 0x40},
+				OA:   tpdu.Address{TOA: 0x80},
+				SCTS: tpdu.Timestamp{Time: time.Date(2011, time.November, 11, 11, 11, 11, 0,
+					time.FixedZone("SCTS", 2*3600+45*60))},
+			},
+			[]tpdu.Warning{
+				{"udl", 12, tpdu.ErrUnderflow, "reduced udl from 5 to 0"},
+			},
+			nil},
+		{"unrecoverable",
+			[]byte{0x04, 0x04, 0x91, 0x36},
+			tpdu.MT,
+			nil,
+			nil,
+			tpdu.DecodeError("oa.addr", 3, tpdu.ErrUnderflow)},
+	}
+	strict, err := tpdu.NewDecoder(
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterStatusReportDecoder)
+	require.Nil(t, err)
+	d, err := tpdu.NewDecoder(
+		tpdu.LenientDecoding,
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterStatusReportDecoder)
+	require.Nil(t, err)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			out, warnings, err := d.DecodeWithWarnings(p.in, p.drn)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.warnings, warnings)
+			if p.out == nil {
+				assert.Nil(t, out)
+			} else {
+				assert.Equal(t, p.out, out)
+			}
+			_, err = strict.Decode(p.in, p.drn)
+			if p.warnings == nil {
+				assert.Equal(t, p.err, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	w := tpdu.Warning{"trailing", 22, tpdu.ErrOverlength, "ignored 2 trailing octets"}
+	assert.Equal(t, "tpdu: trailing at octet 22: overlength - ignored 2 trailing octets",
+		w.String())
+}
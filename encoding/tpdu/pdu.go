@@ -0,0 +1,44 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+// PDU is the interface implemented by all the TPDU types, i.e. Deliver,
+// DeliverReport, Submit, SubmitReport, StatusReport and Command.
+type PDU interface {
+	// MTI returns the MessageType from the first octet of the TPDU.
+	MTI() MessageType
+
+	// Direction returns the Direction in which the TPDU is carried.
+	Direction() Direction
+
+	// MarshalBinary marshals the TPDU into binary.
+	MarshalBinary() ([]byte, error)
+
+	// UnmarshalBinary unmarshals the TPDU from binary.
+	UnmarshalBinary(src []byte) error
+
+	// BaseTPDU returns the fields common to all TPDU types.
+	BaseTPDU() *TPDU
+
+	// HasUD returns true if the TPDU contains the UD field, which is
+	// optional for some TPDU types.
+	HasUD() bool
+
+	// UDHI returns true if the UD contains a UDH.
+	UDHI() bool
+}
+
+// BaseTPDU returns the TPDU itself, so the fields common to all TPDU types
+// are available from a PDU.
+func (p *TPDU) BaseTPDU() *TPDU {
+	return p
+}
+
+// HasUD returns true as the UD is mandatory for most TPDU types.
+// TPDU types for which the UD is optional override this.
+func (p *TPDU) HasUD() bool {
+	return true
+}
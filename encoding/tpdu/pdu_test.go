@@ -0,0 +1,67 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestPDU(t *testing.T) {
+	dr := tpdu.NewDeliverReport()
+	dr.SetDCS(0)
+	sr := tpdu.NewStatusReport()
+	sr.SetUD([]byte("report"))
+	patterns := []struct {
+		name string
+		in   tpdu.PDU
+		mti  tpdu.MessageType
+		drn  tpdu.Direction
+		ud   bool
+	}{
+		{"deliver", tpdu.NewDeliver(), tpdu.MtDeliver, tpdu.MT, true},
+		{"submit", tpdu.NewSubmit(), tpdu.MtSubmit, tpdu.MO, true},
+		{"status report", tpdu.NewStatusReport(), tpdu.MtCommand, tpdu.MT, false},
+		{"status report with ud", sr, tpdu.MtCommand, tpdu.MT, true},
+		{"command", tpdu.NewCommand(), tpdu.MtCommand, tpdu.MO, true},
+		{"deliver report", tpdu.NewDeliverReport(), tpdu.MtDeliver, tpdu.MO, false},
+		{"deliver report without ud", dr, tpdu.MtDeliver, tpdu.MO, false},
+		{"submit report", tpdu.NewSubmitReport(), tpdu.MtSubmit, tpdu.MT, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.mti, p.in.MTI())
+			assert.Equal(t, p.drn, p.in.Direction())
+			assert.Equal(t, p.ud, p.in.HasUD())
+			assert.False(t, p.in.UDHI())
+			p.in.BaseTPDU().SetUDH(tpdu.UserDataHeader{})
+			assert.True(t, p.in.UDHI())
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestPDURoundTrip(t *testing.T) {
+	d, err := tpdu.NewDecoder(
+		tpdu.RegisterDeliverDecoder,
+		tpdu.RegisterSubmitDecoder,
+	)
+	require.Nil(t, err)
+	in := []byte{0x04, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x51, 0x50, 0x71, 0x32,
+		0x20, 0x05, 0x23, 0x08, 0xC8, 0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3}
+	pdu, err := d.Decode(in, tpdu.MT)
+	require.Nil(t, err)
+	assert.Equal(t, tpdu.MT, pdu.Direction())
+	assert.Equal(t, tpdu.UserData("Hahahaha"), pdu.BaseTPDU().UD)
+	out, err := pdu.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, in, out)
+	err = pdu.UnmarshalBinary(in[:1])
+	assert.Equal(t, tpdu.DecodeError("oa.addr", 1, tpdu.ErrUnderflow), err)
+}
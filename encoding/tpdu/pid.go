@@ -0,0 +1,274 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"fmt"
+)
+
+// PID represents the SMS Protocol Identifier field as defined in 3GPP TS
+// 23.040 Section 9.2.3.9.
+type PID byte
+
+const (
+	// PIDDefault is the default PID, being an SME-to-SME protocol with no
+	// interworking.
+	PIDDefault PID = 0x00
+	// PIDType0 identifies a Short Message Type 0, which the MS must
+	// acknowledge but may discard without informing the user.
+	PIDType0 PID = 0x40
+	// PIDReplace1 identifies a Replace Short Message Type 1.
+	// Replace Short Message Types 2-7 follow sequentially.
+	PIDReplace1 PID = 0x41
+	// PIDReplace7 identifies a Replace Short Message Type 7.
+	PIDReplace7 PID = 0x47
+	// PIDDeviceTriggering identifies a Device Triggering Short Message.
+	PIDDeviceTriggering PID = 0x48
+	// PIDEMS identifies an Enhanced Message Service message (obsolete).
+	PIDEMS PID = 0x5e
+	// PIDReturnCall identifies a Return Call Message.
+	PIDReturnCall PID = 0x5f
+	// PIDANSI136RData identifies an ANSI-136 R-DATA message.
+	PIDANSI136RData PID = 0x7c
+	// PIDMEDataDownload identifies an ME Data download message.
+	PIDMEDataDownload PID = 0x7d
+	// PIDMEDepersonalization identifies an ME De-personalization Short
+	// Message.
+	PIDMEDepersonalization PID = 0x7e
+	// PIDSIMDataDownload identifies a (U)SIM Data download message.
+	PIDSIMDataDownload PID = 0x7f
+)
+
+// TelematicDevice identifies the type of telematic device a message is
+// interworked with, as defined in 3GPP TS 23.040 Section 9.2.3.9.
+type TelematicDevice byte
+
+const (
+	// TDImplicit indicates the device type is specific to the SC, or can be
+	// concluded on the basis of the address.
+	TDImplicit TelematicDevice = iota
+	// TDTelex indicates a telex, or teletex reduced to telex format.
+	TDTelex
+	// TDGroup3Telefax indicates a group 3 telefax.
+	TDGroup3Telefax
+	// TDGroup4Telefax indicates a group 4 telefax.
+	TDGroup4Telefax
+	// TDVoiceTelephone indicates a voice telephone, i.e. conversion to
+	// speech.
+	TDVoiceTelephone
+	// TDERMES indicates an ERMES pager.
+	TDERMES
+	// TDNationalPaging indicates a national paging system.
+	TDNationalPaging
+	// TDVideotex indicates Videotex (T.100 [20] /T.101 [21]).
+	TDVideotex
+	// TDTeletexUnspecified indicates teletex, with the carrier unspecified.
+	TDTeletexUnspecified
+	// TDTeletexPSPDN indicates teletex, in PSPDN.
+	TDTeletexPSPDN
+	// TDTeletexCSPDN indicates teletex, in CSPDN.
+	TDTeletexCSPDN
+	// TDTeletexPSTN indicates teletex, in analog PSTN.
+	TDTeletexPSTN
+	// TDTeletexISDN indicates teletex, in digital ISDN.
+	TDTeletexISDN
+	// TDUCI indicates UCI (Universal Computer Interface, ETSI DE/PS 3 01-3).
+	TDUCI
+)
+
+const (
+	// TDMessageHandlingFacility indicates a message handling facility,
+	// known to the SC.
+	TDMessageHandlingFacility TelematicDevice = iota + 0x10
+	// TDX400 indicates any public X.400-based message handling system.
+	TDX400
+	// TDInternetEmail indicates Internet Electronic Mail.
+	TDInternetEmail
+)
+
+const (
+	// TDMobileStation indicates a GSM/UMTS mobile station.
+	// The SC converts the SM from the received TP-DCS to any data coding
+	// scheme supported by that MS.
+	TDMobileStation TelematicDevice = 0x1f
+)
+
+// PIDClass is the broad classification of a PID.
+type PIDClass int
+
+const (
+	// PIDClassSMEToSME indicates an SME-to-SME protocol, with no telematic
+	// interworking.
+	PIDClassSMEToSME PIDClass = iota
+	// PIDClassTelematic indicates telematic interworking.
+	PIDClassTelematic
+	// PIDClassType0 indicates a Short Message Type 0.
+	PIDClassType0
+	// PIDClassReplace indicates a Replace Short Message Type 1-7.
+	PIDClassReplace
+	// PIDClassDeviceTriggering indicates a Device Triggering Short Message.
+	PIDClassDeviceTriggering
+	// PIDClassEMS indicates an Enhanced Message Service message.
+	PIDClassEMS
+	// PIDClassReturnCall indicates a Return Call Message.
+	PIDClassReturnCall
+	// PIDClassANSI136RData indicates an ANSI-136 R-DATA message.
+	PIDClassANSI136RData
+	// PIDClassMEDataDownload indicates an ME Data download message.
+	PIDClassMEDataDownload
+	// PIDClassMEDepersonalization indicates an ME De-personalization
+	// Short Message.
+	PIDClassMEDepersonalization
+	// PIDClassSIMDataDownload indicates a (U)SIM Data download message.
+	PIDClassSIMDataDownload
+	// PIDClassReserved indicates a reserved PID value.
+	PIDClassReserved
+	// PIDClassSCSpecific indicates a PID value assigned by the SC for its
+	// own use.
+	PIDClassSCSpecific
+)
+
+var pidClassNames = map[PIDClass]string{
+	PIDClassSMEToSME:            "sme-to-sme",
+	PIDClassTelematic:           "telematic",
+	PIDClassType0:               "type0",
+	PIDClassReplace:             "replace",
+	PIDClassDeviceTriggering:    "deviceTriggering",
+	PIDClassEMS:                 "ems",
+	PIDClassReturnCall:          "returnCall",
+	PIDClassANSI136RData:        "ansi136RData",
+	PIDClassMEDataDownload:      "meDataDownload",
+	PIDClassMEDepersonalization: "meDepersonalization",
+	PIDClassSIMDataDownload:     "simDataDownload",
+	PIDClassReserved:            "reserved",
+	PIDClassSCSpecific:          "scSpecific",
+}
+
+func (c PIDClass) String() string {
+	if s, ok := pidClassNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("PIDClass(%d)", int(c))
+}
+
+// NewTelematicPID returns the PID indicating telematic interworking with the
+// device.
+// An error is returned if the device is not a valid TelematicDevice.
+func NewTelematicPID(d TelematicDevice) (PID, error) {
+	if d > 0x1f {
+		return PIDDefault, ErrInvalid
+	}
+	return PID(0x20 | d), nil
+}
+
+// NewReplacePID returns the PID for Replace Short Message Type n, where n
+// is in the range 1-7.
+// An error is returned if n is out of range.
+func NewReplacePID(n int) (PID, error) {
+	if n < 1 || n > 7 {
+		return PIDDefault, ErrInvalid
+	}
+	return PIDType0 + PID(n), nil
+}
+
+// Class returns the PIDClass of the PID.
+func (p PID) Class() PIDClass {
+	switch p & 0xc0 {
+	case 0x00:
+		if p&0x20 != 0 {
+			return PIDClassTelematic
+		}
+		return PIDClassSMEToSME
+	case 0x40:
+		switch {
+		case p == PIDType0:
+			return PIDClassType0
+		case p >= PIDReplace1 && p <= PIDReplace7:
+			return PIDClassReplace
+		case p == PIDDeviceTriggering:
+			return PIDClassDeviceTriggering
+		case p == PIDEMS:
+			return PIDClassEMS
+		case p == PIDReturnCall:
+			return PIDClassReturnCall
+		case p == PIDANSI136RData:
+			return PIDClassANSI136RData
+		case p == PIDMEDataDownload:
+			return PIDClassMEDataDownload
+		case p == PIDMEDepersonalization:
+			return PIDClassMEDepersonalization
+		case p == PIDSIMDataDownload:
+			return PIDClassSIMDataDownload
+		}
+		return PIDClassReserved
+	case 0xc0:
+		return PIDClassSCSpecific
+	default:
+		return PIDClassReserved
+	}
+}
+
+// TelematicDevice returns the telematic device the message is interworked
+// with.
+// If the PID does not indicate telematic interworking then ok is false.
+func (p PID) TelematicDevice() (d TelematicDevice, ok bool) {
+	if p.Class() != PIDClassTelematic {
+		return TDImplicit, false
+	}
+	return TelematicDevice(p & 0x1f), true
+}
+
+// SMEProtocol returns the SM-AL protocol used between SMEs.
+// If the PID does not indicate an SME-to-SME protocol then ok is false.
+func (p PID) SMEProtocol() (proto int, ok bool) {
+	if p.Class() != PIDClassSMEToSME {
+		return 0, false
+	}
+	return int(p & 0x1f), true
+}
+
+// ReplaceType returns the Replace Short Message Type (1-7) indicated by the
+// PID.
+// If the PID does not indicate a Replace Short Message then ok is false.
+func (p PID) ReplaceType() (n int, ok bool) {
+	if p.Class() != PIDClassReplace {
+		return 0, false
+	}
+	return int(p - PIDType0), true
+}
+
+// IsType0 returns true if the PID indicates a Short Message Type 0.
+func (p PID) IsType0() bool {
+	return p == PIDType0
+}
+
+// IsReturnCall returns true if the PID indicates a Return Call Message.
+func (p PID) IsReturnCall() bool {
+	return p == PIDReturnCall
+}
+
+// IsDataDownload returns true if the PID indicates an ME or (U)SIM Data
+// download message.
+func (p PID) IsDataDownload() bool {
+	return p == PIDMEDataDownload || p == PIDSIMDataDownload
+}
+
+// Covert returns true if the PID indicates a message that is handled by the
+// MS without being presented to the user, so may be used to covertly probe
+// or reconfigure the MS.
+// These are Short Message Type 0, Device Triggering, ME Data download,
+// ME De-personalization and (U)SIM Data download messages.
+func (p PID) Covert() bool {
+	switch p.Class() {
+	case PIDClassType0,
+		PIDClassDeviceTriggering,
+		PIDClassMEDataDownload,
+		PIDClassMEDepersonalization,
+		PIDClassSIMDataDownload:
+		return true
+	}
+	return false
+}
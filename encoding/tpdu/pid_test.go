@@ -0,0 +1,132 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestPIDClass(t *testing.T) {
+	// might as well test them all..
+	patterns := make(map[int]tpdu.PIDClass)
+	for i := 0x00; i < 0x20; i++ {
+		patterns[i] = tpdu.PIDClassSMEToSME
+	}
+	for i := 0x20; i < 0x40; i++ {
+		patterns[i] = tpdu.PIDClassTelematic
+	}
+	for i := 0x40; i < 0xc0; i++ {
+		patterns[i] = tpdu.PIDClassReserved
+	}
+	for i := 0xc0; i <= 0xff; i++ {
+		patterns[i] = tpdu.PIDClassSCSpecific
+	}
+	patterns[0x40] = tpdu.PIDClassType0
+	for i := 0x41; i < 0x48; i++ {
+		patterns[i] = tpdu.PIDClassReplace
+	}
+	patterns[0x48] = tpdu.PIDClassDeviceTriggering
+	patterns[0x5e] = tpdu.PIDClassEMS
+	patterns[0x5f] = tpdu.PIDClassReturnCall
+	patterns[0x7c] = tpdu.PIDClassANSI136RData
+	patterns[0x7d] = tpdu.PIDClassMEDataDownload
+	patterns[0x7e] = tpdu.PIDClassMEDepersonalization
+	patterns[0x7f] = tpdu.PIDClassSIMDataDownload
+	for i := 0x00; i <= 0xff; i++ {
+		c := patterns[i]
+		f := func(t *testing.T) {
+			p := tpdu.PID(i)
+			assert.Equal(t, c, p.Class())
+			d, ok := p.TelematicDevice()
+			assert.Equal(t, c == tpdu.PIDClassTelematic, ok)
+			if ok {
+				assert.Equal(t, tpdu.TelematicDevice(i&0x1f), d)
+			} else {
+				assert.Equal(t, tpdu.TDImplicit, d)
+			}
+			proto, ok := p.SMEProtocol()
+			assert.Equal(t, c == tpdu.PIDClassSMEToSME, ok)
+			if ok {
+				assert.Equal(t, i, proto)
+			} else {
+				assert.Equal(t, 0, proto)
+			}
+			n, ok := p.ReplaceType()
+			assert.Equal(t, c == tpdu.PIDClassReplace, ok)
+			if ok {
+				assert.Equal(t, i-0x40, n)
+			} else {
+				assert.Equal(t, 0, n)
+			}
+			assert.Equal(t, c == tpdu.PIDClassType0, p.IsType0())
+			assert.Equal(t, c == tpdu.PIDClassReturnCall, p.IsReturnCall())
+			assert.Equal(t, c == tpdu.PIDClassMEDataDownload ||
+				c == tpdu.PIDClassSIMDataDownload, p.IsDataDownload())
+			covert := false
+			switch c {
+			case tpdu.PIDClassType0,
+				tpdu.PIDClassDeviceTriggering,
+				tpdu.PIDClassMEDataDownload,
+				tpdu.PIDClassMEDepersonalization,
+				tpdu.PIDClassSIMDataDownload:
+				covert = true
+			}
+			assert.Equal(t, covert, p.Covert())
+		}
+		t.Run(fmt.Sprintf("%02x", i), f)
+	}
+}
+
+func TestNewTelematicPID(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.TelematicDevice
+		out tpdu.PID
+		err error
+	}{
+		{tpdu.TDImplicit, 0x20, nil},
+		{tpdu.TDGroup3Telefax, 0x22, nil},
+		{tpdu.TDERMES, 0x25, nil},
+		{tpdu.TDUCI, 0x2d, nil},
+		{tpdu.TDX400, 0x31, nil},
+		{tpdu.TDInternetEmail, 0x32, nil},
+		{tpdu.TDMobileStation, 0x3f, nil},
+		{0x20, tpdu.PIDDefault, tpdu.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pid, err := tpdu.NewTelematicPID(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, pid)
+		}
+		t.Run(fmt.Sprintf("%02x", p.in), f)
+	}
+}
+
+func TestNewReplacePID(t *testing.T) {
+	patterns := []struct {
+		in  int
+		out tpdu.PID
+		err error
+	}{
+		{0, tpdu.PIDDefault, tpdu.ErrInvalid},
+		{1, tpdu.PIDReplace1, nil},
+		{4, 0x44, nil},
+		{7, tpdu.PIDReplace7, nil},
+		{8, tpdu.PIDDefault, tpdu.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			pid, err := tpdu.NewReplacePID(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, pid)
+		}
+		t.Run(fmt.Sprintf("%d", p.in), f)
+	}
+}
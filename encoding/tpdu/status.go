@@ -0,0 +1,333 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"fmt"
+)
+
+// Status represents the TP-Status field of a StatusReport, as defined in
+// 3GPP TS 23.040 Section 9.2.3.15.
+//
+// Bit 7 is reserved, and is ignored when classifying the Status.
+type Status byte
+
+const (
+	// Short message transaction completed
+
+	// StatusReceived indicates the short message was received by the SME.
+	StatusReceived Status = 0x00
+	// StatusForwarded indicates the short message was forwarded by the SC to
+	// the SME but the SC is unable to confirm delivery.
+	StatusForwarded Status = 0x01
+	// StatusReplaced indicates the short message was replaced by the SC.
+	StatusReplaced Status = 0x02
+
+	// Temporary error, SC still trying to transfer SM
+
+	// StatusCongestion indicates congestion.
+	StatusCongestion Status = 0x20
+	// StatusSMEBusy indicates the SME is busy.
+	StatusSMEBusy Status = 0x21
+	// StatusNoResponse indicates no response from the SME.
+	StatusNoResponse Status = 0x22
+	// StatusServiceRejected indicates the service was rejected.
+	StatusServiceRejected Status = 0x23
+	// StatusQoSNotAvailable indicates the quality of service is not
+	// available.
+	StatusQoSNotAvailable Status = 0x24
+	// StatusSMEError indicates an error in the SME.
+	StatusSMEError Status = 0x25
+
+	// Permanent error, SC is not making any more transfer attempts
+
+	// StatusRemoteProcedureError indicates a remote procedure error.
+	StatusRemoteProcedureError Status = 0x40
+	// StatusIncompatibleDestination indicates an incompatible destination.
+	StatusIncompatibleDestination Status = 0x41
+	// StatusConnectionRejected indicates the connection was rejected by the
+	// SME.
+	StatusConnectionRejected Status = 0x42
+	// StatusNotObtainable indicates the destination is not obtainable.
+	StatusNotObtainable Status = 0x43
+	// StatusQoSNotAvailablePermanent indicates the quality of service is not
+	// available.
+	StatusQoSNotAvailablePermanent Status = 0x44
+	// StatusNoInterworking indicates no interworking is available.
+	StatusNoInterworking Status = 0x45
+	// StatusVPExpired indicates the SM validity period expired.
+	StatusVPExpired Status = 0x46
+	// StatusDeletedByOriginator indicates the SM was deleted by the
+	// originating SME.
+	StatusDeletedByOriginator Status = 0x47
+	// StatusDeletedBySC indicates the SM was deleted by SC administration.
+	StatusDeletedBySC Status = 0x48
+	// StatusSMNotExist indicates the SM does not exist.
+	StatusSMNotExist Status = 0x49
+
+	// Temporary error, SC is not making any more transfer attempts
+
+	// StatusCongestionGivenUp indicates congestion.
+	StatusCongestionGivenUp Status = 0x60
+	// StatusSMEBusyGivenUp indicates the SME is busy.
+	StatusSMEBusyGivenUp Status = 0x61
+	// StatusNoResponseGivenUp indicates no response from the SME.
+	StatusNoResponseGivenUp Status = 0x62
+	// StatusServiceRejectedGivenUp indicates the service was rejected.
+	StatusServiceRejectedGivenUp Status = 0x63
+	// StatusQoSNotAvailableGivenUp indicates the quality of service is not
+	// available.
+	StatusQoSNotAvailableGivenUp Status = 0x64
+	// StatusSMEErrorGivenUp indicates an error in the SME.
+	StatusSMEErrorGivenUp Status = 0x65
+)
+
+var statusDescriptions = map[Status]string{
+	StatusReceived:                 "short message received by the SME",
+	StatusForwarded:                "short message forwarded by the SC to the SME but the SC is unable to confirm delivery",
+	StatusReplaced:                 "short message replaced by the SC",
+	StatusCongestion:               "congestion, SC still trying",
+	StatusSMEBusy:                  "SME busy, SC still trying",
+	StatusNoResponse:               "no response from SME, SC still trying",
+	StatusServiceRejected:          "service rejected, SC still trying",
+	StatusQoSNotAvailable:          "quality of service not available, SC still trying",
+	StatusSMEError:                 "error in SME, SC still trying",
+	StatusRemoteProcedureError:     "remote procedure error",
+	StatusIncompatibleDestination:  "incompatible destination",
+	StatusConnectionRejected:       "connection rejected by SME",
+	StatusNotObtainable:            "not obtainable",
+	StatusQoSNotAvailablePermanent: "quality of service not available",
+	StatusNoInterworking:           "no interworking available",
+	StatusVPExpired:                "SM validity period expired",
+	StatusDeletedByOriginator:      "SM deleted by originating SME",
+	StatusDeletedBySC:              "SM deleted by SC administration",
+	StatusSMNotExist:               "SM does not exist",
+	StatusCongestionGivenUp:        "congestion, SC not trying",
+	StatusSMEBusyGivenUp:           "SME busy, SC not trying",
+	StatusNoResponseGivenUp:        "no response from SME, SC not trying",
+	StatusServiceRejectedGivenUp:   "service rejected, SC not trying",
+	StatusQoSNotAvailableGivenUp:   "quality of service not available, SC not trying",
+	StatusSMEErrorGivenUp:          "error in SME, SC not trying",
+}
+
+func (s Status) String() string {
+	if d, ok := statusDescriptions[s]; ok {
+		return d
+	}
+	if s.SCSpecific() {
+		return fmt.Sprintf("SC specific (0x%02x)", byte(s))
+	}
+	return fmt.Sprintf("reserved (0x%02x)", byte(s))
+}
+
+// Completed returns true if the Status indicates the short message
+// transaction completed.
+func (s Status) Completed() bool {
+	return s&0x60 == 0x00
+}
+
+// Temporary returns true if the Status indicates a temporary error, whether
+// or not the SC is still trying to transfer the short message.
+func (s Status) Temporary() bool {
+	return s&0x20 != 0
+}
+
+// Permanent returns true if the Status indicates a permanent error.
+func (s Status) Permanent() bool {
+	return s&0x60 == 0x40
+}
+
+// Transient returns true if the Status is not final, i.e. the SC is still
+// trying to transfer the short message and a further StatusReport may
+// follow.
+func (s Status) Transient() bool {
+	return s&0x60 == 0x20
+}
+
+// Final returns true if the SC is no longer trying to transfer the short
+// message, either as the transaction completed or due to an error.
+func (s Status) Final() bool {
+	return !s.Transient()
+}
+
+// Retryable returns true if the Status indicates a temporary error, so the
+// short message may be successfully resubmitted once the SC has stopped
+// trying to transfer it.
+func (s Status) Retryable() bool {
+	return s.Temporary()
+}
+
+// SCSpecific returns true if the Status is a value reserved for SC specific
+// use.
+func (s Status) SCSpecific() bool {
+	return s&0x80 == 0 && s&0x10 != 0
+}
+
+// Status returns the TP-ST of the StatusReport as a Status.
+func (s *StatusReport) Status() Status {
+	return Status(s.ST)
+}
+
+// FailureCause represents the TP-Failure-Cause field of a DeliverReport or
+// SubmitReport, as defined in 3GPP TS 23.040 Section 9.2.3.22.
+type FailureCause byte
+
+const (
+	// TP-PID errors
+
+	// FCSTelematicNotSupported indicates telematic interworking is not
+	// supported.
+	FCSTelematicNotSupported FailureCause = 0x80
+	// FCSType0NotSupported indicates Short Message Type 0 is not supported.
+	FCSType0NotSupported FailureCause = 0x81
+	// FCSCannotReplace indicates the short message cannot be replaced.
+	FCSCannotReplace FailureCause = 0x82
+	// FCSUnspecifiedPIDError indicates an unspecified TP-PID error.
+	FCSUnspecifiedPIDError FailureCause = 0x8f
+
+	// TP-DCS errors
+
+	// FCSAlphabetNotSupported indicates the data coding scheme (alphabet) is
+	// not supported.
+	FCSAlphabetNotSupported FailureCause = 0x90
+	// FCSMessageClassNotSupported indicates the message class is not
+	// supported.
+	FCSMessageClassNotSupported FailureCause = 0x91
+	// FCSUnspecifiedDCSError indicates an unspecified TP-DCS error.
+	FCSUnspecifiedDCSError FailureCause = 0x9f
+
+	// TP-Command errors
+
+	// FCSCommandCannotBeActioned indicates the command cannot be actioned.
+	FCSCommandCannotBeActioned FailureCause = 0xa0
+	// FCSCommandUnsupported indicates the command is unsupported.
+	FCSCommandUnsupported FailureCause = 0xa1
+	// FCSUnspecifiedCommandError indicates an unspecified TP-Command error.
+	FCSUnspecifiedCommandError FailureCause = 0xaf
+
+	// FCSTPDUNotSupported indicates the TPDU is not supported.
+	FCSTPDUNotSupported FailureCause = 0xb0
+
+	// SC errors
+
+	// FCSSCBusy indicates the SC is busy.
+	FCSSCBusy FailureCause = 0xc0
+	// FCSNoSCSubscription indicates there is no SC subscription.
+	FCSNoSCSubscription FailureCause = 0xc1
+	// FCSSCSystemFailure indicates an SC system failure.
+	FCSSCSystemFailure FailureCause = 0xc2
+	// FCSInvalidSMEAddress indicates the SME address is invalid.
+	FCSInvalidSMEAddress FailureCause = 0xc3
+	// FCSDestinationSMEBarred indicates the destination SME is barred.
+	FCSDestinationSMEBarred FailureCause = 0xc4
+	// FCSDuplicateSM indicates the SM was rejected as a duplicate.
+	FCSDuplicateSM FailureCause = 0xc5
+	// FCSVPFNotSupported indicates the TP-VPF is not supported.
+	FCSVPFNotSupported FailureCause = 0xc6
+	// FCSVPNotSupported indicates the TP-VP is not supported.
+	FCSVPNotSupported FailureCause = 0xc7
+
+	// MS errors
+
+	// FCSSIMStorageFull indicates the (U)SIM SMS storage is full.
+	FCSSIMStorageFull FailureCause = 0xd0
+	// FCSNoSIMStorage indicates there is no SMS storage capability in the
+	// (U)SIM.
+	FCSNoSIMStorage FailureCause = 0xd1
+	// FCSMSError indicates an error in the MS.
+	FCSMSError FailureCause = 0xd2
+	// FCSMemoryCapacityExceeded indicates the memory capacity is exceeded.
+	FCSMemoryCapacityExceeded FailureCause = 0xd3
+	// FCSSATBusy indicates the (U)SIM Application Toolkit is busy.
+	FCSSATBusy FailureCause = 0xd4
+	// FCSSIMDataDownloadError indicates a (U)SIM data download error.
+	FCSSIMDataDownloadError FailureCause = 0xd5
+
+	// FCSUnspecified indicates an unspecified error cause.
+	FCSUnspecified FailureCause = 0xff
+)
+
+var failureCauseDescriptions = map[FailureCause]string{
+	FCSTelematicNotSupported:    "telematic interworking not supported",
+	FCSType0NotSupported:        "short message type 0 not supported",
+	FCSCannotReplace:            "cannot replace short message",
+	FCSUnspecifiedPIDError:      "unspecified TP-PID error",
+	FCSAlphabetNotSupported:     "data coding scheme (alphabet) not supported",
+	FCSMessageClassNotSupported: "message class not supported",
+	FCSUnspecifiedDCSError:      "unspecified TP-DCS error",
+	FCSCommandCannotBeActioned:  "command cannot be actioned",
+	FCSCommandUnsupported:       "command unsupported",
+	FCSUnspecifiedCommandError:  "unspecified TP-Command error",
+	FCSTPDUNotSupported:         "TPDU not supported",
+	FCSSCBusy:                   "SC busy",
+	FCSNoSCSubscription:         "no SC subscription",
+	FCSSCSystemFailure:          "SC system failure",
+	FCSInvalidSMEAddress:        "invalid SME address",
+	FCSDestinationSMEBarred:     "destination SME barred",
+	FCSDuplicateSM:              "SM rejected - duplicate SM",
+	FCSVPFNotSupported:          "TP-VPF not supported",
+	FCSVPNotSupported:           "TP-VP not supported",
+	FCSSIMStorageFull:           "(U)SIM SMS storage full",
+	FCSNoSIMStorage:             "no SMS storage capability in (U)SIM",
+	FCSMSError:                  "error in MS",
+	FCSMemoryCapacityExceeded:   "memory capacity exceeded",
+	FCSSATBusy:                  "(U)SIM Application Toolkit busy",
+	FCSSIMDataDownloadError:     "(U)SIM data download error",
+	FCSUnspecified:              "unspecified error cause",
+}
+
+func (f FailureCause) String() string {
+	if d, ok := failureCauseDescriptions[f]; ok {
+		return d
+	}
+	if f.ApplicationSpecific() {
+		return fmt.Sprintf("application specific (0x%02x)", byte(f))
+	}
+	return fmt.Sprintf("reserved (0x%02x)", byte(f))
+}
+
+// ApplicationSpecific returns true if the FailureCause is a value reserved
+// for application specific use.
+func (f FailureCause) ApplicationSpecific() bool {
+	return f >= 0xe0 && f < FCSUnspecified
+}
+
+// Reserved returns true if the FailureCause is a reserved value.
+func (f FailureCause) Reserved() bool {
+	_, ok := failureCauseDescriptions[f]
+	return !ok && !f.ApplicationSpecific()
+}
+
+// Retryable returns true if the FailureCause indicates a temporary
+// condition, such as congestion or full storage, so the short message may
+// be successfully resent later.
+func (f FailureCause) Retryable() bool {
+	switch f {
+	case FCSSCBusy,
+		FCSSCSystemFailure,
+		FCSSIMStorageFull,
+		FCSMemoryCapacityExceeded,
+		FCSSATBusy:
+		return true
+	}
+	return false
+}
+
+// Permanent returns true if the FailureCause indicates the short message
+// will not be accepted if resent unchanged.
+func (f FailureCause) Permanent() bool {
+	return !f.Retryable()
+}
+
+// FailureCause returns the TP-FCS of the DeliverReport as a FailureCause.
+func (d *DeliverReport) FailureCause() FailureCause {
+	return FailureCause(d.FCS)
+}
+
+// FailureCause returns the TP-FCS of the SubmitReport as a FailureCause.
+func (s *SubmitReport) FailureCause() FailureCause {
+	return FailureCause(s.FCS)
+}
@@ -0,0 +1,154 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+func TestStatusClassification(t *testing.T) {
+	type class struct {
+		completed  bool
+		temporary  bool
+		permanent  bool
+		transient  bool
+		final      bool
+		retryable  bool
+		scSpecific bool
+	}
+	patterns := []struct {
+		in  tpdu.Status
+		out class
+	}{
+		{tpdu.StatusReceived, class{completed: true, final: true}},
+		{tpdu.StatusReplaced, class{completed: true, final: true}},
+		{0x10, class{completed: true, final: true, scSpecific: true}},
+		{tpdu.StatusCongestion, class{temporary: true, transient: true, retryable: true}},
+		{0x3f, class{temporary: true, transient: true, retryable: true, scSpecific: true}},
+		{tpdu.StatusVPExpired, class{permanent: true, final: true}},
+		{0x50, class{permanent: true, final: true, scSpecific: true}},
+		{tpdu.StatusSMEBusyGivenUp, class{temporary: true, final: true, retryable: true}},
+		{0x70, class{temporary: true, final: true, retryable: true, scSpecific: true}},
+		{0xc0, class{permanent: true, final: true}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := class{
+				completed:  p.in.Completed(),
+				temporary:  p.in.Temporary(),
+				permanent:  p.in.Permanent(),
+				transient:  p.in.Transient(),
+				final:      p.in.Final(),
+				retryable:  p.in.Retryable(),
+				scSpecific: p.in.SCSpecific(),
+			}
+			assert.Equal(t, p.out, c)
+		}
+		t.Run(fmt.Sprintf("%02x", byte(p.in)), f)
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.Status
+		out string
+	}{
+		{tpdu.StatusReceived, "short message received by the SME"},
+		{tpdu.StatusNoResponse, "no response from SME, SC still trying"},
+		{tpdu.StatusSMNotExist, "SM does not exist"},
+		{tpdu.StatusSMEErrorGivenUp, "error in SME, SC not trying"},
+		{0x03, "reserved (0x03)"},
+		{0x5a, "SC specific (0x5a)"},
+		{0x80, "reserved (0x80)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(fmt.Sprintf("%02x", byte(p.in)), f)
+	}
+}
+
+func TestStatusReportStatus(t *testing.T) {
+	s := tpdu.NewStatusReport()
+	s.ST = 0x46
+	assert.Equal(t, tpdu.StatusVPExpired, s.Status())
+}
+
+func TestFailureCauseClassification(t *testing.T) {
+	type class struct {
+		retryable   bool
+		permanent   bool
+		appSpecific bool
+		reserved    bool
+	}
+	patterns := []struct {
+		in  tpdu.FailureCause
+		out class
+	}{
+		{0x00, class{permanent: true, reserved: true}},
+		{0x7f, class{permanent: true, reserved: true}},
+		{tpdu.FCSTelematicNotSupported, class{permanent: true}},
+		{0x83, class{permanent: true, reserved: true}},
+		{tpdu.FCSAlphabetNotSupported, class{permanent: true}},
+		{tpdu.FCSTPDUNotSupported, class{permanent: true}},
+		{tpdu.FCSSCBusy, class{retryable: true}},
+		{tpdu.FCSSCSystemFailure, class{retryable: true}},
+		{tpdu.FCSInvalidSMEAddress, class{permanent: true}},
+		{tpdu.FCSSIMStorageFull, class{retryable: true}},
+		{tpdu.FCSMemoryCapacityExceeded, class{retryable: true}},
+		{tpdu.FCSSATBusy, class{retryable: true}},
+		{tpdu.FCSSIMDataDownloadError, class{permanent: true}},
+		{0xd6, class{permanent: true, reserved: true}},
+		{0xe0, class{permanent: true, appSpecific: true}},
+		{0xfe, class{permanent: true, appSpecific: true}},
+		{tpdu.FCSUnspecified, class{permanent: true}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			c := class{
+				retryable:   p.in.Retryable(),
+				permanent:   p.in.Permanent(),
+				appSpecific: p.in.ApplicationSpecific(),
+				reserved:    p.in.Reserved(),
+			}
+			assert.Equal(t, p.out, c)
+		}
+		t.Run(fmt.Sprintf("%02x", byte(p.in)), f)
+	}
+}
+
+func TestFailureCauseString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.FailureCause
+		out string
+	}{
+		{tpdu.FCSCannotReplace, "cannot replace short message"},
+		{tpdu.FCSDuplicateSM, "SM rejected - duplicate SM"},
+		{tpdu.FCSUnspecified, "unspecified error cause"},
+		{0x12, "reserved (0x12)"},
+		{0xe5, "application specific (0xe5)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(fmt.Sprintf("%02x", byte(p.in)), f)
+	}
+}
+
+func TestReportFailureCause(t *testing.T) {
+	d := tpdu.NewDeliverReport()
+	d.FCS = 0xd0
+	assert.Equal(t, tpdu.FCSSIMStorageFull, d.FailureCause())
+	s := tpdu.NewSubmitReport()
+	s.FCS = 0xc0
+	assert.Equal(t, tpdu.FCSSCBusy, s.FailureCause())
+}
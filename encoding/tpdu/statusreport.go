@@ -5,6 +5,10 @@
 
 package tpdu
 
+import (
+	"fmt"
+)
+
 // StatusReport represents a SMS-Status-Report PDU as defined in 3GPP TS 23.038 Section 9.2.2.3.
 type StatusReport struct {
 	TPDU
@@ -21,6 +25,16 @@ func NewStatusReport() *StatusReport {
 	return &StatusReport{TPDU: TPDU{FirstOctet: byte(MtCommand)}}
 }
 
+// Direction returns the Direction in which the StatusReport is carried, i.e. MT.
+func (s *StatusReport) Direction() Direction {
+	return MT
+}
+
+// HasUD returns true if the PI indicates the StatusReport contains the UD.
+func (s *StatusReport) HasUD() bool {
+	return s.PI&0x04 != 0
+}
+
 // SetDCS sets the StatusReport dcs field and the corresponding bit of the pi.
 func (s *StatusReport) SetDCS(dcs byte) {
 	s.PI = s.PI | 0x02
@@ -45,6 +59,77 @@ func (s *StatusReport) SetUDH(udh UserDataHeader) {
 	s.TPDU.SetUDH(udh)
 }
 
+// SRQ returns true if the StatusReport is the result of a Command, rather
+// than of a Submit.
+func (s *StatusReport) SRQ() bool {
+	return s.FirstOctet&srrMask != 0
+}
+
+// CommandOutcome is the outcome of a Command, as reported by the
+// StatusReport resulting from the Command.
+type CommandOutcome int
+
+const (
+	// CommandCompleted indicates the Command was actioned.
+	// For an enquiry, the Status of the StatusReport is the status of the
+	// short message the enquiry related to.
+	CommandCompleted CommandOutcome = iota
+	// CommandPending indicates the SC has not yet completed the Command, but
+	// is still trying.
+	CommandPending
+	// CommandSMNotFound indicates the short message the Command related to
+	// does not exist in the SC.
+	CommandSMNotFound
+	// CommandFailed indicates the SC could not action the Command.
+	CommandFailed
+)
+
+var commandOutcomeNames = map[CommandOutcome]string{
+	CommandCompleted:  "completed",
+	CommandPending:    "pending",
+	CommandSMNotFound: "SM not found",
+	CommandFailed:     "failed",
+}
+
+func (o CommandOutcome) String() string {
+	if s, ok := commandOutcomeNames[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("CommandOutcome(%d)", int(o))
+}
+
+// CommandOutcome interprets the StatusReport resulting from a Command of
+// type ct.
+//
+// A delete is considered completed if the SC reports the short message as
+// completed or as deleted by the originating SME.
+// An enquiry is considered completed unless the short message does not
+// exist, as the Status reports the status of the short message rather than
+// of the enquiry.
+//
+// If the StatusReport is not the result of a Command, i.e. TP-SRQ is not
+// set, then ErrInvalid is returned.
+func (s *StatusReport) CommandOutcome(ct CommandType) (CommandOutcome, error) {
+	if !s.SRQ() {
+		return CommandFailed, ErrInvalid
+	}
+	st := s.Status()
+	switch {
+	case st == StatusSMNotExist:
+		return CommandSMNotFound, nil
+	case ct == CTEnquiry:
+		return CommandCompleted, nil
+	case ct == CTDelete && st == StatusDeletedByOriginator:
+		return CommandCompleted, nil
+	case st.Completed():
+		return CommandCompleted, nil
+	case st.Transient():
+		return CommandPending, nil
+	default:
+		return CommandFailed, nil
+	}
+}
+
 // MarshalBinary marshals an SMS-Status-Report TPDU.
 func (s *StatusReport) MarshalBinary() ([]byte, error) {
 	b := []byte{s.FirstOctet, s.MR}
@@ -281,7 +281,7 @@ func TestStatusReportUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterStatusReportDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterStatusReportDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
@@ -99,3 +99,43 @@ func TestStatusReportSetUDH(t *testing.T) {
 		}
 	}
 }
+
+func TestStatusReportCommandOutcome(t *testing.T) {
+	patterns := []struct {
+		name string
+		srq  bool
+		ct   tpdu.CommandType
+		st   tpdu.Status
+		out  tpdu.CommandOutcome
+		err  error
+	}{
+		{"not command", false, tpdu.CTDelete, tpdu.StatusReceived, tpdu.CommandFailed, tpdu.ErrInvalid},
+		{"enquiry", true, tpdu.CTEnquiry, tpdu.StatusSMEBusy, tpdu.CommandCompleted, nil},
+		{"enquiry not found", true, tpdu.CTEnquiry, tpdu.StatusSMNotExist, tpdu.CommandSMNotFound, nil},
+		{"deleted", true, tpdu.CTDelete, tpdu.StatusDeletedByOriginator, tpdu.CommandCompleted, nil},
+		{"delete completed", true, tpdu.CTDelete, tpdu.StatusReceived, tpdu.CommandCompleted, nil},
+		{"delete not found", true, tpdu.CTDelete, tpdu.StatusSMNotExist, tpdu.CommandSMNotFound, nil},
+		{"cancel pending", true, tpdu.CTCancelSRR, tpdu.StatusCongestion, tpdu.CommandPending, nil},
+		{"cancel failed", true, tpdu.CTCancelSRR, tpdu.StatusDeletedByOriginator, tpdu.CommandFailed, nil},
+		{"enable failed", true, tpdu.CTEnableSRR, tpdu.StatusCongestionGivenUp, tpdu.CommandFailed, nil},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			s := tpdu.NewStatusReport()
+			if p.srq {
+				s.FirstOctet |= 0x20
+			}
+			assert.Equal(t, p.srq, s.SRQ())
+			s.ST = byte(p.st)
+			out, err := s.CommandOutcome(p.ct)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCommandOutcomeString(t *testing.T) {
+	assert.Equal(t, "SM not found", tpdu.CommandSMNotFound.String())
+	assert.Equal(t, "CommandOutcome(42)", tpdu.CommandOutcome(42).String())
+}
@@ -18,6 +18,11 @@ func NewSubmit() *Submit {
 	return &Submit{TPDU: TPDU{FirstOctet: byte(MtSubmit)}}
 }
 
+// Direction returns the Direction in which the Submit is carried, i.e. MO.
+func (s *Submit) Direction() Direction {
+	return MO
+}
+
 // MaxUDL returns the maximum number of octets that can be encoded into the UD.
 // Note that for 7bit encoding this can result in up to 160 septets.
 func (s *Submit) MaxUDL() int {
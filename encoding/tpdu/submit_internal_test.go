@@ -34,7 +34,7 @@ var marshalSubmitTestPatterns = []marshalSubmitTestPattern{
 		Submit{
 			TPDU: TPDU{FirstOctet: 1, UD: []byte("Hahahaha")},
 			DA:   Address{Addr: "6391", TOA: 0x91},
-			VP:   ValidityPeriod{VpfRelative, Timestamp{}, time.Duration(6000000000000), 0},
+			VP:   ValidityPeriod{VpfRelative, Timestamp{}, time.Duration(6000000000000), 0, nil},
 		},
 		[]byte{0x01, 0x00, 0x04, 0x91, 0x36, 0x19, 0x00, 0x00, 0x13, 0x08, 0xC8,
 			0x30, 0x3A, 0x8C, 0x0E, 0xA3, 0xC3},
@@ -50,7 +50,7 @@ var marshalSubmitTestPatterns = []marshalSubmitTestPattern{
 		Submit{
 			TPDU: TPDU{FirstOctet: 1, UD: []byte("Hahahaha")},
 			DA:   Address{Addr: "6391", TOA: 0x91},
-			VP:   ValidityPeriod{6, Timestamp{}, 0, 0},
+			VP:   ValidityPeriod{6, Timestamp{}, 0, 0, nil},
 		},
 		nil,
 		EncodeError("vp.vpf", ErrInvalid)},
@@ -170,7 +170,7 @@ func TestSubmitUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterSubmitDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterSubmitDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
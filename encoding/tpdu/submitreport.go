@@ -18,6 +18,16 @@ func NewSubmitReport() *SubmitReport {
 	return &SubmitReport{TPDU: TPDU{FirstOctet: byte(MtSubmit)}}
 }
 
+// Direction returns the Direction in which the SubmitReport is carried, i.e. MT.
+func (s *SubmitReport) Direction() Direction {
+	return MT
+}
+
+// HasUD returns true if the PI indicates the SubmitReport contains the UD.
+func (s *SubmitReport) HasUD() bool {
+	return s.PI&0x04 != 0
+}
+
 // SetDCS sets the SubmitReport dcs field and the corresponding bit of the pi.
 func (s *SubmitReport) SetDCS(dcs byte) {
 	s.PI = s.PI | 0x02
@@ -170,7 +170,7 @@ func TestSubmitReportUnmarshalBinary(t *testing.T) {
 }
 
 func TestRegisterSubmitReportDecoder(t *testing.T) {
-	dec := Decoder{map[byte]ConcreteDecoder{}}
+	dec := Decoder{d: map[byte]ConcreteDecoder{}}
 	err := RegisterSubmitReportDecoder(&dec)
 	if err != nil {
 		t.Errorf("registration should not fail")
@@ -6,11 +6,16 @@
 package tpdu
 
 import (
+	"fmt"
+
 	"github.com/warthog618/sms/encoding/gsm7"
 )
 
 const (
 	udhiMask byte = 0x40
+	// srrMask is the TP-SRR bit of a Command, or the TP-SRQ bit of a
+	// StatusReport.
+	srrMask byte = 0x20
 )
 
 // TPDU is the base type for SMS TPDUs.
@@ -29,6 +34,9 @@ type TPDU struct {
 	//  These have NOT been converted to the corresponding UTF8.
 	//  Use the usc2 package to convert to UTF8.
 	// For Alpha8Bit, UD contains the raw octets.
+	// If the DCS indicates the UD is compressed then UD contains the
+	// compressed octets, irrespective of the Alphabet.
+	//  Decompression is not supported.
 	UD UserData
 }
 
@@ -37,6 +45,15 @@ func (p *TPDU) Alphabet() (Alphabet, error) {
 	return DCS(p.DCS).Alphabet()
 }
 
+// udAlphabet returns the alphabet determining how the UD is encoded.
+// Compressed UD is always encoded as octets.
+func (p *TPDU) udAlphabet() (Alphabet, error) {
+	if DCS(p.DCS).Compressed() {
+		return Alpha8Bit, nil
+	}
+	return p.Alphabet()
+}
+
 // MTI returns the MessageType from the first octet of the SMS TPDU.
 func (p *TPDU) MTI() MessageType {
 	return MessageType(p.FirstOctet & 0x3)
@@ -72,7 +89,7 @@ func (p *TPDU) decodeUserData(src []byte) error {
 	var udh UserDataHeader
 	sml7 := 0
 	ri := 1
-	alphabet, err := p.Alphabet()
+	alphabet, err := p.udAlphabet()
 	if err != nil {
 		return DecodeError("alphabet", ri, err)
 	}
@@ -160,7 +177,7 @@ func (p *TPDU) encodeUserData() (b []byte, err error) {
 		return nil, EncodeError("udh", err)
 	}
 	ud := p.UD
-	alphabet, err := p.Alphabet()
+	alphabet, err := p.udAlphabet()
 	if err != nil {
 		return nil, EncodeError("alphabet", err)
 	}
@@ -211,6 +228,20 @@ const (
 	MtReserved
 )
 
+var messageTypeNames = map[MessageType]string{
+	MtDeliver:  "deliver",
+	MtSubmit:   "submit",
+	MtCommand:  "command",
+	MtReserved: "reserved",
+}
+
+func (mt MessageType) String() string {
+	if s, ok := messageTypeNames[mt]; ok {
+		return s
+	}
+	return fmt.Sprintf("MessageType(%d)", int(mt))
+}
+
 // Direction indicates the direction that the SMS TPDU is carried.
 type Direction int
 
@@ -220,3 +251,13 @@ const (
 	// MO indicates that the SMS TPDU is intended to be sent by the MS.
 	MO
 )
+
+func (d Direction) String() string {
+	switch d {
+	case MT:
+		return "MT"
+	case MO:
+		return "MO"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
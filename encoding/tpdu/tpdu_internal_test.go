@@ -53,6 +53,15 @@ func TestTPDUdecodeUserData(t *testing.T) {
 			nil,
 			UserDataHeader([]InformationElement{{ID: 1, Data: []byte{1, 2, 3}}}),
 			nil},
+		{"compressed 7bit", TPDU{DCS: 0x20},
+			[]byte{0x03, 0x01, 0x02, 0x03},
+			[]byte{0x01, 0x02, 0x03},
+			nil, nil},
+		{"compressed ucs2 udh", TPDU{FirstOctet: 0x40, DCS: 0x28},
+			[]byte{0x09, 5, 0, 3, 1, 2, 3, 0x01, 0x02, 0x03},
+			[]byte{0x01, 0x02, 0x03},
+			UserDataHeader([]InformationElement{{ID: 0, Data: []byte{1, 2, 3}}}),
+			nil},
 		{"bad dcs", TPDU{FirstOctet: 0x40, DCS: 0xaa},
 			[]byte{6, 4, 1, 3, 1, 2}, nil, nil, DecodeError("alphabet", 1, ErrInvalid)},
 		{"overlength", TPDU{FirstOctet: 0x40},
@@ -119,6 +128,14 @@ func TestTPDUencodeUserData(t *testing.T) {
 			UDH: UserDataHeader([]InformationElement{{ID: 1, Data: []byte{1, 2, 3}}})},
 			[]byte{6, 5, 1, 3, 1, 2, 3},
 			nil},
+		{"compressed 7bit", TPDU{DCS: 0x20, UD: []byte{0x01, 0x02, 0x03}},
+			[]byte{0x03, 0x01, 0x02, 0x03},
+			nil},
+		{"compressed ucs2 udh", TPDU{FirstOctet: 0x40, DCS: 0x28,
+			UDH: UserDataHeader([]InformationElement{{ID: 0, Data: []byte{1, 2, 3}}}),
+			UD:  []byte{0x01, 0x02, 0x03}},
+			[]byte{0x09, 5, 0, 3, 1, 2, 3, 0x01, 0x02, 0x03},
+			nil},
 		{"unknown alphabet", TPDU{DCS: 0x80},
 			nil,
 			EncodeError("alphabet", ErrInvalid)},
@@ -127,7 +127,7 @@ func (udh UserDataHeader) ConcatInfo() (segments, seqno, mref int, ok bool) {
 // User Data Header, for the 8bit message reference case.
 // If the UDH contains no segmentation information then ok is false and zero values are returned.
 func (udh UserDataHeader) ConcatInfo8() (segments, seqno, mref int, ok bool) {
-	if c, k := udh.IE(0x00); k && len(c.Data) == 3 {
+	if c, k := udh.IE(IEIConcatenated8); k && len(c.Data) == 3 {
 		ok = true
 		mref = int(c.Data[0])
 		segments = int(c.Data[1])
@@ -140,7 +140,7 @@ func (udh UserDataHeader) ConcatInfo8() (segments, seqno, mref int, ok bool) {
 // User Data Header, for the 16bit message reference case.
 // If the UDH contains no segmentation information then ok is false and zero values are returned.
 func (udh UserDataHeader) ConcatInfo16() (segments, seqno, mref int, ok bool) {
-	if c, k := udh.IE(0x08); k && len(c.Data) == 4 {
+	if c, k := udh.IE(IEIConcatenated16); k && len(c.Data) == 4 {
 		ok = true
 		mref = int(binary.BigEndian.Uint16(c.Data[0:2]))
 		segments = int(c.Data[2])
@@ -149,6 +149,25 @@ func (udh UserDataHeader) ConcatInfo16() (segments, seqno, mref int, ok bool) {
 	return
 }
 
+// PortInfo extracts the application port addressing contained in the provided
+// User Data Header.
+// If the UDH contains no port addressing then ok is false and zero values are returned.
+// The returned values do not distinguish between 8bit and 16bit ports.
+func (udh UserDataHeader) PortInfo() (dst, src int, ok bool) {
+	if p, k := udh.IE(IEIPort16); k && len(p.Data) == 4 {
+		ok = true
+		dst = int(binary.BigEndian.Uint16(p.Data[0:2]))
+		src = int(binary.BigEndian.Uint16(p.Data[2:4]))
+		return
+	}
+	if p, k := udh.IE(IEIPort8); k && len(p.Data) == 2 {
+		ok = true
+		dst = int(p.Data[0])
+		src = int(p.Data[1])
+	}
+	return
+}
+
 // UDDecoder converts TPDU UD to UTF8.
 // By default the translator only supports the default character set.
 // Additional character sets can be added using the AddLockingCharset and
@@ -214,7 +233,7 @@ func (d *UDDecoder) Decode(ud UserData, udh UserDataHeader, alpha Alphabet) ([]b
 		fallthrough
 	case Alpha7Bit:
 		gd := gsm7.NewDecoder()
-		if ie, ok := udh.IE(lockingIEI); ok {
+		if ie, ok := udh.IE(IEINationalLanguageLockingShift); ok {
 			if len(ie.Data) >= 1 {
 				nli := charset.NationalLanguageIdentifier(ie.Data[0])
 				if _, ok := d.locking[nli]; ok {
@@ -222,7 +241,7 @@ func (d *UDDecoder) Decode(ud UserData, udh UserDataHeader, alpha Alphabet) ([]b
 				}
 			}
 		}
-		if ie, ok := udh.IE(shiftIEI); ok {
+		if ie, ok := udh.IE(IEINationalLanguageSingleShift); ok {
 			if len(ie.Data) >= 1 {
 				nli := charset.NationalLanguageIdentifier(ie.Data[0])
 				if _, ok := d.shift[nli]; ok {
@@ -271,11 +290,6 @@ func (e *UDEncoder) AddShiftCharset(nli charset.NationalLanguageIdentifier) {
 	e.s = append(e.s, nli)
 }
 
-const (
-	shiftIEI   byte = 24
-	lockingIEI byte = 25
-)
-
 // Encode converts a UTF8 message into corresponding TPDU User Data.
 // Note that the UD size is not limited to the szie available in a single
 // TPDU, and so may need to be segmented into several concatenated messages.
@@ -303,7 +317,7 @@ func (e *UDEncoder) Encode(msg string) (UserData, UserDataHeader, Alphabet, erro
 		enc, err = lge.Encode([]byte(msg))
 		if err == nil {
 			return enc, UserDataHeader{
-					InformationElement{ID: lockingIEI, Data: []byte{byte(nli)}}},
+					InformationElement{ID: IEINationalLanguageLockingShift, Data: []byte{byte(nli)}}},
 				Alpha7Bit, nil
 		}
 	}
@@ -313,7 +327,7 @@ func (e *UDEncoder) Encode(msg string) (UserData, UserDataHeader, Alphabet, erro
 		enc, err = sge.Encode([]byte(msg))
 		if err == nil {
 			return enc, UserDataHeader{
-					InformationElement{ID: shiftIEI, Data: []byte{byte(nli)}}},
+					InformationElement{ID: IEINationalLanguageSingleShift, Data: []byte{byte(nli)}}},
 				Alpha7Bit, nil
 		}
 	}
@@ -260,6 +260,41 @@ func TestConcatInfo16(t *testing.T) {
 	}
 }
 
+func TestPortInfo(t *testing.T) {
+	patterns := []struct {
+		name string
+		udh  tpdu.UserDataHeader
+		dst  int
+		src  int
+		ok   bool
+	}{
+		{"empty", tpdu.UserDataHeader{}, 0, 0, false},
+		{"port8", tpdu.UserDataHeader{tpdu.InformationElement{ID: 4, Data: []byte{0xf5, 0xf6}}},
+			0xf5, 0xf6, true},
+		{"port16", tpdu.UserDataHeader{tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}}},
+			2948, 9200, true},
+		{"both", tpdu.UserDataHeader{
+			tpdu.InformationElement{ID: 4, Data: []byte{0xf5, 0xf6}},
+			tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}}},
+			2948, 9200, true},
+		{"short port8", tpdu.UserDataHeader{tpdu.InformationElement{ID: 4, Data: []byte{0xf5}}},
+			0, 0, false},
+		{"short port16", tpdu.UserDataHeader{tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23}}},
+			0, 0, false},
+		{"concat", tpdu.UserDataHeader{tpdu.InformationElement{ID: 0, Data: []byte{3, 2, 1}}},
+			0, 0, false},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			dst, src, ok := p.udh.PortInfo()
+			assert.Equal(t, p.ok, ok)
+			assert.Equal(t, p.dst, dst)
+			assert.Equal(t, p.src, src)
+		}
+		t.Run(p.name, f)
+	}
+}
+
 func TestUDDDecode(t *testing.T) {
 	// Also tests NewUDDecoder, AddLockingCharset and AddShiftCharset
 	patterns := []struct {
@@ -277,20 +312,20 @@ func TestUDDDecode(t *testing.T) {
 		{"message reserved", []byte("message\x10"), nil, tpdu.AlphaReserved, 0, 0, []byte("messageΔ"), nil},
 		{"message 7bit esc", []byte("message\x1b"), nil, tpdu.Alpha7Bit, 0, 0, []byte("message "), nil},
 		{"message 7bit locking", []byte("\x01\x02\x03"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 25, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageLockingShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, charset.Kannada, 0, []byte("\u0c82\u0c83\u0c85"), nil},
 		{"message 7bit shift", []byte("\x1b\x1e\x1b\x1f\x1b\x20"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 24, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageSingleShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, 0, charset.Kannada, []byte("\u0ce8\u0ce9\u0cea"), nil},
 		{"message 8bit", []byte("message\x1b"), nil, tpdu.Alpha8Bit, 0, 0, []byte("message\x1b"), nil},
 		{"euro", []byte("\x1be"), nil, tpdu.Alpha7Bit, 0, 0, []byte("€"), nil},
 		{"grin", []byte{0xd8, 0x3d, 0xde, 0x01}, nil, tpdu.AlphaUCS2, 0, 0, []byte("😁"), nil},
 		// repeat the GSM7 Kannada tests without charset to force decoding to fallback to default
 		{"message 7bit locking defaulted", []byte("\x01\x02\x03"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 25, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageLockingShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, 0, 0, []byte("£$¥"), nil},
 		{"message 7bit shift defaulted", []byte("\x1b\x1e\x1b\x1f\x1b\x20"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 24, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageSingleShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, 0, 0, []byte("ßÉ "), nil},
 		// error tests
 		{"dangling surrogate", []byte{0xd8, 0x3d, 0xde, 0x01, 0xd8, 0x3d},
@@ -332,10 +367,10 @@ func TestUDDDecodeAllCharsets(t *testing.T) {
 		{"message reserved", []byte("message\x10"), nil, []byte("messageΔ"), nil},
 		{"message 7bit esc", []byte("message\x1b"), nil, []byte("message "), nil},
 		{"message 7bit locking", []byte("\x01\x02\x03"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 25, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageLockingShift, Data: []byte{byte(charset.Kannada)}}},
 			[]byte("\u0c82\u0c83\u0c85"), nil},
 		{"message 7bit shift", []byte("\x1b\x1e\x1b\x1f\x1b\x20"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 24, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageSingleShift, Data: []byte{byte(charset.Kannada)}}},
 			[]byte("\u0ce8\u0ce9\u0cea"), nil},
 		{"euro", []byte("\x1be"), nil, []byte("€"), nil},
 	}
@@ -372,10 +407,10 @@ func TestUDEEncode(t *testing.T) {
 		{"message 7bit", []byte("message\x10"),
 			nil, tpdu.Alpha7Bit, 0, 0, []byte("messageΔ"), nil},
 		{"message 7bit locking", []byte("\x01\x02\x03"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 25, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageLockingShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, charset.Kannada, 0, []byte("\u0c82\u0c83\u0c85"), nil},
 		{"message 7bit shift", []byte("\x1b\x1e\x1b\x1f\x1b\x20"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 24, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageSingleShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, 0, charset.Kannada, []byte("\u0ce8\u0ce9\u0cea"), nil},
 		{"euro", []byte("\x1be"), nil, tpdu.Alpha7Bit, 0, 0, []byte("€"), nil},
 		{"grin", []byte{0xd8, 0x3d, 0xde, 0x01}, nil, tpdu.AlphaUCS2, 0, 0, []byte("😁"), nil},
@@ -425,10 +460,10 @@ func TestUDEEncodeAllCharsets(t *testing.T) {
 		{"message 7bit", []byte("message\x10"),
 			nil, tpdu.Alpha7Bit, []byte("messageΔ"), nil},
 		{"message 7bit locking", []byte("\x01\x02\x03"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 25, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageLockingShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, []byte("\u0c82\u0c83\u0c85"), nil},
 		{"message 7bit shift", []byte("\x1b\x1e\x1b\x1f\x1b\x20"),
-			tpdu.UserDataHeader{tpdu.InformationElement{ID: 24, Data: []byte{byte(charset.Kannada)}}},
+			tpdu.UserDataHeader{tpdu.InformationElement{ID: tpdu.IEINationalLanguageSingleShift, Data: []byte{byte(charset.Kannada)}}},
 			tpdu.Alpha7Bit, []byte("\u0ce8\u0ce9\u0cea"), nil},
 		{"euro", []byte("\x1be"), nil, tpdu.Alpha7Bit, []byte("€"), nil},
 		{"grin", []byte{0xd8, 0x3d, 0xde, 0x01}, nil, tpdu.AlphaUCS2, []byte("😁"), nil},
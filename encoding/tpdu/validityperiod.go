@@ -6,6 +6,7 @@
 package tpdu
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/warthog618/sms/encoding/bcd"
@@ -17,6 +18,9 @@ type ValidityPeriod struct {
 	Time     Timestamp     // for VpfAbsolute
 	Duration time.Duration // for VpfRelative and VpfEnhanced
 	EFI      byte          // enhanced functionality indicator - first octet of enhanced format
+	// EFIExt contains the additional functionality indicator octets that
+	// follow the EFI if its extension bit is set.
+	EFIExt []byte
 }
 
 // SetAbsolute seth the validity period to an absolute time.
@@ -25,14 +29,18 @@ func (v *ValidityPeriod) SetAbsolute(t Timestamp) {
 	v.Duration = 0
 	v.Time = t
 	v.EFI = 0
+	v.EFIExt = nil
 }
 
 // SetRelative sets the validity period to a relative time.
+// The duration is rounded down to the relative format when marshalled, so
+// use a VPBuilder to control the rounding.
 func (v *ValidityPeriod) SetRelative(d time.Duration) {
 	v.Format = VpfRelative
 	v.Duration = d
 	v.Time = Timestamp{}
 	v.EFI = 0
+	v.EFIExt = nil
 }
 
 // SetEnhanced sets the validity period to an enhnaced format as determined
@@ -42,6 +50,7 @@ func (v *ValidityPeriod) SetEnhanced(d time.Duration, efi byte) {
 	v.Duration = d
 	v.Time = Timestamp{}
 	v.EFI = efi
+	v.EFIExt = nil
 }
 
 // MarshalBinary marshals a ValidityPeriod.
@@ -54,26 +63,36 @@ func (v *ValidityPeriod) MarshalBinary() ([]byte, error) {
 		if evpf > EvpfRelativeHHMMSS {
 			return nil, EncodeError("fi", ErrInvalid)
 		}
+		if err := v.checkEFIExt(); err != nil {
+			return nil, EncodeError("efi", err)
+		}
 		dst := make([]byte, 7)
 		dst[0] = v.EFI
+		base := 1 + copy(dst[1:], v.EFIExt)
 		switch evpf {
 		case EvpfRelative:
-			dst[1] = durationToRelative(v.Duration)
+			dst[base] = durationToRelative(v.Duration)
 		case EvpfRelativeSeconds:
 			secs := v.Duration / time.Second
 			if secs > 255 {
 				secs = 255
 			}
-			dst[1] = byte(secs)
+			dst[base] = byte(secs)
 		case EvpfRelativeHHMMSS:
-			f := []int{int(v.Duration.Hours()) % 100, int(v.Duration.Minutes()) % 60, int(v.Duration.Seconds()) % 60}
+			if v.Duration.Hours() >= 100 {
+				return nil, EncodeError("enhanced", ErrOverlength)
+			}
+			if base+3 > len(dst) {
+				return nil, EncodeError("efi", ErrOverlength)
+			}
+			f := []int{int(v.Duration.Hours()), int(v.Duration.Minutes()) % 60, int(v.Duration.Seconds()) % 60}
 			for i, tf := range f {
 				t, err := bcd.Encode(tf)
 				// this should never trip, as the encoded values should always be valid, but just in case...
 				if err != nil {
 					return nil, EncodeError("enhanced", err)
 				}
-				dst[i+1] = t
+				dst[base+i] = t
 			}
 		}
 		return dst, nil
@@ -86,6 +105,30 @@ func (v *ValidityPeriod) MarshalBinary() ([]byte, error) {
 	return nil, EncodeError("vpf", ErrInvalid)
 }
 
+// checkEFIExt checks that the EFIExt is consistent with the extension bits
+// of the EFI and EFIExt, and leaves room for at least one octet of VP.
+func (v *ValidityPeriod) checkEFIExt() error {
+	if v.EFI&efiExtMask == 0 {
+		if len(v.EFIExt) != 0 {
+			return ErrInvalid
+		}
+		return nil
+	}
+	if len(v.EFIExt) == 0 {
+		return ErrInvalid
+	}
+	if len(v.EFIExt) > maxEFIExt {
+		return ErrOverlength
+	}
+	last := len(v.EFIExt) - 1
+	for i, e := range v.EFIExt {
+		if (e&efiExtMask != 0) != (i != last) {
+			return ErrInvalid
+		}
+	}
+	return nil
+}
+
 // UnmarshalBinary unmarshals a ValidityPeriod stored in the given format.
 // Returns the number of bytes read from the src, and any error detected
 // during the unmarshalling.
@@ -124,24 +167,36 @@ func (v *ValidityPeriod) unmarshalVPEnhanced(src []byte) (int, error) {
 		return 0, ErrUnderflow
 	}
 	efi := src[0]
+	var ext []byte
+	base := 1
+	for e := efi; e&efiExtMask != 0; base++ {
+		if base > maxEFIExt {
+			return 7, DecodeError("efi", base, ErrOverlength)
+		}
+		e = src[base]
+		ext = append(ext, e)
+	}
 	evpf := EnhancedValidityPeriodFormat(efi & 0x7)
 	used := 0
 	d := time.Duration(0)
 	switch evpf {
 	case EvpfNotPresent:
 	case EvpfRelative:
-		d = relativeToDuration(src[1])
+		d = relativeToDuration(src[base])
 		used = 1
 	case EvpfRelativeSeconds:
-		d = time.Second * time.Duration(src[1])
+		d = time.Second * time.Duration(src[base])
 		used = 1
 	case EvpfRelativeHHMMSS:
+		if base+3 > 7 {
+			return 7, DecodeError("efi", base, ErrOverlength)
+		}
 		i := make([]int, 3)
 		var err error
 		for idx := 0; idx < 3; idx++ {
-			i[idx], err = bcd.Decode(src[idx+1])
+			i[idx], err = bcd.Decode(src[base+idx])
 			if err != nil {
-				return 4, DecodeError("enhanced", 1, err)
+				return base + 3, DecodeError("enhanced", base, err)
 			}
 		}
 		d = time.Duration(i[0])*time.Hour + time.Duration(i[1])*time.Minute + time.Duration(i[2])*time.Second
@@ -149,16 +204,30 @@ func (v *ValidityPeriod) unmarshalVPEnhanced(src []byte) (int, error) {
 	default:
 		return 7, DecodeError("enhanced", 0, ErrInvalid)
 	}
-	for i := used + 1; i < 7; i++ {
+	for i := base + used; i < 7; i++ {
 		if src[i] != 0 {
-			return used + 1, DecodeError("enhanced", i, ErrNonZero)
+			return base + used, DecodeError("enhanced", i, ErrNonZero)
 		}
 	}
 	v.EFI = efi
+	v.EFIExt = ext
 	v.Duration = d
 	return 7, nil
 }
 
+const (
+	// efiExtMask is the extension bit of the EFI, and of any subsequent
+	// functionality indicator octets.
+	efiExtMask byte = 0x80
+	// efiSingleShotMask is the single shot SM bit of the EFI.
+	efiSingleShotMask byte = 0x40
+	// maxEFIExt is the maximum number of functionality indicator octets that
+	// may follow the EFI.  The enhanced format is 7 octets, as per 3GPP TS
+	// 23.040 Section 9.2.3.12.3, and must contain the EFI and at least one
+	// octet of VP.
+	maxEFIExt = 5
+)
+
 // ValidityPeriodFormat identifies the format of the ValidityPeriod when encoded to binary.
 type ValidityPeriodFormat byte
 
@@ -174,6 +243,20 @@ const (
 	VpfAbsolute
 )
 
+var vpfNames = map[ValidityPeriodFormat]string{
+	VpfNotPresent: "notPresent",
+	VpfEnhanced:   "enhanced",
+	VpfRelative:   "relative",
+	VpfAbsolute:   "absolute",
+}
+
+func (f ValidityPeriodFormat) String() string {
+	if s, ok := vpfNames[f]; ok {
+		return s
+	}
+	return fmt.Sprintf("ValidityPeriodFormat(%d)", int(f))
+}
+
 // EnhancedValidityPeriodFormat identifies the subformat of the ValidityPeriod
 // when encoded to binary in enhanced format, as per 3GPP TS 23.038 Section 9.2.3.12.3
 type EnhancedValidityPeriodFormat byte
@@ -196,7 +279,7 @@ func durationToRelative(d time.Duration) byte {
 	switch {
 	case d < time.Hour*12:
 		t := byte(d / (time.Minute * 5))
-		if t > 1 {
+		if t > 0 {
 			t--
 		}
 		return t
@@ -69,6 +69,18 @@ var marshalVPPatterns = []marshalVPPattern{
 				time.FixedZone("SCTS", 8*3600))}},
 		[]byte{0x71, 0x80, 0x13, 0x11, 0x12, 0x45, 0x23},
 		nil},
+	{"relative5Minutes",
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfRelative,
+			Duration: 5 * time.Minute},
+		[]byte{0x00},
+		nil},
+	{"relative10Minutes",
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfRelative,
+			Duration: 10 * time.Minute},
+		[]byte{0x01},
+		nil},
 	{"relativeMinutes",
 		tpdu.ValidityPeriod{
 			Format:   tpdu.VpfRelative,
@@ -133,10 +145,50 @@ var marshalVPPatterns = []marshalVPPattern{
 		},
 		[]byte{0x03, 0x30, 0x21, 0x54, 0x00, 0x00, 0x00},
 		nil},
+	{"enhancedSingleShot",
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfEnhanced,
+			EFI:      0x40 | byte(tpdu.EvpfRelativeSeconds),
+			Duration: 30 * time.Second},
+		[]byte{0x42, 0x1e, 0x00, 0x00, 0x00, 0x00, 0x00},
+		nil},
+	{"enhancedExtension",
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfEnhanced,
+			EFI:      0x80 | byte(tpdu.EvpfRelativeHHMMSS),
+			EFIExt:   []byte{0x81, 0x01},
+			Duration: 3*time.Hour + 12*time.Minute + 45*time.Second,
+		},
+		[]byte{0x83, 0x81, 0x01, 0x30, 0x21, 0x54, 0x00},
+		nil},
 	{"invalid enhanced",
 		tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, EFI: 0xff},
 		nil,
 		tpdu.EncodeError("fi", tpdu.ErrInvalid)},
+	{"overlength enhancedHHMMSS",
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfEnhanced,
+			EFI:      byte(tpdu.EvpfRelativeHHMMSS),
+			Duration: 100 * time.Hour,
+		},
+		nil,
+		tpdu.EncodeError("enhanced", tpdu.ErrOverlength)},
+	{"missing extension",
+		tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, EFI: 0x82},
+		nil,
+		tpdu.EncodeError("efi", tpdu.ErrInvalid)},
+	{"unexpected extension",
+		tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, EFI: 0x02, EFIExt: []byte{0x01}},
+		nil,
+		tpdu.EncodeError("efi", tpdu.ErrInvalid)},
+	{"unterminated extension",
+		tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, EFI: 0x82, EFIExt: []byte{0x81}},
+		nil,
+		tpdu.EncodeError("efi", tpdu.ErrInvalid)},
+	{"overlength extension",
+		tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, EFI: 0x83, EFIExt: []byte{0x81, 0x81, 0x81, 0x01}},
+		nil,
+		tpdu.EncodeError("efi", tpdu.ErrOverlength)},
 }
 
 func TestVPMarshalBinary(t *testing.T) {
@@ -246,6 +298,31 @@ var unmarshalVPPatterns = []unmarshalVPPattern{
 			Duration: 3*time.Hour + 12*time.Minute + 45*time.Second,
 		},
 		nil},
+	{"enhancedExtension",
+		[]byte{0x83, 0x81, 0x01, 0x30, 0x21, 0x54, 0x00},
+		tpdu.VpfEnhanced, 7,
+		tpdu.ValidityPeriod{
+			Format:   tpdu.VpfEnhanced,
+			EFI:      0x80 | byte(tpdu.EvpfRelativeHHMMSS),
+			EFIExt:   []byte{0x81, 0x01},
+			Duration: 3*time.Hour + 12*time.Minute + 45*time.Second,
+		},
+		nil},
+	{"overlength extension",
+		[]byte{0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x00},
+		tpdu.VpfEnhanced, 7,
+		tpdu.ValidityPeriod{},
+		tpdu.DecodeError("efi", 6, tpdu.ErrOverlength)},
+	{"overlength extension enhancedHHMMSS",
+		[]byte{0x83, 0x81, 0x81, 0x81, 0x01, 0x30, 0x21},
+		tpdu.VpfEnhanced, 7,
+		tpdu.ValidityPeriod{},
+		tpdu.DecodeError("efi", 5, tpdu.ErrOverlength)},
+	{"nonzero pad enhancedExtension",
+		[]byte{0x82, 0x01, 0x10, 0x00, 0x01, 0x00, 0x00},
+		tpdu.VpfEnhanced, 3,
+		tpdu.ValidityPeriod{},
+		tpdu.DecodeError("enhanced", 4, tpdu.ErrNonZero)},
 	{"underflow relative",
 		nil,
 		tpdu.VpfRelative, 0,
@@ -0,0 +1,326 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rounding identifies how a requested validity is rounded to a validity that
+// can be encoded.
+type Rounding int
+
+const (
+	// RoundNearest rounds to the nearest validity that can be encoded, with
+	// ties rounded up.
+	RoundNearest Rounding = iota
+	// RoundDown rounds to the longest validity that can be encoded and does
+	// not exceed the requested validity.
+	RoundDown
+	// RoundUp rounds to the shortest validity that can be encoded and is no
+	// less than the requested validity.
+	RoundUp
+)
+
+var roundingNames = map[Rounding]string{
+	RoundNearest: "nearest",
+	RoundDown:    "down",
+	RoundUp:      "up",
+}
+
+func (r Rounding) String() string {
+	if s, ok := roundingNames[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("Rounding(%d)", int(r))
+}
+
+// VPEncoding identifies one of the encodings of the validity period
+// considered by the VPBuilder.
+type VPEncoding int
+
+const (
+	// VPRelative is the relative format.
+	VPRelative VPEncoding = iota
+	// VPEnhancedRelative is the enhanced format containing a relative
+	// validity period.
+	VPEnhancedRelative
+	// VPEnhancedSeconds is the enhanced format containing a relative
+	// validity period in seconds, from 0 to 255.
+	VPEnhancedSeconds
+	// VPEnhancedHHMMSS is the enhanced format containing a relative validity
+	// period in hours, minutes and seconds, up to 99:59:59.
+	VPEnhancedHHMMSS
+	// VPAbsolute is the absolute format.
+	VPAbsolute
+)
+
+var vpEncodingNames = map[VPEncoding]string{
+	VPRelative:         "relative",
+	VPEnhancedRelative: "enhancedRelative",
+	VPEnhancedSeconds:  "enhancedSeconds",
+	VPEnhancedHHMMSS:   "enhancedHHMMSS",
+	VPAbsolute:         "absolute",
+}
+
+func (e VPEncoding) String() string {
+	if s, ok := vpEncodingNames[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("VPEncoding(%d)", int(e))
+}
+
+// VPBuilder builds the ValidityPeriod that best encodes a requested validity.
+type VPBuilder struct {
+	rounding  Rounding
+	encodings []VPEncoding
+	efi       byte
+	efiExt    []byte
+	now       func() time.Time
+}
+
+// VPBuilderOption modifies the behaviour of a VPBuilder.
+type VPBuilderOption func(*VPBuilder)
+
+// NewVPBuilder creates a VPBuilder.
+//
+// By default the VPBuilder rounds to the nearest validity, and considers all
+// encodings, other than VPEnhancedRelative.
+func NewVPBuilder(opts ...VPBuilderOption) *VPBuilder {
+	b := &VPBuilder{
+		rounding:  RoundNearest,
+		encodings: []VPEncoding{VPRelative, VPEnhancedSeconds, VPEnhancedHHMMSS, VPAbsolute},
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithRounding sets the rounding policy applied to the requested validity.
+func WithRounding(r Rounding) VPBuilderOption {
+	return func(b *VPBuilder) {
+		b.rounding = r
+	}
+}
+
+// WithVPEncodings restricts the encodings considered by the VPBuilder to
+// those provided.
+func WithVPEncodings(e ...VPEncoding) VPBuilderOption {
+	return func(b *VPBuilder) {
+		b.encodings = append([]VPEncoding(nil), e...)
+	}
+}
+
+// WithSingleShot sets the single shot SM bit in the EFI, requesting the SC
+// make only one delivery attempt.
+//
+// As the EFI is only present in the enhanced format, only the enhanced
+// encodings are considered, and VPRelative is replaced with
+// VPEnhancedRelative.
+func WithSingleShot() VPBuilderOption {
+	return func(b *VPBuilder) {
+		b.efi |= efiSingleShotMask
+	}
+}
+
+// WithEFIExtension sets the extension bit in the EFI, and appends the
+// provided functionality indicator octets, which may be no more than 5.
+// As the enhanced format is limited to 7 octets, the HHMMSS encoding is not
+// considered if more than 3 octets are provided.
+// The extension bits of the provided octets are set as required to chain
+// them.
+//
+// As with WithSingleShot, only the enhanced encodings are considered.
+func WithEFIExtension(ext ...byte) VPBuilderOption {
+	return func(b *VPBuilder) {
+		if len(ext) == 0 {
+			return
+		}
+		b.efi |= efiExtMask
+		b.efiExt = make([]byte, len(ext))
+		last := len(ext) - 1
+		for i, e := range ext {
+			b.efiExt[i] = e | efiExtMask
+			if i == last {
+				b.efiExt[i] = e &^ efiExtMask
+			}
+		}
+	}
+}
+
+// WithClock sets the function providing the current time, which is used to
+// determine absolute validity periods.
+// The default is time.Now.
+func WithClock(now func() time.Time) VPBuilderOption {
+	return func(b *VPBuilder) {
+		b.now = now
+	}
+}
+
+// Duration returns the ValidityPeriod that best encodes a validity of d,
+// along with the effective validity actually encoded.
+//
+// Of the encodings considered, the one with the effective validity closest
+// to d, after rounding, is returned. Ties are resolved in favour of the
+// encoding first listed in VPEncoding.
+func (b *VPBuilder) Duration(d time.Duration) (ValidityPeriod, time.Duration, error) {
+	now := b.now()
+	return b.build(d, now, now.Location())
+}
+
+// Deadline returns the ValidityPeriod that best encodes a validity ending
+// at t, along with the effective validity actually encoded, measured from
+// the current time.
+//
+// If the absolute format is selected then the validity period is encoded
+// in the time zone of t.
+func (b *VPBuilder) Deadline(t time.Time) (ValidityPeriod, time.Duration, error) {
+	now := b.now()
+	return b.build(t.Sub(now), now, t.Location())
+}
+
+func (b *VPBuilder) build(d time.Duration, now time.Time, loc *time.Location) (ValidityPeriod, time.Duration, error) {
+	if d < 0 {
+		return ValidityPeriod{}, 0, EncodeError("vp", ErrInvalid)
+	}
+	enhancedOnly := b.efi != 0
+	if len(b.efiExt) > maxEFIExt {
+		return ValidityPeriod{}, 0, EncodeError("efi", ErrOverlength)
+	}
+	found := false
+	var best VPEncoding
+	var eff time.Duration
+	for _, e := range b.encodings {
+		if enhancedOnly {
+			switch e {
+			case VPRelative:
+				e = VPEnhancedRelative
+			case VPAbsolute:
+				continue
+			}
+		}
+		if e == VPEnhancedHHMMSS && 1+len(b.efiExt)+3 > 7 {
+			continue
+		}
+		v, ok := b.round(e, d, now)
+		if !ok {
+			continue
+		}
+		if !found || absDuration(v-d) < absDuration(eff-d) ||
+			(absDuration(v-d) == absDuration(eff-d) && e < best) {
+			found = true
+			best = e
+			eff = v
+		}
+	}
+	if !found {
+		if b.rounding == RoundDown {
+			return ValidityPeriod{}, 0, EncodeError("vp", ErrUnderflow)
+		}
+		return ValidityPeriod{}, 0, EncodeError("vp", ErrOverlength)
+	}
+	vp := ValidityPeriod{Format: VpfEnhanced, Duration: eff, EFI: b.efi}
+	if len(b.efiExt) > 0 {
+		vp.EFIExt = append([]byte(nil), b.efiExt...)
+	}
+	switch best {
+	case VPRelative:
+		vp.SetRelative(eff)
+	case VPEnhancedRelative:
+		vp.EFI |= byte(EvpfRelative)
+	case VPEnhancedSeconds:
+		vp.EFI |= byte(EvpfRelativeSeconds)
+	case VPEnhancedHHMMSS:
+		vp.EFI |= byte(EvpfRelativeHHMMSS)
+	case VPAbsolute:
+		vp.SetAbsolute(Timestamp{Time: now.Add(eff).In(timestampLocation(loc, now.Add(eff)))})
+	}
+	return vp, eff, nil
+}
+
+// round returns the effective validity for d when encoded using e.
+// Returns false if d cannot be encoded using e with the rounding policy.
+func (b *VPBuilder) round(e VPEncoding, d time.Duration, now time.Time) (time.Duration, bool) {
+	var lo, hi time.Duration
+	var loOK, hiOK bool
+	switch e {
+	case VPRelative, VPEnhancedRelative:
+		for t := 0; t < 256; t++ {
+			v := relativeToDuration(byte(t))
+			if v <= d {
+				lo, loOK = v, true
+			}
+			if v >= d && !hiOK {
+				hi, hiOK = v, true
+			}
+		}
+	case VPEnhancedSeconds:
+		lo, hi, loOK, hiOK = steps(d, time.Second, 255*time.Second)
+	case VPEnhancedHHMMSS:
+		lo, hi, loOK, hiOK = steps(d, time.Second, 100*time.Hour-time.Second)
+	case VPAbsolute:
+		t := now.Add(d)
+		lt := t.Truncate(time.Second)
+		ht := lt
+		if !ht.Equal(t) {
+			ht = ht.Add(time.Second)
+		}
+		lo, loOK = lt.Sub(now), !lt.Before(now) && absoluteYearOK(lt)
+		hi, hiOK = ht.Sub(now), absoluteYearOK(ht)
+	default:
+		return 0, false
+	}
+	switch b.rounding {
+	case RoundDown:
+		return lo, loOK
+	case RoundUp:
+		return hi, hiOK
+	}
+	if !loOK || (hiOK && hi-d <= d-lo) {
+		return hi, hiOK
+	}
+	return lo, loOK
+}
+
+// steps returns the multiples of step either side of d, limited to max.
+func steps(d, step, max time.Duration) (lo, hi time.Duration, loOK, hiOK bool) {
+	if d >= max {
+		return max, max, true, d == max
+	}
+	lo = d - d%step
+	hi = lo
+	if lo != d {
+		hi += step
+	}
+	return lo, hi, true, true
+}
+
+// absoluteYearOK returns true if the year of t can be encoded in a
+// Timestamp, which only encodes the last two digits of the year.
+func absoluteYearOK(t time.Time) bool {
+	y := t.UTC().Year()
+	return y >= 1970 && y < 2070
+}
+
+// timestampLocation returns loc if the offset of t in loc can be encoded in
+// a Timestamp, else UTC.
+func timestampLocation(loc *time.Location, t time.Time) *time.Location {
+	_, offset := t.In(loc).Zone()
+	if offset%(15*60) != 0 || offset > 79*15*60 || offset < -79*15*60 {
+		return time.UTC
+	}
+	return loc
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
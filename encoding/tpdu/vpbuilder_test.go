@@ -0,0 +1,325 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tpdu_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+var vpNow = time.Date(2018, time.March, 1, 12, 0, 0, 0, time.FixedZone("AEST", 10*3600))
+
+func vpClock() time.Time {
+	return vpNow
+}
+
+func TestVPBuilderDuration(t *testing.T) {
+	patterns := []struct {
+		name string
+		opts []tpdu.VPBuilderOption
+		in   time.Duration
+		out  tpdu.ValidityPeriod
+		eff  time.Duration
+		err  error
+	}{
+		{"relative",
+			nil,
+			90 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 90 * time.Minute},
+			90 * time.Minute,
+			nil},
+		{"relative weeks",
+			nil,
+			3 * 7 * 24 * time.Hour,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 3 * 7 * 24 * time.Hour},
+			3 * 7 * 24 * time.Hour,
+			nil},
+		{"seconds",
+			nil,
+			100 * time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 100 * time.Second, EFI: 0x02},
+			100 * time.Second,
+			nil},
+		{"hhmmss",
+			nil,
+			2*time.Hour + 30*time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 2*time.Hour + 30*time.Second, EFI: 0x03},
+			2*time.Hour + 30*time.Second,
+			nil},
+		{"absolute",
+			nil,
+			200*24*time.Hour + time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfAbsolute,
+				Time: tpdu.Timestamp{Time: vpNow.Add(200*24*time.Hour + time.Second)}},
+			200*24*time.Hour + time.Second,
+			nil},
+		{"relative nearest down",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative)},
+			12 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 10 * time.Minute},
+			10 * time.Minute,
+			nil},
+		{"relative nearest minimum",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative)},
+			7 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 5 * time.Minute},
+			5 * time.Minute,
+			nil},
+		{"relative nearest up",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative)},
+			8 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 10 * time.Minute},
+			10 * time.Minute,
+			nil},
+		{"relative nearest tie",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative)},
+			7*time.Minute + 30*time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 10 * time.Minute},
+			10 * time.Minute,
+			nil},
+		{"relative down",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPRelative),
+				tpdu.WithRounding(tpdu.RoundDown)},
+			14 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 10 * time.Minute},
+			10 * time.Minute,
+			nil},
+		{"relative down minimum",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPRelative),
+				tpdu.WithRounding(tpdu.RoundDown)},
+			9 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 5 * time.Minute},
+			5 * time.Minute,
+			nil},
+		{"relative up",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPRelative),
+				tpdu.WithRounding(tpdu.RoundUp)},
+			36 * time.Hour,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 48 * time.Hour},
+			48 * time.Hour,
+			nil},
+		{"relative clamp",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative)},
+			70 * 7 * 24 * time.Hour,
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 63 * 7 * 24 * time.Hour},
+			63 * 7 * 24 * time.Hour,
+			nil},
+		{"relative overlength",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPRelative),
+				tpdu.WithRounding(tpdu.RoundUp)},
+			70 * 7 * 24 * time.Hour,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrOverlength)},
+		{"relative underflow",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPRelative),
+				tpdu.WithRounding(tpdu.RoundDown)},
+			time.Minute,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrUnderflow)},
+		{"seconds rounding",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPEnhancedSeconds)},
+			1500 * time.Millisecond,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 2 * time.Second, EFI: 0x02},
+			2 * time.Second,
+			nil},
+		{"hhmmss clamp",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPEnhancedHHMMSS),
+				tpdu.WithRounding(tpdu.RoundDown)},
+			120 * time.Hour,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 100*time.Hour - time.Second, EFI: 0x03},
+			100*time.Hour - time.Second,
+			nil},
+		{"absolute down",
+			[]tpdu.VPBuilderOption{
+				tpdu.WithVPEncodings(tpdu.VPAbsolute),
+				tpdu.WithRounding(tpdu.RoundDown)},
+			time.Hour + 1500*time.Millisecond,
+			tpdu.ValidityPeriod{Format: tpdu.VpfAbsolute,
+				Time: tpdu.Timestamp{Time: vpNow.Add(time.Hour + time.Second)}},
+			time.Hour + time.Second,
+			nil},
+		{"absolute overlength",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPAbsolute)},
+			100 * 365 * 24 * time.Hour,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrOverlength)},
+		{"single shot",
+			[]tpdu.VPBuilderOption{tpdu.WithSingleShot()},
+			90 * time.Minute,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 90 * time.Minute, EFI: 0x41},
+			90 * time.Minute,
+			nil},
+		{"single shot no absolute",
+			[]tpdu.VPBuilderOption{tpdu.WithSingleShot()},
+			200*24*time.Hour + time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 203 * 24 * time.Hour, EFI: 0x41},
+			203 * 24 * time.Hour,
+			nil},
+		{"extension",
+			[]tpdu.VPBuilderOption{tpdu.WithEFIExtension(0x81, 0x02)},
+			100 * time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 100 * time.Second, EFI: 0x82,
+				EFIExt: []byte{0x81, 0x02}},
+			100 * time.Second,
+			nil},
+		{"extension max",
+			[]tpdu.VPBuilderOption{tpdu.WithEFIExtension(1, 2, 3, 4, 5),
+				tpdu.WithVPEncodings(tpdu.VPEnhancedHHMMSS, tpdu.VPEnhancedSeconds)},
+			100 * time.Second,
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 100 * time.Second, EFI: 0x82,
+				EFIExt: []byte{0x81, 0x82, 0x83, 0x84, 0x05}},
+			100 * time.Second,
+			nil},
+		{"extension overlength",
+			[]tpdu.VPBuilderOption{tpdu.WithEFIExtension(1, 2, 3, 4, 5, 6)},
+			100 * time.Second,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("efi", tpdu.ErrOverlength)},
+		{"no encodings",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings()},
+			time.Hour,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrOverlength)},
+		{"negative",
+			nil,
+			-time.Hour,
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrInvalid)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			opts := append([]tpdu.VPBuilderOption{tpdu.WithClock(vpClock)}, p.opts...)
+			b := tpdu.NewVPBuilder(opts...)
+			vp, eff, err := b.Duration(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.eff, eff)
+			assert.Equal(t, p.out, vp)
+			if err != nil {
+				return
+			}
+			// effective validity must survive a round trip
+			bin, err := vp.MarshalBinary()
+			assert.Nil(t, err)
+			var uvp tpdu.ValidityPeriod
+			_, err = uvp.UnmarshalBinary(bin, vp.Format)
+			assert.Nil(t, err)
+			if vp.Format == tpdu.VpfAbsolute {
+				assert.Equal(t, eff, uvp.Time.Sub(vpNow))
+			} else {
+				assert.Equal(t, eff, uvp.Duration)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestVPBuilderDeadline(t *testing.T) {
+	utc := vpNow.Add(48*time.Hour + 30*time.Second).UTC()
+	odd := time.FixedZone("odd", 10*3600+60)
+	patterns := []struct {
+		name string
+		opts []tpdu.VPBuilderOption
+		in   time.Time
+		out  tpdu.ValidityPeriod
+		eff  time.Duration
+		err  error
+	}{
+		{"relative",
+			nil,
+			vpNow.Add(48 * time.Hour),
+			tpdu.ValidityPeriod{Format: tpdu.VpfRelative, Duration: 48 * time.Hour},
+			48 * time.Hour,
+			nil},
+		{"hhmmss",
+			nil,
+			vpNow.Add(48*time.Hour + 30*time.Second),
+			tpdu.ValidityPeriod{Format: tpdu.VpfEnhanced, Duration: 48*time.Hour + 30*time.Second, EFI: 0x03},
+			48*time.Hour + 30*time.Second,
+			nil},
+		{"absolute zone",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPRelative, tpdu.VPAbsolute)},
+			utc,
+			tpdu.ValidityPeriod{Format: tpdu.VpfAbsolute, Time: tpdu.Timestamp{Time: utc}},
+			48*time.Hour + 30*time.Second,
+			nil},
+		{"absolute odd zone",
+			[]tpdu.VPBuilderOption{tpdu.WithVPEncodings(tpdu.VPAbsolute)},
+			utc.In(odd),
+			tpdu.ValidityPeriod{Format: tpdu.VpfAbsolute, Time: tpdu.Timestamp{Time: utc}},
+			48*time.Hour + 30*time.Second,
+			nil},
+		{"past",
+			nil,
+			vpNow.Add(-time.Second),
+			tpdu.ValidityPeriod{},
+			0,
+			tpdu.EncodeError("vp", tpdu.ErrInvalid)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			opts := append([]tpdu.VPBuilderOption{tpdu.WithClock(vpClock)}, p.opts...)
+			b := tpdu.NewVPBuilder(opts...)
+			vp, eff, err := b.Deadline(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.eff, eff)
+			assert.Equal(t, p.out, vp)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestRoundingString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.Rounding
+		out string
+	}{
+		{tpdu.RoundNearest, "nearest"},
+		{tpdu.RoundDown, "down"},
+		{tpdu.RoundUp, "up"},
+		{tpdu.Rounding(42), "Rounding(42)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
+
+func TestVPEncodingString(t *testing.T) {
+	patterns := []struct {
+		in  tpdu.VPEncoding
+		out string
+	}{
+		{tpdu.VPRelative, "relative"},
+		{tpdu.VPEnhancedRelative, "enhancedRelative"},
+		{tpdu.VPEnhancedSeconds, "enhancedSeconds"},
+		{tpdu.VPEnhancedHHMMSS, "enhancedHHMMSS"},
+		{tpdu.VPAbsolute, "absolute"},
+		{tpdu.VPEncoding(42), "VPEncoding(42)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
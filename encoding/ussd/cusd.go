@@ -0,0 +1,131 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ussd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Status is the status of a USSD session, as reported in a +CUSD response
+// and defined in 3GPP TS 27.007 Section 7.15.
+type Status int
+
+const (
+	// StatusNoActionRequired indicates no further user action is required.
+	StatusNoActionRequired Status = iota
+	// StatusActionRequired indicates further user action is required, i.e.
+	// the network expects a reply.
+	StatusActionRequired
+	// StatusTerminated indicates the session was terminated by the network.
+	StatusTerminated
+	// StatusOtherClient indicates another local client has responded.
+	StatusOtherClient
+	// StatusNotSupported indicates the operation is not supported.
+	StatusNotSupported
+	// StatusTimeout indicates the network timed out.
+	StatusTimeout
+)
+
+var statusNames = map[Status]string{
+	StatusNoActionRequired: "noActionRequired",
+	StatusActionRequired:   "actionRequired",
+	StatusTerminated:       "terminated",
+	StatusOtherClient:      "otherClient",
+	StatusNotSupported:     "notSupported",
+	StatusTimeout:          "timeout",
+}
+
+func (s Status) String() string {
+	if n, ok := statusNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Response is an unsolicited result code or response returned by a modem
+// for a USSD session, in the form:
+//
+//	+CUSD: <m>[,<str>[,<dcs>]]
+type Response struct {
+	Status Status
+
+	// Str is the USSD string, as a hex string.
+	// Empty if the response contains no string.
+	Str string
+
+	// DCS is the coding of Str.
+	DCS tpdu.CBSDCS
+}
+
+// cusdPrefix is the prefix of the +CUSD response.
+const cusdPrefix = "+CUSD:"
+
+// ParseCUSD parses a +CUSD response.
+// The +CUSD: prefix is optional.
+func ParseCUSD(s string) (*Response, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(strings.TrimPrefix(s, cusdPrefix))
+	fields := splitFields(s)
+	if len(fields) < 1 || len(fields) > 3 {
+		return nil, ErrInvalid
+	}
+	m, err := strconv.Atoi(fields[0])
+	if err != nil || m < 0 {
+		return nil, ErrInvalid
+	}
+	r := Response{Status: Status(m)}
+	if len(fields) > 1 {
+		str := fields[1]
+		if len(str) < 2 || str[0] != '"' || str[len(str)-1] != '"' {
+			return nil, ErrInvalid
+		}
+		r.Str = str[1 : len(str)-1]
+	}
+	if len(fields) > 2 {
+		dcs, err := strconv.ParseUint(fields[2], 10, 8)
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		r.DCS = tpdu.CBSDCS(dcs)
+	}
+	return &r, nil
+}
+
+// Message decodes the USSD string contained in the response.
+// Returns nil if the response contains no string.
+func (r *Response) Message(opts ...DecodeOption) (*Message, error) {
+	if r.Str == "" {
+		return nil, nil
+	}
+	return DecodeString(r.DCS, r.Str, opts...)
+}
+
+// splitFields splits the comma separated fields, ignoring commas within
+// quoted strings, and trims surrounding whitespace from each field.
+func splitFields(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var fields []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				fields = append(fields, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, strings.TrimSpace(s[start:]))
+}
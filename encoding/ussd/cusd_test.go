@@ -0,0 +1,90 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ussd_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/ussd"
+)
+
+func TestParseCUSD(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		out  *ussd.Response
+		err  error
+	}{
+		{"status only", "+CUSD: 2", &ussd.Response{Status: ussd.StatusTerminated}, nil},
+		{"full", "+CUSD: 0,\"aa180c3602\",15",
+			&ussd.Response{Status: ussd.StatusNoActionRequired, Str: "aa180c3602", DCS: 0x0f},
+			nil},
+		{"no prefix", "1,\"aa180c3602\",15",
+			&ussd.Response{Status: ussd.StatusActionRequired, Str: "aa180c3602", DCS: 0x0f},
+			nil},
+		{"whitespace", "  +CUSD:1 , \"aa180c3602\" , 72\r\n",
+			&ussd.Response{Status: ussd.StatusActionRequired, Str: "aa180c3602", DCS: 0x48},
+			nil},
+		{"no dcs", "+CUSD: 0,\"aa180c3602\"",
+			&ussd.Response{Str: "aa180c3602"}, nil},
+		{"quoted comma", "+CUSD: 0,\"a,b\",68",
+			&ussd.Response{Str: "a,b", DCS: 0x44}, nil},
+		{"empty", "", nil, ussd.ErrInvalid},
+		{"prefix only", "+CUSD:", nil, ussd.ErrInvalid},
+		{"bad status", "+CUSD: x", nil, ussd.ErrInvalid},
+		{"negative status", "+CUSD: -1", nil, ussd.ErrInvalid},
+		{"unquoted", "+CUSD: 0,aa,15", nil, ussd.ErrInvalid},
+		{"bad dcs", "+CUSD: 0,\"aa\",256", nil, ussd.ErrInvalid},
+		{"too many fields", "+CUSD: 0,\"aa\",15,1", nil, ussd.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			r, err := ussd.ParseCUSD(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, r)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestResponseMessage(t *testing.T) {
+	r := ussd.Response{Str: "aa180c3602", DCS: 0x0f}
+	m, err := r.Message()
+	assert.Nil(t, err)
+	assert.Equal(t, &ussd.Message{DCS: 0x0f, Text: []byte("*100#")}, m)
+
+	r = ussd.Response{Str: "2a31303023", DCS: 0x0f}
+	m, err = r.Message(ussd.WithUnpacked())
+	assert.Nil(t, err)
+	assert.Equal(t, &ussd.Message{DCS: 0x0f, Text: []byte("*100#")}, m)
+
+	r = ussd.Response{Status: ussd.StatusTimeout}
+	m, err = r.Message()
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+}
+
+func TestStatusString(t *testing.T) {
+	patterns := []struct {
+		in  ussd.Status
+		out string
+	}{
+		{ussd.StatusNoActionRequired, "noActionRequired"},
+		{ussd.StatusActionRequired, "actionRequired"},
+		{ussd.StatusTerminated, "terminated"},
+		{ussd.StatusOtherClient, "otherClient"},
+		{ussd.StatusNotSupported, "notSupported"},
+		{ussd.StatusTimeout, "timeout"},
+		{ussd.Status(6), "Status(6)"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.in.String())
+		}
+		t.Run(p.out, f)
+	}
+}
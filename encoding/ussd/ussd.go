@@ -0,0 +1,240 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package ussd provides encoding and decoding of USSD strings, as described
+// in 3GPP TS 23.038 and 3GPP TS 24.090.
+//
+// USSD strings are coded using the Cell Broadcast Data Coding Scheme, and
+// GSM7 strings are packed as per 3GPP TS 23.038 Section 6.1.2.3.
+package ussd
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/warthog618/sms/encoding/cbs"
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/encoding/ucs2"
+)
+
+const (
+	// MaxOctets is the maximum length of a USSD string, in octets.
+	MaxOctets = 160
+
+	// MaxSeptets is the maximum length of a GSM7 USSD string, in septets.
+	// This is the 182 character limit, with escaped characters counting
+	// as two.
+	MaxSeptets = MaxOctets * 8 / 7
+)
+
+// Message is a decoded USSD string.
+type Message struct {
+	DCS tpdu.CBSDCS
+
+	// Language is the ISO 639-1 code for the language of the string,
+	// as indicated by either the DCS or the start of the string.
+	// An empty Language indicates the language is unspecified.
+	Language string
+
+	// Text is the content of the string.
+	// For GSM7 and UCS2 the content is converted to UTF-8.
+	// For 8bit the content is the raw octets of the string.
+	Text []byte
+}
+
+// encoder contains the configuration for Encode.
+type encoder struct {
+	lang string
+}
+
+// EncodeOption modifies the behaviour of Encode.
+type EncodeOption func(*encoder)
+
+// WithLanguage sets the language of the string, as an ISO 639-1 code.
+//
+// If the language has a CBS language group, and the string can be encoded
+// using GSM7, then the language is indicated by the DCS, else the language
+// is indicated by a prefix at the start of the string.
+func WithLanguage(lang string) EncodeOption {
+	return func(e *encoder) {
+		e.lang = lang
+	}
+}
+
+// Encode converts the UTF-8 message into a USSD string.
+//
+// The message is encoded using the GSM7 default alphabet, if possible, else
+// UCS2.  The DCS and the encoded string are returned.
+//
+// Returns ErrOverlength if the encoded string exceeds MaxSeptets for GSM7,
+// or MaxOctets for UCS2.
+func Encode(msg []byte, opts ...EncodeOption) (tpdu.CBSDCS, []byte, error) {
+	e := encoder{}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	ge := gsm7.NewEncoder()
+	if s, err := ge.Encode(msg); err == nil {
+		dcs, prefix, err := cbs.LanguageCoding(e.lang, tpdu.Alpha7Bit)
+		if err != nil {
+			return 0, nil, ErrUnsupportedLanguage
+		}
+		s = append(prefix, s...)
+		if len(s) > MaxSeptets {
+			return 0, nil, ErrOverlength
+		}
+		return dcs, gsm7.Pack7BitUSSD(s, 0), nil
+	}
+	dcs, b, err := cbs.LanguageCoding(e.lang, tpdu.AlphaUCS2)
+	if err != nil {
+		return 0, nil, ErrUnsupportedLanguage
+	}
+	b = append(b, ucs2.Encode([]rune(string(msg)))...)
+	if len(b) > MaxOctets {
+		return 0, nil, ErrOverlength
+	}
+	return dcs, b, nil
+}
+
+// EncodeToString converts the UTF-8 message into a USSD string, encoded as
+// the hex string expected by a modem, such as in an AT+CUSD command.
+func EncodeToString(msg []byte, opts ...EncodeOption) (tpdu.CBSDCS, string, error) {
+	dcs, b, err := Encode(msg, opts...)
+	if err != nil {
+		return 0, "", err
+	}
+	return dcs, hex.EncodeToString(b), nil
+}
+
+// decoder contains the configuration for Decode.
+type decoder struct {
+	unpacked bool
+}
+
+// DecodeOption modifies the behaviour of Decode.
+type DecodeOption func(*decoder)
+
+// WithUnpacked indicates that a GSM7 string has already been unpacked, and
+// so contains one septet per octet.
+//
+// This is the form returned by some modems in +CUSD responses.
+func WithUnpacked() DecodeOption {
+	return func(d *decoder) {
+		d.unpacked = true
+	}
+}
+
+// Decode converts the USSD string, coded as per the DCS, into a Message.
+//
+// By default GSM7 strings are assumed to be packed.
+func Decode(dcs tpdu.CBSDCS, src []byte, opts ...DecodeOption) (*Message, error) {
+	d := decoder{}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	dc := dcs.DataCoding()
+	if dc.Compressed {
+		return nil, ErrCompressed
+	}
+	m := Message{DCS: dcs, Language: dc.Language}
+	prefixed := dc.Group == tpdu.GroupLanguagePrefixed
+	gd := gsm7.NewDecoder()
+	switch dc.Alphabet {
+	case tpdu.Alpha8Bit:
+		if len(src) > MaxOctets {
+			return nil, ErrOverlength
+		}
+		m.Text = append([]byte(nil), src...)
+	case tpdu.AlphaUCS2:
+		if len(src) > MaxOctets {
+			return nil, ErrOverlength
+		}
+		if prefixed {
+			l, n, err := cbs.LanguagePrefix(dc.Alphabet, src)
+			if err != nil {
+				return nil, prefixError(err)
+			}
+			m.Language = l
+			src = src[n:]
+		}
+		if len(src)&0x1 == 1 {
+			return nil, ErrInvalid
+		}
+		t, err := ucs2.Decode(src)
+		if err != nil {
+			return nil, err
+		}
+		m.Text = []byte(string(t))
+	default:
+		s := src
+		if d.unpacked {
+			if len(s) > MaxSeptets {
+				return nil, ErrOverlength
+			}
+			for _, b := range s {
+				if b > 0x7f {
+					return nil, ErrInvalid
+				}
+			}
+		} else {
+			if len(s) > MaxOctets {
+				return nil, ErrOverlength
+			}
+			s = gsm7.Unpack7BitUSSD(s, 0)
+		}
+		if prefixed {
+			l, n, err := cbs.LanguagePrefix(dc.Alphabet, s)
+			if err != nil {
+				return nil, prefixError(err)
+			}
+			m.Language = l
+			s = s[n:]
+		}
+		t, err := gd.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		m.Text = t
+	}
+	return &m, nil
+}
+
+// prefixError converts an error from decoding a language prefix into the
+// corresponding ussd error.
+func prefixError(err error) error {
+	if err == cbs.ErrUnderflow {
+		return ErrUnderflow
+	}
+	return ErrInvalid
+}
+
+// DecodeString converts the USSD string, coded as per the DCS and
+// provided as a hex string, such as in a +CUSD response, into a Message.
+func DecodeString(dcs tpdu.CBSDCS, s string, opts ...DecodeOption) (*Message, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(dcs, b, opts...)
+}
+
+var (
+	// ErrCompressed indicates the string is compressed, which is not
+	// supported.
+	ErrCompressed = errors.New("ussd: compressed strings not supported")
+
+	// ErrInvalid indicates a value is invalid.
+	ErrInvalid = errors.New("ussd: invalid")
+
+	// ErrOverlength indicates the string is too long.
+	ErrOverlength = errors.New("ussd: overlength")
+
+	// ErrUnderflow indicates the string is too short.
+	ErrUnderflow = errors.New("ussd: underflow")
+
+	// ErrUnsupportedLanguage indicates the language cannot be encoded.
+	ErrUnsupportedLanguage = errors.New("ussd: unsupported language")
+)
@@ -0,0 +1,135 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ussd_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/encoding/ussd"
+)
+
+func TestEncode(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		opts []ussd.EncodeOption
+		dcs  tpdu.CBSDCS
+		out  string
+		err  error
+	}{
+		{"empty", "", nil, 0x0f, "", nil},
+		{"balance", "*100#", nil, 0x0f, "aa180c3602", nil},
+		{"filler", "1234567", nil, 0x0f, "31d98c56b3dd1a", nil},
+		{"language", "*100#", []ussd.EncodeOption{ussd.WithLanguage("en")}, 0x01,
+			"aa180c3602", nil},
+		{"prefixed", "Hi", []ussd.EncodeOption{ussd.WithLanguage("ga")}, 0x10,
+			"e770039906", nil},
+		{"ucs2", "Привет", nil, 0x48, "041f04400438043204350442", nil},
+		{"ucs2 prefixed", "Привет", []ussd.EncodeOption{ussd.WithLanguage("ru")}, 0x11,
+			"f23a041f04400438043204350442", nil},
+		{"unsupported language", "Hi", []ussd.EncodeOption{ussd.WithLanguage("english")}, 0,
+			"", ussd.ErrUnsupportedLanguage},
+		{"gsm7 overlength", strings.Repeat("a", ussd.MaxSeptets+1), nil, 0, "",
+			ussd.ErrOverlength},
+		{"prefixed overlength", strings.Repeat("a", ussd.MaxSeptets-2),
+			[]ussd.EncodeOption{ussd.WithLanguage("ga")}, 0, "", ussd.ErrOverlength},
+		{"escape overlength", strings.Repeat("a", ussd.MaxSeptets-1) + "{", nil, 0, "",
+			ussd.ErrOverlength},
+		{"ucs2 overlength", strings.Repeat("П", ussd.MaxOctets/2+1), nil, 0, "",
+			ussd.ErrOverlength},
+		{"ucs2 prefixed overlength", strings.Repeat("П", ussd.MaxOctets/2),
+			[]ussd.EncodeOption{ussd.WithLanguage("ru")}, 0, "", ussd.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			dcs, s, err := ussd.EncodeToString([]byte(p.in), p.opts...)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.dcs, dcs)
+			assert.Equal(t, p.out, s)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEncodeMaxLength(t *testing.T) {
+	in := []byte(strings.Repeat("a", ussd.MaxSeptets))
+	dcs, b, err := ussd.Encode(in)
+	assert.Nil(t, err)
+	assert.Equal(t, tpdu.CBSDCS(0x0f), dcs)
+	assert.Equal(t, ussd.MaxOctets, len(b))
+	m, err := ussd.Decode(dcs, b)
+	assert.Nil(t, err)
+	assert.Equal(t, in, m.Text)
+
+	in = []byte(strings.Repeat("П", ussd.MaxOctets/2))
+	dcs, b, err = ussd.Encode(in)
+	assert.Nil(t, err)
+	assert.Equal(t, tpdu.CBSDCS(0x48), dcs)
+	assert.Equal(t, ussd.MaxOctets, len(b))
+	m, err = ussd.Decode(dcs, b)
+	assert.Nil(t, err)
+	assert.Equal(t, in, m.Text)
+}
+
+func TestDecode(t *testing.T) {
+	patterns := []struct {
+		name string
+		dcs  tpdu.CBSDCS
+		in   string
+		opts []ussd.DecodeOption
+		out  *ussd.Message
+		err  error
+	}{
+		{"empty", 0x0f, "", nil, &ussd.Message{DCS: 0x0f}, nil},
+		{"balance", 0x0f, "aa180c3602", nil,
+			&ussd.Message{DCS: 0x0f, Text: []byte("*100#")}, nil},
+		{"filler", 0x0f, "31d98c56b3dd1a", nil,
+			&ussd.Message{DCS: 0x0f, Text: []byte("1234567")}, nil},
+		{"language", 0x01, "aa180c3602", nil,
+			&ussd.Message{DCS: 0x01, Language: "en", Text: []byte("*100#")}, nil},
+		{"general", 0x40, "aa180c3602", nil,
+			&ussd.Message{DCS: 0x40, Text: []byte("*100#")}, nil},
+		{"unpacked", 0x0f, "2a31303023", []ussd.DecodeOption{ussd.WithUnpacked()},
+			&ussd.Message{DCS: 0x0f, Text: []byte("*100#")}, nil},
+		{"prefixed", 0x10, "e770039906", nil,
+			&ussd.Message{DCS: 0x10, Language: "ga", Text: []byte("Hi")}, nil},
+		{"prefixed unpacked", 0x10, "67610d4869", []ussd.DecodeOption{ussd.WithUnpacked()},
+			&ussd.Message{DCS: 0x10, Language: "ga", Text: []byte("Hi")}, nil},
+		{"ucs2", 0x48, "041f04400438043204350442", nil,
+			&ussd.Message{DCS: 0x48, Text: []byte("Привет")}, nil},
+		{"ucs2 prefixed", 0x11, "f23a041f04400438043204350442", nil,
+			&ussd.Message{DCS: 0x11, Language: "ru", Text: []byte("Привет")}, nil},
+		{"8bit", 0x44, "0102fe", nil,
+			&ussd.Message{DCS: 0x44, Text: []byte{0x01, 0x02, 0xfe}}, nil},
+		{"compressed", 0x60, "0102", nil, nil, ussd.ErrCompressed},
+		{"hex", 0x0f, "zz", nil, nil, hex.InvalidByteError(0x7a)},
+		{"prefix missing", 0x10, "4824", []ussd.DecodeOption{}, nil, ussd.ErrInvalid},
+		{"ucs2 odd", 0x48, "041f04", nil, nil, ussd.ErrInvalid},
+		{"ucs2 prefix underflow", 0x11, "f2", nil, nil, ussd.ErrUnderflow},
+		{"gsm7 overlength", 0x0f, strings.Repeat("00", ussd.MaxOctets+1), nil, nil,
+			ussd.ErrOverlength},
+		{"unpacked overlength", 0x0f, strings.Repeat("61", ussd.MaxSeptets+1),
+			[]ussd.DecodeOption{ussd.WithUnpacked()}, nil, ussd.ErrOverlength},
+		{"ucs2 overlength", 0x48, strings.Repeat("0041", ussd.MaxOctets/2+1), nil, nil,
+			ussd.ErrOverlength},
+		{"8bit overlength", 0x44, strings.Repeat("00", ussd.MaxOctets+1), nil, nil,
+			ussd.ErrOverlength},
+		{"invalid septet", 0x0f, "80", []ussd.DecodeOption{ussd.WithUnpacked()}, nil,
+			ussd.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			m, err := ussd.DecodeString(p.dcs, p.in, p.opts...)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, m)
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -7,5 +7,10 @@
 // The contained packages add layers of functionality above tpdu:
 // - sar provides segmentation and reassembly above tpdu
 // - message provides conversion to abstract messages above sar
+// - mwi provides message waiting indications above tpdu
+// - tracker provides delivery status tracking above tpdu
 // - pdumode provides stuff...
+// - sim provides encoding and decoding of SMS records stored on a SIM
+// - ota provides (U)SIM OTA secured packets carried over SMS-PP
+// - stk provides the SIM Toolkit envelope and proactive command carrying SMS TPDUs
 package ms
@@ -0,0 +1,182 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package message
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Handler processes a Message routed to it by a Dispatcher.
+type Handler func(m *Message) error
+
+// Matcher determines if a Message should be routed to a Handler.
+type Matcher func(m *Message) bool
+
+// Dispatcher routes reassembled Messages to Handlers.
+// Routes are checked in the order they were added, and the Message is passed
+// to the Handler of the first matching route.
+// If no route matches then the Message is passed to the default Handler.
+type Dispatcher struct {
+	mutex  sync.RWMutex // covers routes and def
+	routes []route
+	def    Handler
+}
+
+type route struct {
+	m Matcher
+	h Handler
+}
+
+// NewDispatcher creates a Dispatcher.
+// The default Handler is called for Messages that do not match any route,
+// and may be nil.
+func NewDispatcher(def Handler) *Dispatcher {
+	return &Dispatcher{def: def}
+}
+
+// Handle adds a route to the Dispatcher so that Messages that match m are
+// passed to h.
+func (d *Dispatcher) Handle(m Matcher, h Handler) {
+	d.mutex.Lock()
+	d.routes = append(d.routes, route{m, h})
+	d.mutex.Unlock()
+}
+
+// SetDefault sets the Handler called for Messages that do not match any route.
+func (d *Dispatcher) SetDefault(h Handler) {
+	d.mutex.Lock()
+	d.def = h
+	d.mutex.Unlock()
+}
+
+// Dispatch passes the Message to the Handler of the first matching route,
+// or the default Handler if no route matches.
+// The error returned by the Handler is returned.
+// If the Handler panics then the panic is recovered and returned as an
+// ErrHandlerPanic.
+// If no Handler is found then ErrNoHandler is returned.
+func (d *Dispatcher) Dispatch(m *Message) error {
+	d.mutex.RLock()
+	h := d.def
+	for _, r := range d.routes {
+		if r.m(m) {
+			h = r.h
+			break
+		}
+	}
+	d.mutex.RUnlock()
+	if h == nil {
+		return ErrNoHandler
+	}
+	return callHandler(h, m)
+}
+
+func callHandler(h Handler, m *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ErrHandlerPanic{m, r}
+		}
+	}()
+	return h(m)
+}
+
+// MatchPort matches Messages addressed to the destination application port.
+func MatchPort(port int) Matcher {
+	return func(m *Message) bool {
+		dst, _, ok := m.Ports()
+		return ok && dst == port
+	}
+}
+
+// MatchOriginator matches Messages with an originating number that matches
+// the pattern.
+// The pattern syntax is that of path.Match, e.g. "+61*" matches all
+// international numbers from Australia.
+// An error is returned if the pattern is malformed.
+func MatchOriginator(pattern string) (Matcher, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(m *Message) bool {
+		ok, _ := path.Match(pattern, m.Number)
+		return ok
+	}, nil
+}
+
+// MatchPID matches Messages with the TP-PID.
+func MatchPID(pid tpdu.PID) Matcher {
+	return func(m *Message) bool {
+		if len(m.TPDUs) == 0 {
+			return false
+		}
+		return m.PID() == pid
+	}
+}
+
+// MatchPIDClass matches Messages with a TP-PID of the class.
+func MatchPIDClass(c tpdu.PIDClass) Matcher {
+	return func(m *Message) bool {
+		if len(m.TPDUs) == 0 {
+			return false
+		}
+		return m.PID().Class() == c
+	}
+}
+
+// MatchClass matches Messages with the DCS message class.
+// MClassUnknown matches Messages with no message class.
+func MatchClass(c tpdu.MessageClass) Matcher {
+	return func(m *Message) bool {
+		if len(m.TPDUs) == 0 {
+			return false
+		}
+		mc, err := tpdu.DCS(m.TPDUs[0].DCS).Class()
+		return err == nil && mc == c
+	}
+}
+
+// MatchAlphabet matches Messages encoded with the DCS alphabet.
+func MatchAlphabet(a tpdu.Alphabet) Matcher {
+	return func(m *Message) bool {
+		if len(m.TPDUs) == 0 {
+			return false
+		}
+		ma, err := m.TPDUs[0].Alphabet()
+		return err == nil && ma == a
+	}
+}
+
+// MatchAll matches Messages that match all of the provided Matchers.
+func MatchAll(ms ...Matcher) Matcher {
+	return func(m *Message) bool {
+		for _, mf := range ms {
+			if !mf(m) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ErrHandlerPanic indicates that a Handler panicked while handling a Message.
+type ErrHandlerPanic struct {
+	M     *Message
+	Value interface{}
+}
+
+func (e ErrHandlerPanic) Error() string {
+	return fmt.Sprintf("message: handler panicked: %v", e.Value)
+}
+
+var (
+	// ErrNoHandler indicates that no Handler was found for a Message.
+	ErrNoHandler = errors.New("no handler")
+)
@@ -0,0 +1,100 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package message_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/message"
+)
+
+func newDispatchMessage(number string, pid, dcs byte, udh tpdu.UserDataHeader) *message.Message {
+	d := tpdu.NewDeliver()
+	d.PID = pid
+	d.DCS = dcs
+	d.SetUDH(udh)
+	return &message.Message{Number: number, TPDUs: []*tpdu.Deliver{d}}
+}
+
+func TestDispatch(t *testing.T) {
+	var handled string
+	handler := func(name string) message.Handler {
+		return func(m *message.Message) error {
+			handled = name
+			return nil
+		}
+	}
+	d := message.NewDispatcher(handler("default"))
+	d.Handle(message.MatchPort(2948), handler("wap"))
+	om, err := message.MatchOriginator("+61*")
+	assert.Nil(t, err)
+	d.Handle(message.MatchAll(om, message.MatchPID(0x41)), handler("au replace"))
+	d.Handle(om, handler("au"))
+	d.Handle(message.MatchPID(0x40), handler("type0"))
+	d.Handle(message.MatchPIDClass(tpdu.PIDClassSIMDataDownload), handler("sim"))
+	d.Handle(message.MatchClass(tpdu.MClass0), handler("flash"))
+	d.Handle(message.MatchAlphabet(tpdu.AlphaUCS2), handler("ucs2"))
+	wap := tpdu.UserDataHeader{tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}}}
+	patterns := []struct {
+		name string
+		in   *message.Message
+		out  string
+	}{
+		{"default", newDispatchMessage("+1234", 0, 0, nil), "default"},
+		{"empty", &message.Message{}, "default"},
+		{"port", newDispatchMessage("+61234", 0x40, 0x10, wap), "wap"},
+		{"originator", newDispatchMessage("+61234", 0x40, 0x10, nil), "au"},
+		{"all", newDispatchMessage("+61234", 0x41, 0x10, nil), "au replace"},
+		{"pid", newDispatchMessage("+1234", 0x40, 0x10, nil), "type0"},
+		{"pid class", newDispatchMessage("+1234", 0x7f, 0x16, nil), "sim"},
+		{"class", newDispatchMessage("+1234", 0, 0x10, nil), "flash"},
+		{"alphabet", newDispatchMessage("+1234", 0, 0x08, nil), "ucs2"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			handled = ""
+			err := d.Dispatch(p.in)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, handled)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	d := message.NewDispatcher(nil)
+	m := newDispatchMessage("+1234", 0, 0, nil)
+	err := d.Dispatch(m)
+	assert.Equal(t, message.ErrNoHandler, err)
+
+	herr := errors.New("handler failed")
+	d.SetDefault(func(m *message.Message) error {
+		return herr
+	})
+	err = d.Dispatch(m)
+	assert.Equal(t, herr, err)
+
+	d.Handle(message.MatchPID(0), func(m *message.Message) error {
+		panic("oops")
+	})
+	err = d.Dispatch(m)
+	assert.Equal(t, message.ErrHandlerPanic{M: m, Value: "oops"}, err)
+	assert.Equal(t, "message: handler panicked: oops", err.Error())
+}
+
+func TestMatchOriginator(t *testing.T) {
+	m, err := message.MatchOriginator("[")
+	assert.Equal(t, path.ErrBadPattern, err)
+	assert.Nil(t, m)
+	m, err = message.MatchOriginator("+6?23*")
+	assert.Nil(t, err)
+	assert.True(t, m(&message.Message{Number: "+61234"}))
+	assert.False(t, m(&message.Message{Number: "61234"}))
+}
@@ -16,9 +16,10 @@ import (
 type Encoder struct {
 	e        DataEncoder
 	s        Segmenter
-	mutex    sync.Mutex // covers msgCount and t
+	mutex    sync.Mutex // covers msgCount, t and po
 	msgCount int
 	t        *tpdu.Submit
+	po       []tpdu.ParseOption
 }
 
 // DataEncoder converts a UTF-8 message into the corresponding TPDU user data.
@@ -34,7 +35,7 @@ type Segmenter interface {
 
 // NewEncoder creates an Encoder.
 func NewEncoder(e DataEncoder, s Segmenter) *Encoder {
-	return &Encoder{e, s, sync.Mutex{}, 0, nil}
+	return &Encoder{e: e, s: s}
 }
 
 // SetT sets the template Submit TPDU used by Encode.
@@ -49,6 +50,15 @@ func (e *Encoder) SetT(t *tpdu.Submit) {
 	e.mutex.Unlock()
 }
 
+// SetParseOptions enables parsing of the destination number using
+// tpdu.ParseAddress, with the provided options, to determine the DA.
+// By default the DA is assumed to be an international number.
+func (e *Encoder) SetParseOptions(opts ...tpdu.ParseOption) {
+	e.mutex.Lock()
+	e.po = append([]tpdu.ParseOption{}, opts...)
+	e.mutex.Unlock()
+}
+
 // Encode builds a set of Submit TPDUs from the destination number and UTF8 message.
 // Long messages are split into multiple concatenated TPDUs, while short messages
 // may fit in one.
@@ -59,49 +69,87 @@ func (e *Encoder) Encode(number, msg string) ([]tpdu.Submit, error) {
 	}
 	s := tpdu.NewSubmit()
 	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	if e.t != nil {
 		*s = *e.t
 		s.SetUDH(append(e.t.UDH, udh...))
 	} else {
 		s.SetUDH(udh)
 	}
-	if len(number) > 0 && number[0] == '+' {
-		number = number[1:]
+	if s.DA, err = e.address(number); err != nil {
+		return nil, err
 	}
-	s.DA = tpdu.Address{TOA: 0x80 | byte(tpdu.TonInternational<<4) | byte(tpdu.NpISDN), Addr: number}
 	dcs, err := tpdu.DCS(s.DCS).WithAlphabet(alpha)
 	if err != nil {
 		// ignore the template dcs
 		dcs, _ = tpdu.DCS(0).WithAlphabet(alpha)
 	}
 	s.DCS = byte(dcs)
-	segments := e.segment(d, s)
-	e.mutex.Unlock()
-	return segments, nil
+	return e.segment(d, s), nil
 }
 
 // Encode8Bit builds a set of Submit TPDUs from the destination number and raw binary message.
 // Long messages are split into multiple concatenated TPDUs, while short messages
 // may fit in one.
 func (e *Encoder) Encode8Bit(number string, d []byte) ([]tpdu.Submit, error) {
+	return e.encode8Bit(number, d, nil)
+}
+
+// Encode8BitToPort8 builds a set of Submit TPDUs from the destination number
+// and raw binary message, addressed to an application using 8bit application
+// port addressing.
+// The port addressing IE is repeated in every TPDU.
+func (e *Encoder) Encode8BitToPort8(number string, d []byte, dst, src uint8) ([]tpdu.Submit, error) {
+	ie, _ := tpdu.NewInformationElement(&tpdu.Port8IE{Dst: dst, Src: src})
+	return e.encode8Bit(number, d, &ie)
+}
+
+// Encode8BitToPort16 builds a set of Submit TPDUs from the destination number
+// and raw binary message, addressed to an application using 16bit application
+// port addressing.
+// The port addressing IE is repeated in every TPDU.
+func (e *Encoder) Encode8BitToPort16(number string, d []byte, dst, src uint16) ([]tpdu.Submit, error) {
+	ie, _ := tpdu.NewInformationElement(&tpdu.Port16IE{Dst: dst, Src: src})
+	return e.encode8Bit(number, d, &ie)
+}
+
+func (e *Encoder) encode8Bit(number string, d []byte, port *tpdu.InformationElement) ([]tpdu.Submit, error) {
 	s := tpdu.NewSubmit()
 	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	if e.t != nil {
 		*s = *e.t
 	}
-	if len(number) > 0 && number[0] == '+' {
-		number = number[1:]
+	if port != nil {
+		udh := make(tpdu.UserDataHeader, 0, len(s.UDH)+1)
+		udh = append(udh, s.UDH...)
+		s.SetUDH(append(udh, *port))
+	}
+	var err error
+	if s.DA, err = e.address(number); err != nil {
+		return nil, err
 	}
-	s.DA = tpdu.Address{TOA: 0x80 | byte(tpdu.TonInternational<<4) | byte(tpdu.NpISDN), Addr: number}
 	dcs, err := tpdu.DCS(s.DCS).WithAlphabet(tpdu.Alpha8Bit)
 	if err != nil {
 		// ignore the template dcs
 		dcs, _ = tpdu.DCS(0).WithAlphabet(tpdu.Alpha8Bit)
 	}
 	s.DCS = byte(dcs)
-	segments := e.segment(d, s)
-	e.mutex.Unlock()
-	return segments, nil
+	return e.segment(d, s), nil
+}
+
+// address returns the DA for the destination number.
+// If parse options have been set then the number is parsed using
+// tpdu.ParseAddress, else it is assumed to be an international number.
+func (e *Encoder) address(number string) (tpdu.Address, error) {
+	if e.po != nil {
+		a, _, err := tpdu.ParseAddress(number, e.po...)
+		return a, err
+	}
+	if len(number) > 0 && number[0] == '+' {
+		number = number[1:]
+	}
+	return tpdu.Address{TOA: 0x80 | byte(tpdu.TonInternational<<4) | byte(tpdu.NpISDN), Addr: number}, nil
 }
 
 func (e *Encoder) segment(d []byte, s *tpdu.Submit) []tpdu.Submit {
@@ -277,3 +277,125 @@ func TestEncode8BitWithTemplate(t *testing.T) {
 		t.Run(p.name, f)
 	}
 }
+
+func TestEncode8BitToPort(t *testing.T) {
+	msg := "this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you might think"
+	port8 := tpdu.InformationElement{ID: 4, Data: []byte{0xf5, 0xf6}}
+	port16 := tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}}
+	tmplIE := tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}
+	patterns := []struct {
+		name string
+		wide bool
+		tmpl bool
+		msg  string
+		out  []encodeOutPattern
+	}{
+		{"port8", false, false, "hello",
+			[]encodeOutPattern{{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+				tpdu.UserDataHeader{port8}, []byte("hello")}}},
+		{"port16", true, false, "hello",
+			[]encodeOutPattern{{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+				tpdu.UserDataHeader{port16}, []byte("hello")}}},
+		{"port16 template", true, true, "hello",
+			[]encodeOutPattern{{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+				tpdu.UserDataHeader{tmplIE, port16}, []byte("hello")}}},
+		{"two segment port8", false, false, msg,
+			[]encodeOutPattern{
+				{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+					tpdu.UserDataHeader{port8, tpdu.InformationElement{ID: 0, Data: []byte{1, 2, 1}}},
+					[]byte(msg[:130])},
+				{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+					tpdu.UserDataHeader{port8, tpdu.InformationElement{ID: 0, Data: []byte{1, 2, 2}}},
+					[]byte(msg[130:])}}},
+		{"two segment port16 template", true, true, msg,
+			[]encodeOutPattern{
+				{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+					tpdu.UserDataHeader{tmplIE, port16, tpdu.InformationElement{ID: 0, Data: []byte{2, 2, 1}}},
+					[]byte(msg[:123])},
+				{tpdu.Address{Addr: "1234", TOA: 0x91}, 4,
+					tpdu.UserDataHeader{tmplIE, port16, tpdu.InformationElement{ID: 0, Data: []byte{2, 2, 2}}},
+					[]byte(msg[123:])}}},
+	}
+	ude, _ := tpdu.NewUDEncoder()
+	s := sar.NewSegmenter()
+	e := message.NewEncoder(ude, s)
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			fo := byte(1)
+			if p.tmpl {
+				tmpl := tpdu.NewSubmit()
+				tmpl.SetUDH(tpdu.UserDataHeader{tmplIE})
+				e.SetT(tmpl)
+				defer e.SetT(nil)
+			}
+			var out []tpdu.Submit
+			var err error
+			if p.wide {
+				out, err = e.Encode8BitToPort16("1234", []byte(p.msg), 2948, 9200)
+			} else {
+				out, err = e.Encode8BitToPort8("1234", []byte(p.msg), 0xf5, 0xf6)
+			}
+			assert.Nil(t, err)
+			expected := make([]tpdu.Submit, len(p.out))
+			for i, o := range p.out {
+				expected[i].FirstOctet = fo
+				expected[i].DA = o.da
+				expected[i].DCS = o.dcs
+				expected[i].SetUDH(o.udh)
+				expected[i].UD = o.ud
+			}
+			assert.Equal(t, expected, out)
+			for _, s := range out {
+				b, err := s.MarshalBinary()
+				assert.Nil(t, err)
+				// 2 octets for FirstOctet and MR, 4 for DA, 3 for PID, DCS and UDL
+				assert.True(t, len(b) <= s.MaxUDL()+9)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEncodeParseOptions(t *testing.T) {
+	home := tpdu.WithHomeCountry("61")
+	patterns := []struct {
+		name   string
+		opts   []tpdu.ParseOption
+		number string
+		da     tpdu.Address
+		err    error
+	}{
+		{"international", []tpdu.ParseOption{home}, "+61 409 865 629",
+			tpdu.Address{Addr: "61409865629", TOA: 0x91}, nil},
+		{"national", []tpdu.ParseOption{home}, "0409 865 629",
+			tpdu.Address{Addr: "409865629", TOA: 0xa1}, nil},
+		{"normalized", []tpdu.ParseOption{home, tpdu.WithNormalization()}, "0409 865 629",
+			tpdu.Address{Addr: "61409865629", TOA: 0x91}, nil},
+		{"short code", []tpdu.ParseOption{home}, "1234",
+			tpdu.Address{Addr: "1234", TOA: 0x81}, nil},
+		{"invalid", []tpdu.ParseOption{home}, "+999 1234 5678",
+			tpdu.Address{}, tpdu.ErrUnknownCountryCode},
+	}
+	ude, _ := tpdu.NewUDEncoder()
+	e := message.NewEncoder(ude, sar.NewSegmenter())
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			e.SetParseOptions(p.opts...)
+			out, err := e.Encode(p.number, "hello")
+			assert.Equal(t, p.err, err)
+			if p.err != nil {
+				assert.Nil(t, out)
+			} else if assert.Equal(t, 1, len(out)) {
+				assert.Equal(t, p.da, out[0].DA)
+			}
+			out, err = e.Encode8Bit(p.number, []byte("hello"))
+			assert.Equal(t, p.err, err)
+			if p.err != nil {
+				assert.Nil(t, out)
+			} else if assert.Equal(t, 1, len(out)) {
+				assert.Equal(t, p.da, out[0].DA)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -7,6 +7,7 @@ package message
 
 import (
 	"encoding/binary"
+	"errors"
 
 	"github.com/warthog618/sms/encoding/tpdu"
 	"github.com/warthog618/sms/encoding/ucs2"
@@ -21,6 +22,34 @@ type Message struct {
 	TPDUs  []*tpdu.Deliver
 }
 
+// Ports returns the application ports the message was addressed to, as
+// determined from the application port addressing IE in the UDH.
+// If the message is not port addressed then ok is false and zero values are
+// returned.
+func (m *Message) Ports() (dst, src int, ok bool) {
+	if len(m.TPDUs) == 0 {
+		return
+	}
+	return m.TPDUs[0].UDH.PortInfo()
+}
+
+// PID returns the TP-PID of the message.
+func (m *Message) PID() tpdu.PID {
+	if len(m.TPDUs) == 0 {
+		return tpdu.PIDDefault
+	}
+	return tpdu.PID(m.TPDUs[0].PID)
+}
+
+// Covert returns true if the message is one that the MS handles without
+// presenting it to the user, such as a Short Message Type 0 or (U)SIM Data
+// download, as determined by the TP-PID.
+// Such messages may warrant flagging as they can be used to covertly probe
+// or reconfigure the MS.
+func (m *Message) Covert() bool {
+	return m.PID().Covert()
+}
+
 // Reassembler is responsible for collecting TPDUs and building Messages from
 // them using the DataDecoder (typically a tpdu.UDDecoder).
 type Reassembler struct {
@@ -82,7 +111,11 @@ func NewConcatenator(d DataDecoder) *Concatenator {
 
 // Concatenate converts a set of concatenated TPDUs into a Message.
 // The User Data in each TPDU is converted to UTF-8 using the DataDecoder.
+// Compressed User Data is not supported, and returns ErrCompressed.
 func (c *Concatenator) Concatenate(segments []*tpdu.Deliver) (*Message, error) {
+	if tpdu.DCS(segments[0].DCS).Compressed() {
+		return nil, ErrCompressed
+	}
 	bl := 0
 	ts := make([][]byte, len(segments))
 	var danglingSurrogate tpdu.UserData
@@ -112,3 +145,9 @@ func (c *Concatenator) Concatenate(segments []*tpdu.Deliver) (*Message, error) {
 	}
 	return &Message{string(m), segments[0].OA.Number(), segments}, nil
 }
+
+var (
+	// ErrCompressed indicates the user data is compressed, which is not
+	// supported.
+	ErrCompressed = errors.New("compressed user data not supported")
+)
@@ -113,6 +113,25 @@ func TestConcatenate(t *testing.T) {
 	assert.Equal(t, expected, m)
 }
 
+func TestMessagePorts(t *testing.T) {
+	m := message.Message{}
+	dst, src, ok := m.Ports()
+	assert.False(t, ok)
+	assert.Equal(t, 0, dst)
+	assert.Equal(t, 0, src)
+	d := tpdu.NewDeliver()
+	m.TPDUs = []*tpdu.Deliver{d}
+	_, _, ok = m.Ports()
+	assert.False(t, ok)
+	d.SetUDH(tpdu.UserDataHeader{
+		tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}},
+		tpdu.InformationElement{ID: 0, Data: []byte{3, 2, 1}}})
+	dst, src, ok = m.Ports()
+	assert.True(t, ok)
+	assert.Equal(t, 2948, dst)
+	assert.Equal(t, 9200, src)
+}
+
 type MockCollector struct {
 	CloseFunc   func()
 	CollectFunc func(pdu *tpdu.Deliver) (d []*tpdu.Deliver, err error)
@@ -133,3 +152,30 @@ type MockDecoder struct {
 func (d *MockDecoder) Decode(ud tpdu.UserData, udh tpdu.UserDataHeader, alpha tpdu.Alphabet) ([]byte, error) {
 	return d.DecodeFunc(ud, udh, alpha)
 }
+
+func TestConcatenateCompressed(t *testing.T) {
+	u := tpdu.NewDeliver()
+	u.DCS = 0x20
+	u.OA = tpdu.Address{TOA: 0x91, Addr: "1234"}
+	u.UD = []byte{0x40, 0x01, 0x02}
+	d, _ := tpdu.NewUDDecoder()
+	c := message.NewConcatenator(d)
+	m, err := c.Concatenate([]*tpdu.Deliver{u})
+	assert.Equal(t, message.ErrCompressed, err)
+	assert.Nil(t, m)
+}
+
+func TestMessagePID(t *testing.T) {
+	m := message.Message{}
+	assert.Equal(t, tpdu.PIDDefault, m.PID())
+	assert.False(t, m.Covert())
+	d := tpdu.NewDeliver()
+	d.PID = byte(tpdu.PIDType0)
+	m.TPDUs = []*tpdu.Deliver{d}
+	assert.Equal(t, tpdu.PIDType0, m.PID())
+	assert.True(t, m.Covert())
+	d.PID = byte(tpdu.PIDSIMDataDownload)
+	assert.True(t, m.Covert())
+	d.PID = byte(tpdu.PIDReturnCall)
+	assert.False(t, m.Covert())
+}
@@ -0,0 +1,88 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package message
+
+import (
+	"sync"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Store holds received Messages and applies the Replace Short Message
+// semantics defined in 3GPP TS 23.040 Section 9.2.3.9.
+//
+// A Message with a Replace Short Message Type PID replaces any stored
+// Message with the same PID and originating address.
+// As Messages are reassembled before being added, a concatenated Message
+// replaces, and is replaced by, other Messages as a whole.
+//
+// Note that the SC address is not available in the Message and so, unlike
+// the MS behaviour described in TS 23.040, is not considered when matching.
+type Store struct {
+	mutex sync.Mutex // covers msgs
+	msgs  []*Message
+}
+
+// NewStore creates a Store.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Add adds the Message to the Store.
+// If the Message is a Replace Short Message and replaces a stored Message
+// then the replaced Message is returned, else nil.
+func (s *Store) Add(m *Message) (replaced *Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if i, ok := s.match(m); ok {
+		replaced = s.msgs[i]
+		s.msgs[i] = m
+		return
+	}
+	s.msgs = append(s.msgs, m)
+	return
+}
+
+// Messages returns the Messages in the Store, in the order they were added.
+// Replacement Messages retain the position of the Message they replaced.
+func (s *Store) Messages() []*Message {
+	s.mutex.Lock()
+	msgs := make([]*Message, len(s.msgs))
+	copy(msgs, s.msgs)
+	s.mutex.Unlock()
+	return msgs
+}
+
+// Remove removes the Message from the Store.
+// Returns false if the Message was not in the Store.
+func (s *Store) Remove(m *Message) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, sm := range s.msgs {
+		if sm == m {
+			s.msgs = append(s.msgs[:i], s.msgs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the index of the stored Message replaced by m.
+func (s *Store) match(m *Message) (int, bool) {
+	if len(m.TPDUs) == 0 || m.PID().Class() != tpdu.PIDClassReplace {
+		return 0, false
+	}
+	oa := m.TPDUs[0].OA
+	for i, sm := range s.msgs {
+		if len(sm.TPDUs) == 0 {
+			continue
+		}
+		if sm.PID() == m.PID() && sm.TPDUs[0].OA == oa {
+			return i, true
+		}
+	}
+	return 0, false
+}
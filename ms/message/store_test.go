@@ -0,0 +1,65 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package message_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/message"
+)
+
+func newStoreMessage(msg, oa string, pid tpdu.PID, segments int) *message.Message {
+	m := &message.Message{Msg: msg, Number: oa}
+	for i := 0; i < segments; i++ {
+		d := tpdu.NewDeliver()
+		d.PID = byte(pid)
+		d.OA = tpdu.Address{TOA: 0x91, Addr: oa}
+		m.TPDUs = append(m.TPDUs, d)
+	}
+	return m
+}
+
+func TestStore(t *testing.T) {
+	s := message.NewStore()
+	assert.Empty(t, s.Messages())
+
+	plain := newStoreMessage("plain", "1234", tpdu.PIDDefault, 1)
+	assert.Nil(t, s.Add(plain))
+	r1 := newStoreMessage("replace 1", "1234", tpdu.PIDReplace1, 1)
+	assert.Nil(t, s.Add(r1))
+	r1other := newStoreMessage("replace 1 other", "5678", tpdu.PIDReplace1, 1)
+	assert.Nil(t, s.Add(r1other))
+	r2 := newStoreMessage("replace 2", "1234", tpdu.PIDReplace1+1, 2)
+	assert.Nil(t, s.Add(r2))
+	empty := &message.Message{}
+	assert.Nil(t, s.Add(empty))
+	assert.Equal(t, []*message.Message{plain, r1, r1other, r2, empty}, s.Messages())
+
+	// replace single with concatenated
+	r1b := newStoreMessage("replace 1 again", "1234", tpdu.PIDReplace1, 3)
+	assert.Equal(t, r1, s.Add(r1b))
+	assert.Equal(t, []*message.Message{plain, r1b, r1other, r2, empty}, s.Messages())
+
+	// replace concatenated with single
+	r2b := newStoreMessage("replace 2 again", "1234", tpdu.PIDReplace1+1, 1)
+	assert.Equal(t, r2, s.Add(r2b))
+	assert.Equal(t, []*message.Message{plain, r1b, r1other, r2b, empty}, s.Messages())
+
+	// plain messages are never replaced
+	plainb := newStoreMessage("plain again", "1234", tpdu.PIDDefault, 1)
+	assert.Nil(t, s.Add(plainb))
+	assert.Equal(t, []*message.Message{plain, r1b, r1other, r2b, empty, plainb}, s.Messages())
+
+	assert.True(t, s.Remove(r1b))
+	assert.False(t, s.Remove(r1))
+	assert.Equal(t, []*message.Message{plain, r1other, r2b, empty, plainb}, s.Messages())
+
+	// no longer anything to replace
+	assert.Nil(t, s.Add(r1))
+	assert.Equal(t, []*message.Message{plain, r1other, r2b, empty, plainb, r1}, s.Messages())
+}
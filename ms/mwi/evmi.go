@@ -0,0 +1,282 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mwi
+
+import (
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// EnhancedVoiceMailIE is the Enhanced Voice Mail Information IE, as defined
+// in 3GPP TS 23.040 Section 9.2.3.24.13.
+//
+// The IE is either an Enhanced Voice Mail Notification, which lists new
+// voice messages, or an Enhanced Voice Delete Confirmation, which lists
+// voice messages that have been deleted, as determined by
+// DeleteConfirmation.
+type EnhancedVoiceMailIE struct {
+	// DeleteConfirmation indicates the IE is a delete confirmation rather
+	// than a notification.
+	DeleteConfirmation bool
+
+	// ProfileID identifies the multiple subscriber profile (0-3).
+	ProfileID int
+
+	// Store indicates the message should be stored rather than discarded
+	// after the indication has been updated.
+	Store bool
+
+	// AlmostFull indicates the voice mailbox is almost full.
+	// Only relevant to notifications.
+	AlmostFull bool
+
+	// Full indicates the voice mailbox is full.
+	// Only relevant to notifications.
+	Full bool
+
+	// AccessAddress is the address used to access the voice mailbox.
+	AccessAddress tpdu.Address
+
+	// Messages is the number of unread voice messages in the mailbox.
+	Messages int
+
+	// StatusExtension contains the optional mailbox status extension.
+	StatusExtension []byte
+
+	// Notifications lists the new voice messages.
+	// Only relevant to notifications.
+	Notifications []VoiceMessage
+
+	// Deletes lists the deleted voice messages.
+	// Only relevant to delete confirmations.
+	Deletes []VoiceMessageDelete
+}
+
+// VoiceMessage describes a voice message in an Enhanced Voice Mail
+// Notification.
+type VoiceMessage struct {
+	// ID identifies the message within the mailbox.
+	ID uint16
+
+	// Length is the length of the message, in seconds.
+	Length int
+
+	// RetentionDays is the number of days the message will be retained
+	// (0-31).
+	RetentionDays int
+
+	// Priority indicates the message is urgent.
+	Priority bool
+
+	// CallingLineIdentity is the address of the party that left the message.
+	CallingLineIdentity tpdu.Address
+
+	// Extension contains the optional message extension.
+	Extension []byte
+}
+
+// VoiceMessageDelete identifies a voice message in an Enhanced Voice Delete
+// Confirmation.
+type VoiceMessageDelete struct {
+	// ID identifies the message within the mailbox.
+	ID uint16
+
+	// Extension contains the optional message extension.
+	Extension []byte
+}
+
+// IEI returns tpdu.IEIEnhancedVoiceMailInformation.
+func (e EnhancedVoiceMailIE) IEI() byte {
+	return tpdu.IEIEnhancedVoiceMailInformation
+}
+
+// MarshalIED marshals the IE into binary.
+func (e EnhancedVoiceMailIE) MarshalIED() ([]byte, error) {
+	if e.ProfileID < 0 || e.ProfileID > 3 ||
+		e.Messages < 0 || e.Messages > 0xff {
+		return nil, tpdu.ErrInvalid
+	}
+	n := len(e.Notifications)
+	if e.DeleteConfirmation {
+		n = len(e.Deletes)
+	}
+	if n > 0x1f {
+		return nil, tpdu.ErrOverlength
+	}
+	o1 := byte(e.ProfileID << 2)
+	if e.DeleteConfirmation {
+		o1 |= 0x01
+	}
+	if e.Store {
+		o1 |= 0x10
+	}
+	if !e.DeleteConfirmation {
+		if e.AlmostFull {
+			o1 |= 0x20
+		}
+		if e.Full {
+			o1 |= 0x40
+		}
+	}
+	if len(e.StatusExtension) > 0 {
+		o1 |= 0x80
+	}
+	addr, err := e.AccessAddress.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	dst := []byte{o1}
+	dst = append(dst, addr...)
+	dst = append(dst, byte(e.Messages), byte(n))
+	if dst, err = appendExtension(dst, e.StatusExtension); err != nil {
+		return nil, err
+	}
+	if e.DeleteConfirmation {
+		for _, d := range e.Deletes {
+			var ext byte
+			if len(d.Extension) > 0 {
+				ext = 0x80
+			}
+			dst = append(dst, byte(d.ID>>8), byte(d.ID), ext)
+			if dst, err = appendExtension(dst, d.Extension); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, m := range e.Notifications {
+			if m.Length < 0 || m.Length > 0xff ||
+				m.RetentionDays < 0 || m.RetentionDays > 0x1f {
+				return nil, tpdu.ErrInvalid
+			}
+			o := byte(m.RetentionDays)
+			if m.Priority {
+				o |= 0x40
+			}
+			if len(m.Extension) > 0 {
+				o |= 0x80
+			}
+			dst = append(dst, byte(m.ID>>8), byte(m.ID), byte(m.Length), o)
+			cli, err := m.CallingLineIdentity.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, cli...)
+			if dst, err = appendExtension(dst, m.Extension); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(dst) > 0xff {
+		return nil, tpdu.ErrOverlength
+	}
+	return dst, nil
+}
+
+// UnmarshalIED unmarshals the IE from binary.
+func (e *EnhancedVoiceMailIE) UnmarshalIED(src []byte) error {
+	if len(src) < 1 {
+		return tpdu.ErrUnderflow
+	}
+	v := EnhancedVoiceMailIE{}
+	o1 := src[0]
+	v.DeleteConfirmation = o1&0x01 != 0
+	v.ProfileID = int(o1>>2) & 0x03
+	v.Store = o1&0x10 != 0
+	if !v.DeleteConfirmation {
+		v.AlmostFull = o1&0x20 != 0
+		v.Full = o1&0x40 != 0
+	}
+	ri := 1
+	n, err := v.AccessAddress.UnmarshalBinary(src[ri:])
+	if err != nil {
+		return err
+	}
+	ri += n
+	if len(src) < ri+2 {
+		return tpdu.ErrUnderflow
+	}
+	v.Messages = int(src[ri])
+	count := int(src[ri+1] & 0x1f)
+	ri += 2
+	if o1&0x80 != 0 {
+		if v.StatusExtension, n, err = unmarshalExtension(src[ri:]); err != nil {
+			return err
+		}
+		ri += n
+	}
+	for i := 0; i < count; i++ {
+		if v.DeleteConfirmation {
+			if len(src) < ri+3 {
+				return tpdu.ErrUnderflow
+			}
+			d := VoiceMessageDelete{ID: uint16(src[ri])<<8 | uint16(src[ri+1])}
+			ext := src[ri+2]&0x80 != 0
+			ri += 3
+			if ext {
+				if d.Extension, n, err = unmarshalExtension(src[ri:]); err != nil {
+					return err
+				}
+				ri += n
+			}
+			v.Deletes = append(v.Deletes, d)
+			continue
+		}
+		if len(src) < ri+4 {
+			return tpdu.ErrUnderflow
+		}
+		m := VoiceMessage{
+			ID:            uint16(src[ri])<<8 | uint16(src[ri+1]),
+			Length:        int(src[ri+2]),
+			RetentionDays: int(src[ri+3] & 0x1f),
+			Priority:      src[ri+3]&0x40 != 0,
+		}
+		ext := src[ri+3]&0x80 != 0
+		ri += 4
+		if n, err = m.CallingLineIdentity.UnmarshalBinary(src[ri:]); err != nil {
+			return err
+		}
+		ri += n
+		if ext {
+			if m.Extension, n, err = unmarshalExtension(src[ri:]); err != nil {
+				return err
+			}
+			ri += n
+		}
+		v.Notifications = append(v.Notifications, m)
+	}
+	if ri != len(src) {
+		return tpdu.ErrOverlength
+	}
+	*e = v
+	return nil
+}
+
+// appendExtension appends the length prefixed extension to dst, if the
+// extension is not empty.
+func appendExtension(dst, ext []byte) ([]byte, error) {
+	if len(ext) == 0 {
+		return dst, nil
+	}
+	if len(ext) > 0xff {
+		return nil, tpdu.ErrOverlength
+	}
+	dst = append(dst, byte(len(ext)))
+	return append(dst, ext...), nil
+}
+
+// unmarshalExtension returns a copy of the length prefixed extension at the
+// start of src, and the number of octets read.
+func unmarshalExtension(src []byte) ([]byte, int, error) {
+	if len(src) < 1 {
+		return nil, 0, tpdu.ErrUnderflow
+	}
+	l := int(src[0])
+	if len(src) < l+1 {
+		return nil, 0, tpdu.ErrUnderflow
+	}
+	ext := make([]byte, l)
+	copy(ext, src[1:l+1])
+	return ext, l + 1, nil
+}
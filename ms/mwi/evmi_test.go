@@ -0,0 +1,168 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mwi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/mwi"
+)
+
+func TestEnhancedVoiceMailIE(t *testing.T) {
+	patterns := []struct {
+		name string
+		ie   mwi.EnhancedVoiceMailIE
+		b    []byte
+	}{
+		{"empty notification",
+			mwi.EnhancedVoiceMailIE{AccessAddress: tpdu.Address{TOA: 0x91}},
+			[]byte{0x00, 0x00, 0x91, 0x00, 0x00}},
+		{"notification",
+			mwi.EnhancedVoiceMailIE{
+				ProfileID:     2,
+				Store:         true,
+				AlmostFull:    true,
+				AccessAddress: tpdu.Address{TOA: 0x91, Addr: "1234"},
+				Messages:      3,
+				Notifications: []mwi.VoiceMessage{
+					{
+						ID:                  0x1234,
+						Length:              45,
+						RetentionDays:       7,
+						Priority:            true,
+						CallingLineIdentity: tpdu.Address{TOA: 0x81, Addr: "567"},
+					},
+					{
+						ID:                  0x0001,
+						Length:              255,
+						RetentionDays:       31,
+						CallingLineIdentity: tpdu.Address{TOA: 0x81, Addr: "89"},
+						Extension:           []byte{0xaa, 0xbb},
+					},
+				},
+			},
+			[]byte{0x38, 0x04, 0x91, 0x21, 0x43, 0x03, 0x02,
+				0x12, 0x34, 0x2d, 0x47, 0x03, 0x81, 0x65, 0xf7,
+				0x00, 0x01, 0xff, 0x9f, 0x02, 0x81, 0x98, 0x02, 0xaa, 0xbb}},
+		{"full with status extension",
+			mwi.EnhancedVoiceMailIE{
+				Full:            true,
+				AccessAddress:   tpdu.Address{TOA: 0x91, Addr: "1234"},
+				Messages:        255,
+				StatusExtension: []byte{0x01},
+			},
+			[]byte{0xc0, 0x04, 0x91, 0x21, 0x43, 0xff, 0x00, 0x01, 0x01}},
+		{"delete confirmation",
+			mwi.EnhancedVoiceMailIE{
+				DeleteConfirmation: true,
+				ProfileID:          1,
+				AccessAddress:      tpdu.Address{TOA: 0x91, Addr: "1234"},
+				Messages:           1,
+				Deletes: []mwi.VoiceMessageDelete{
+					{ID: 0x1234},
+					{ID: 0x5678, Extension: []byte{0xcc}},
+				},
+			},
+			[]byte{0x05, 0x04, 0x91, 0x21, 0x43, 0x01, 0x02,
+				0x12, 0x34, 0x00, 0x56, 0x78, 0x80, 0x01, 0xcc}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, tpdu.IEIEnhancedVoiceMailInformation, p.ie.IEI())
+			b, err := p.ie.MarshalIED()
+			assert.Nil(t, err)
+			assert.Equal(t, p.b, b)
+			ie := mwi.EnhancedVoiceMailIE{}
+			err = ie.UnmarshalIED(p.b)
+			assert.Nil(t, err)
+			assert.Equal(t, p.ie, ie)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEnhancedVoiceMailIEMarshalErrors(t *testing.T) {
+	addr := tpdu.Address{TOA: 0x91, Addr: "1234"}
+	patterns := []struct {
+		name string
+		ie   mwi.EnhancedVoiceMailIE
+		err  error
+	}{
+		{"profile", mwi.EnhancedVoiceMailIE{ProfileID: 4, AccessAddress: addr}, tpdu.ErrInvalid},
+		{"messages", mwi.EnhancedVoiceMailIE{Messages: 256, AccessAddress: addr}, tpdu.ErrInvalid},
+		{"notifications",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr, Notifications: make([]mwi.VoiceMessage, 32)},
+			tpdu.ErrOverlength},
+		{"deletes",
+			mwi.EnhancedVoiceMailIE{DeleteConfirmation: true, AccessAddress: addr,
+				Deletes: make([]mwi.VoiceMessageDelete, 32)},
+			tpdu.ErrOverlength},
+		{"address",
+			mwi.EnhancedVoiceMailIE{AccessAddress: tpdu.Address{TOA: 0xd0, Addr: "😀"}},
+			tpdu.EncodeError("addr", gsm7.ErrInvalidUTF8('😀'))},
+		{"cli",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr,
+				Notifications: []mwi.VoiceMessage{
+					{CallingLineIdentity: tpdu.Address{TOA: 0xd0, Addr: "😀"}}}},
+			tpdu.EncodeError("addr", gsm7.ErrInvalidUTF8('😀'))},
+		{"length",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr,
+				Notifications: []mwi.VoiceMessage{{Length: 256}}},
+			tpdu.ErrInvalid},
+		{"retention",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr,
+				Notifications: []mwi.VoiceMessage{{RetentionDays: 32}}},
+			tpdu.ErrInvalid},
+		{"extension",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr, StatusExtension: make([]byte, 256)},
+			tpdu.ErrOverlength},
+		{"overlength",
+			mwi.EnhancedVoiceMailIE{AccessAddress: addr,
+				Notifications: []mwi.VoiceMessage{
+					{Extension: make([]byte, 200)},
+					{Extension: make([]byte, 200)}}},
+			tpdu.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.ie.MarshalIED()
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestEnhancedVoiceMailIEUnmarshalErrors(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		err  error
+	}{
+		{"empty", []byte{}, tpdu.ErrUnderflow},
+		{"address", []byte{0x00, 0x04}, tpdu.DecodeError("addr", 0, tpdu.ErrUnderflow)},
+		{"counts", []byte{0x00, 0x00, 0x91, 0x00}, tpdu.ErrUnderflow},
+		{"status extension", []byte{0x80, 0x00, 0x91, 0x00, 0x00, 0x02, 0x01}, tpdu.ErrUnderflow},
+		{"notification", []byte{0x00, 0x00, 0x91, 0x00, 0x01, 0x00, 0x01, 0x02}, tpdu.ErrUnderflow},
+		{"cli", []byte{0x00, 0x00, 0x91, 0x00, 0x01, 0x00, 0x01, 0x02, 0x03, 0x02, 0x81}, tpdu.DecodeError("addr", 2, tpdu.ErrUnderflow)},
+		{"message extension", []byte{0x00, 0x00, 0x91, 0x00, 0x01, 0x00, 0x01, 0x02, 0x83, 0x00, 0x81}, tpdu.ErrUnderflow},
+		{"delete", []byte{0x01, 0x00, 0x91, 0x00, 0x01, 0x00, 0x01}, tpdu.ErrUnderflow},
+		{"delete extension", []byte{0x01, 0x00, 0x91, 0x00, 0x01, 0x00, 0x01, 0x80, 0x01}, tpdu.ErrUnderflow},
+		{"overlength", []byte{0x00, 0x00, 0x91, 0x00, 0x00, 0x00}, tpdu.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			ie := mwi.EnhancedVoiceMailIE{}
+			err := ie.UnmarshalIED(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, mwi.EnhancedVoiceMailIE{}, ie)
+		}
+		t.Run(p.name, f)
+	}
+}
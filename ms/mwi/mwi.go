@@ -0,0 +1,203 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package mwi provides encoding and decoding of message waiting indications
+// carried in SMS Deliver TPDUs.
+//
+// Indications may be carried in the DCS message waiting groups, as defined
+// in 3GPP TS 23.038 Section 4, in Special SMS Message Indication IEs, and in
+// the Enhanced Voice Mail Information IE, as defined in 3GPP TS 23.040
+// Section 9.2.3.24.
+package mwi
+
+import (
+	"errors"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Type is the type of message waiting.
+type Type int
+
+const (
+	// Voice indicates voice messages waiting.
+	Voice Type = iota
+	// Fax indicates fax messages waiting.
+	Fax
+	// Email indicates electronic mail messages waiting.
+	Email
+	// Other indicates other messages waiting.
+	Other
+	// Video indicates video messages waiting.
+	Video
+)
+
+// types is the order indications are encoded.
+var types = []Type{Voice, Fax, Email, Other, Video}
+
+// Indication is the state of a message waiting indication.
+type Indication struct {
+	// Active indicates messages are waiting.
+	Active bool
+
+	// Count is the number of messages waiting, if known, else 0.
+	// Counts greater than 255 are encoded as 255.
+	// When encoding, an Active Indication with a zero Count is encoded with
+	// a count of 1.
+	Count int
+}
+
+// WaitingStatus is the message waiting status indicated by a Deliver TPDU.
+type WaitingStatus struct {
+	// Indications contains the indications being updated, keyed by Type.
+	// Types that are not present are left unchanged.
+	Indications map[Type]Indication
+
+	// Store indicates the message should be stored after the indications
+	// have been updated, rather than being discarded.
+	Store bool
+
+	// ProfileID identifies the multiple subscriber profile (0-3).
+	ProfileID int
+
+	// VoiceMail contains the Enhanced Voice Mail Information, if any.
+	// If present, the Voice Indication reflects the number of voice
+	// messages in the mailbox.
+	VoiceMail *EnhancedVoiceMailIE
+}
+
+// Parse returns the WaitingStatus indicated by the Deliver TPDU.
+// If the Deliver does not contain any message waiting indications then
+// ErrNoIndication is returned.
+func Parse(d *tpdu.Deliver) (WaitingStatus, error) {
+	ws := WaitingStatus{Indications: make(map[Type]Indication)}
+	dc := tpdu.DCS(d.DCS).DataCoding()
+	switch dc.Group {
+	case tpdu.GroupMWIStore:
+		ws.Store = true
+		fallthrough
+	case tpdu.GroupMWIDiscard:
+		ws.Indications[Type(dc.MWI)] = Indication{Active: dc.MWIActive}
+	}
+	var evmi *EnhancedVoiceMailIE
+	for i, ie := range d.UDH {
+		switch ie.ID {
+		case tpdu.IEISpecialSMSIndication:
+			s := tpdu.SpecialSMSIndicationIE{}
+			if err := s.UnmarshalIED(ie.Data); err != nil {
+				return ws, tpdu.DecodeError("ie", i, err)
+			}
+			t := Type(s.Type)
+			switch s.ExtendedType {
+			case 0:
+			case 1:
+				t = Video
+			default:
+				// unknown extended type
+				continue
+			}
+			ws.Indications[t] = Indication{Active: s.Count > 0, Count: s.Count}
+			ws.Store = ws.Store || s.Store
+			ws.ProfileID = s.ProfileID
+		case tpdu.IEIEnhancedVoiceMailInformation:
+			evmi = &EnhancedVoiceMailIE{}
+			if err := evmi.UnmarshalIED(ie.Data); err != nil {
+				return ws, tpdu.DecodeError("ie", i, err)
+			}
+		}
+	}
+	if evmi != nil {
+		ws.VoiceMail = evmi
+		ws.Indications[Voice] = Indication{Active: evmi.Messages > 0, Count: evmi.Messages}
+		ws.Store = ws.Store || evmi.Store
+		ws.ProfileID = evmi.ProfileID
+	}
+	if len(ws.Indications) == 0 {
+		return ws, ErrNoIndication
+	}
+	return ws, nil
+}
+
+// NewDeliver creates a Deliver TPDU that sets or clears the indications in
+// the WaitingStatus.
+//
+// The first of the voice, fax, email or other indications is encoded in the
+// DCS message waiting group, using the GSM 7 bit alphabet, and all the
+// indications are encoded in Special SMS Message Indication IEs, so the
+// counts are available to the recipient.  If VoiceMail is present then it
+// is encoded in place of the Special SMS Message Indication IE for voice.
+//
+// The OA, SCTS and UD should be set by the caller.
+func NewDeliver(ws WaitingStatus) (*tpdu.Deliver, error) {
+	d := tpdu.NewDeliver()
+	udh := tpdu.UserDataHeader{}
+	dcsSet := false
+	for _, t := range types {
+		ind, ok := ws.Indications[t]
+		if !ok {
+			continue
+		}
+		if !dcsSet && t != Video {
+			dc := tpdu.DataCoding{
+				Group:     tpdu.GroupMWIDiscard,
+				Class:     tpdu.MClassUnknown,
+				MWIActive: ind.Active,
+				MWI:       tpdu.MWIType(t),
+			}
+			if ws.Store {
+				dc.Group = tpdu.GroupMWIStore
+			}
+			dcs, err := dc.DCS()
+			if err != nil {
+				return nil, tpdu.EncodeError("dcs", err)
+			}
+			d.DCS = byte(dcs)
+			dcsSet = true
+		}
+		if t == Voice && ws.VoiceMail != nil {
+			continue
+		}
+		s := tpdu.SpecialSMSIndicationIE{
+			Store:     ws.Store,
+			ProfileID: ws.ProfileID,
+			Type:      int(t),
+		}
+		if t == Video {
+			s.Type = int(Other)
+			s.ExtendedType = 1
+		}
+		if ind.Active {
+			s.Count = ind.Count
+			if s.Count < 1 {
+				s.Count = 1
+			}
+			if s.Count > 0xff {
+				s.Count = 0xff
+			}
+		}
+		ie, err := tpdu.NewInformationElement(&s)
+		if err != nil {
+			return nil, tpdu.EncodeError("ssi", err)
+		}
+		udh = append(udh, ie)
+	}
+	if ws.VoiceMail != nil {
+		ie, err := tpdu.NewInformationElement(ws.VoiceMail)
+		if err != nil {
+			return nil, tpdu.EncodeError("evmi", err)
+		}
+		udh = append(udh, ie)
+	}
+	if len(udh) == 0 {
+		return nil, ErrNoIndication
+	}
+	d.SetUDH(udh)
+	return d, nil
+}
+
+var (
+	// ErrNoIndication indicates there are no message waiting indications.
+	ErrNoIndication = errors.New("no indication")
+)
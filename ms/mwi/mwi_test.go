@@ -0,0 +1,202 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package mwi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/mwi"
+)
+
+func newDeliver(dcs byte, udh tpdu.UserDataHeader) *tpdu.Deliver {
+	d := tpdu.NewDeliver()
+	d.DCS = dcs
+	d.SetUDH(udh)
+	return d
+}
+
+var evmiIE = tpdu.InformationElement{
+	ID:   tpdu.IEIEnhancedVoiceMailInformation,
+	Data: []byte{0x10, 0x04, 0x91, 0x21, 0x43, 0x05, 0x00},
+}
+
+var evmi = mwi.EnhancedVoiceMailIE{
+	Store:         true,
+	AccessAddress: tpdu.Address{TOA: 0x91, Addr: "1234"},
+	Messages:      5,
+}
+
+func TestParse(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   *tpdu.Deliver
+		out  mwi.WaitingStatus
+		err  error
+	}{
+		{"none",
+			newDeliver(0x00, nil),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{}},
+			mwi.ErrNoIndication},
+		{"dcs discard",
+			newDeliver(0xc8, nil),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true}}},
+			nil},
+		{"dcs store",
+			newDeliver(0xd1, nil),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Fax: {}},
+				Store: true},
+			nil},
+		{"dcs store ucs2",
+			newDeliver(0xea, nil),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Email: {Active: true}},
+				Store: true},
+			nil},
+		{"ssi",
+			newDeliver(0x00, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0xc0, 0x03}},
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x43, 0x00}},
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x47, 0x02}},
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x4b, 0x02}},
+			}),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true, Count: 3},
+				mwi.Other: {},
+				mwi.Video: {Active: true, Count: 2}},
+				Store:     true,
+				ProfileID: 2},
+			nil},
+		{"ssi overrides dcs",
+			newDeliver(0xc8, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x00, 0x04}},
+			}),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true, Count: 4}}},
+			nil},
+		{"evmi",
+			newDeliver(0xc0, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x00, 0x04}},
+				evmiIE,
+			}),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true, Count: 5}},
+				Store:     true,
+				VoiceMail: &evmi},
+			nil},
+		{"bad ssi",
+			newDeliver(0x00, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x00}},
+			}),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{}},
+			tpdu.DecodeError("ie", 0, tpdu.ErrUnderflow)},
+		{"bad evmi",
+			newDeliver(0x00, tpdu.UserDataHeader{
+				{ID: tpdu.IEIPort8, Data: []byte{0x01, 0x02}},
+				{ID: tpdu.IEIEnhancedVoiceMailInformation},
+			}),
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{}},
+			tpdu.DecodeError("ie", 1, tpdu.ErrUnderflow)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			ws, err := mwi.Parse(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, ws)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestNewDeliver(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   mwi.WaitingStatus
+		out  *tpdu.Deliver
+		err  error
+	}{
+		{"none", mwi.WaitingStatus{}, nil, mwi.ErrNoIndication},
+		{"voice",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true, Count: 2}}},
+			newDeliver(0xc8, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x00, 0x02}},
+			}),
+			nil},
+		{"clear fax store",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Fax: {Count: 2}},
+				Store: true},
+			newDeliver(0xd1, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x81, 0x00}},
+			}),
+			nil},
+		{"multiple",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Video: {Active: true, Count: 300},
+				mwi.Email: {Active: true},
+				mwi.Other: {Active: true, Count: 1}},
+				ProfileID: 1},
+			newDeliver(0xca, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x22, 0x01}},
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x23, 0x01}},
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x27, 0xff}},
+			}),
+			nil},
+		{"video",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Video: {Active: true, Count: 1}}},
+			newDeliver(0x00, tpdu.UserDataHeader{
+				{ID: tpdu.IEISpecialSMSIndication, Data: []byte{0x07, 0x01}},
+			}),
+			nil},
+		{"evmi",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true, Count: 5}},
+				Store:     true,
+				VoiceMail: &evmi},
+			newDeliver(0xd8, tpdu.UserDataHeader{evmiIE}),
+			nil},
+		{"bad profile",
+			mwi.WaitingStatus{Indications: map[mwi.Type]mwi.Indication{
+				mwi.Voice: {Active: true}},
+				ProfileID: 4},
+			nil,
+			tpdu.EncodeError("ssi", tpdu.ErrInvalid)},
+		{"bad evmi",
+			mwi.WaitingStatus{VoiceMail: &mwi.EnhancedVoiceMailIE{Messages: 256}},
+			nil,
+			tpdu.EncodeError("evmi", tpdu.ErrInvalid)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			d, err := mwi.NewDeliver(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, d)
+			if err != nil {
+				return
+			}
+			ws, err := mwi.Parse(d)
+			assert.Nil(t, err)
+			for k, v := range p.in.Indications {
+				if v.Active && v.Count == 0 {
+					v.Count = 1
+				}
+				if v.Count > 0xff {
+					v.Count = 0xff
+				}
+				if !v.Active {
+					v.Count = 0
+				}
+				assert.Equal(t, v, ws.Indications[k])
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+const (
+	// the algorithm, bits 1 and 2, of the KIc and KID.
+	algoDES = 0x01
+	algoAES = 0x02
+	algoCRC = 0x01
+
+	// the mode, bits 3 and 4, of the KIc and KID.
+	modeDESCBC   = 0x00
+	mode3DES2Key = 0x01
+	mode3DES3Key = 0x02
+	modeDESECB   = 0x03
+	modeAESCBC   = 0x00
+	modeAESCMAC  = 0x00
+	modeCRC16    = 0x00
+	modeCRC32    = 0x01
+
+	// the lengths of the RC and CC.
+	ccLength    = 8
+	crc16Length = 2
+	crc32Length = 4
+)
+
+// newBlock returns the block cipher identified by the KIc or KID.
+// The ECB flag indicates the block cipher is to be used in ECB, rather than
+// CBC, mode.
+func newBlock(k byte, key []byte) (b cipher.Block, ecb bool, err error) {
+	mode := (k >> 2) & 0x03
+	switch k & 0x03 {
+	case algoDES:
+		switch mode {
+		case modeDESCBC, modeDESECB:
+			if len(key) != 8 {
+				return nil, false, ErrInvalidKey
+			}
+			b, err = des.NewCipher(key)
+			return b, mode == modeDESECB, err
+		case mode3DES2Key:
+			if len(key) != 16 {
+				return nil, false, ErrInvalidKey
+			}
+			k3 := append(append([]byte(nil), key...), key[:8]...)
+			b, err = des.NewTripleDESCipher(k3)
+			return b, false, err
+		case mode3DES3Key:
+			if len(key) != 24 {
+				return nil, false, ErrInvalidKey
+			}
+			b, err = des.NewTripleDESCipher(key)
+			return b, false, err
+		}
+	case algoAES:
+		if mode != modeAESCBC {
+			break
+		}
+		switch len(key) {
+		case 16, 24, 32:
+			b, err = aes.NewCipher(key)
+			return b, false, err
+		}
+		return nil, false, ErrInvalidKey
+	}
+	return nil, false, ErrUnsupportedAlgorithm
+}
+
+// encrypter ciphers the secured portion of packets.
+type encrypter struct {
+	b   cipher.Block
+	ecb bool
+}
+
+// newEncrypter creates the encrypter identified by the KIc.
+func newEncrypter(kic byte, key []byte) (*encrypter, error) {
+	b, ecb, err := newBlock(kic, key)
+	if err != nil {
+		return nil, err
+	}
+	return &encrypter{b, ecb}, nil
+}
+
+// blockSize returns the block size of the cipher.
+func (e *encrypter) blockSize() int {
+	return e.b.BlockSize()
+}
+
+// encrypt ciphers the data in place.
+// The data must be a multiple of the block size.
+func (e *encrypter) encrypt(b []byte) {
+	if e.ecb {
+		bs := e.b.BlockSize()
+		for i := 0; i < len(b); i += bs {
+			e.b.Encrypt(b[i:i+bs], b[i:i+bs])
+		}
+		return
+	}
+	iv := make([]byte, e.b.BlockSize())
+	cipher.NewCBCEncrypter(e.b, iv).CryptBlocks(b, b)
+}
+
+// decrypt deciphers the data in place.
+// The data must be a multiple of the block size.
+func (e *encrypter) decrypt(b []byte) {
+	if e.ecb {
+		bs := e.b.BlockSize()
+		for i := 0; i < len(b); i += bs {
+			e.b.Decrypt(b[i:i+bs], b[i:i+bs])
+		}
+		return
+	}
+	iv := make([]byte, e.b.BlockSize())
+	cipher.NewCBCDecrypter(e.b, iv).CryptBlocks(b, b)
+}
+
+// checksummer computes the RC or CC of packets.
+type checksummer struct {
+	length int
+	sum    func([]byte) []byte
+}
+
+// newChecksummer creates the checksummer for the RC or CC identified by the
+// KID.
+func newChecksummer(i Integrity, kid byte, key []byte) (*checksummer, error) {
+	mode := (kid >> 2) & 0x03
+	switch i {
+	case IntegrityRC:
+		if kid&0x03 != algoCRC {
+			break
+		}
+		switch mode {
+		case modeCRC16:
+			return &checksummer{crc16Length, crc16Sum}, nil
+		case modeCRC32:
+			return &checksummer{crc32Length, crc32Sum}, nil
+		}
+	case IntegrityCC:
+		switch kid & 0x03 {
+		case algoDES:
+			if mode == modeDESECB {
+				break
+			}
+			b, _, err := newBlock(kid, key)
+			if err != nil {
+				return nil, err
+			}
+			return &checksummer{ccLength, func(d []byte) []byte { return cbcMAC(b, d) }}, nil
+		case algoAES:
+			if mode != modeAESCMAC {
+				break
+			}
+			b, _, err := newBlock(kid, key)
+			if err != nil {
+				return nil, err
+			}
+			return &checksummer{ccLength, func(d []byte) []byte { return cmac(b, d)[:ccLength] }}, nil
+		}
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// verify returns true if the checksum matches that computed from the data.
+func (c *checksummer) verify(data, sum []byte) bool {
+	return len(sum) == c.length && subtle.ConstantTimeCompare(c.sum(data), sum) == 1
+}
+
+// crc16Sum returns the CRC-16 of the data, as defined in ISO/IEC 13239.
+func crc16Sum(d []byte) []byte {
+	crc := uint16(0xffff)
+	for _, b := range d {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&0x0001 != 0 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	sum := make([]byte, crc16Length)
+	binary.BigEndian.PutUint16(sum, ^crc)
+	return sum
+}
+
+// crc32Sum returns the CRC-32 of the data, as defined in ISO/IEC 13239.
+func crc32Sum(d []byte) []byte {
+	sum := make([]byte, crc32Length)
+	binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(d))
+	return sum
+}
+
+// cbcMAC returns the CBC-MAC of the data, being the final block of the data
+// ciphered in CBC mode with a zero IV.
+// The data is padded with zeroes to a multiple of the block size.
+func cbcMAC(b cipher.Block, d []byte) []byte {
+	bs := b.BlockSize()
+	p := make([]byte, len(d)+padLength(len(d), bs))
+	copy(p, d)
+	if len(p) == 0 {
+		p = make([]byte, bs)
+	}
+	cipher.NewCBCEncrypter(b, make([]byte, bs)).CryptBlocks(p, p)
+	return p[len(p)-bs:]
+}
+
+// cmac returns the CMAC of the data, as defined in NIST SP 800-38B.
+func cmac(b cipher.Block, d []byte) []byte {
+	bs := b.BlockSize()
+	k1 := make([]byte, bs)
+	b.Encrypt(k1, k1)
+	cmacDouble(k1)
+	last := make([]byte, bs)
+	n := (len(d) + bs - 1) / bs
+	if n > 0 && len(d)%bs == 0 {
+		copy(last, d[(n-1)*bs:])
+		xor(last, k1)
+	} else {
+		if n == 0 {
+			n = 1
+		}
+		k2 := append([]byte(nil), k1...)
+		cmacDouble(k2)
+		r := copy(last, d[(n-1)*bs:])
+		last[r] = 0x80
+		xor(last, k2)
+	}
+	x := make([]byte, bs)
+	for i := 0; i < n-1; i++ {
+		xor(x, d[i*bs:(i+1)*bs])
+		b.Encrypt(x, x)
+	}
+	xor(x, last)
+	b.Encrypt(x, x)
+	return x
+}
+
+// cmacDouble multiplies the subkey by x in GF(2^128), in place.
+func cmacDouble(k []byte) {
+	msb := k[0] & 0x80
+	for i := 0; i < len(k)-1; i++ {
+		k[i] = k[i]<<1 | k[i+1]>>7
+	}
+	k[len(k)-1] <<= 1
+	if msb != 0 {
+		k[len(k)-1] ^= 0x87
+	}
+}
+
+// xor xors src into dst, in place.
+func xor(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// padLength returns the number of octets required to pad data of length l
+// to a multiple of the block size.
+func padLength(l, bs int) int {
+	return (bs - l%bs) % bs
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC(t *testing.T) {
+	assert.Equal(t, []byte{0x90, 0x6e}, crc16Sum([]byte("123456789")))
+	assert.Equal(t, []byte{0xcb, 0xf4, 0x39, 0x26}, crc32Sum([]byte("123456789")))
+}
+
+func TestCMAC(t *testing.T) {
+	// test vectors from RFC 4493
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	b, err := aes.NewCipher(key)
+	require.Nil(t, err)
+	patterns := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"empty", "", "bb1d6929e95937287fa37d129b756746"},
+		{"block", "6bc1bee22e409f96e93d7e117393172a",
+			"070a16b46b4d4144f79bdd9dd04a287c"},
+		{"partial", "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411",
+			"dfa66747de9ae63030ca32611497c827"},
+		{"multi", "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+			"51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			assert.Equal(t, p.out, hex.EncodeToString(cmac(b, in)))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestNewBlock(t *testing.T) {
+	patterns := []struct {
+		name string
+		k    byte
+		key  int
+		bs   int
+		ecb  bool
+		err  error
+	}{
+		{"des cbc", 0x01, 8, 8, false, nil},
+		{"des ecb", 0x0d, 8, 8, true, nil},
+		{"3des 2key", 0x05, 16, 8, false, nil},
+		{"3des 3key", 0x09, 24, 8, false, nil},
+		{"aes 128", 0x02, 16, 16, false, nil},
+		{"aes 192", 0x02, 24, 16, false, nil},
+		{"aes 256", 0x12, 32, 16, false, nil},
+		{"des short", 0x01, 7, 0, false, ErrInvalidKey},
+		{"3des short", 0x05, 8, 0, false, ErrInvalidKey},
+		{"aes short", 0x02, 8, 0, false, ErrInvalidKey},
+		{"aes mode", 0x06, 16, 0, false, ErrUnsupportedAlgorithm},
+		{"proprietary", 0x03, 16, 0, false, ErrUnsupportedAlgorithm},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, ecb, err := newBlock(p.k, make([]byte, p.key))
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.ecb, ecb)
+			if p.err == nil {
+				require.NotNil(t, b)
+				assert.Equal(t, p.bs, b.BlockSize())
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package ota provides encoding and decoding of the secured packets used to
+// remotely manage a (U)SIM Over The Air, as described in 3GPP TS 31.115,
+// 3GPP TS 23.048 and ETSI TS 102 225.
+//
+// Command packets are sent to the (U)SIM in SMS-PP, with the PID set to
+// (U)SIM Data download, and are identified by the Command Packet Identifier
+// in the UDH.  Response packets, also known as Proof of Receipt (PoR), are
+// returned in either the SMS-DELIVER-REPORT or an SMS-SUBMIT, and are
+// identified by the Response Packet Identifier in the UDH.
+package ota
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Integrity identifies the form of integrity check applied to a packet, i.e.
+// the RC/CC/DS field.
+type Integrity byte
+
+const (
+	// IntegrityNone indicates no integrity check.
+	IntegrityNone Integrity = iota
+	// IntegrityRC indicates a Redundancy Check.
+	IntegrityRC
+	// IntegrityCC indicates a Cryptographic Checksum.
+	IntegrityCC
+	// IntegrityDS indicates a Digital Signature.
+	IntegrityDS
+)
+
+var integrityNames = map[Integrity]string{
+	IntegrityNone: "none",
+	IntegrityRC:   "rc",
+	IntegrityCC:   "cc",
+	IntegrityDS:   "ds",
+}
+
+func (i Integrity) String() string {
+	if s, ok := integrityNames[i]; ok {
+		return s
+	}
+	return fmt.Sprintf("Integrity(%d)", int(i))
+}
+
+// CounterMode identifies the handling of the counter by the receiving
+// entity.
+type CounterMode byte
+
+const (
+	// CounterNone indicates no counter is available.
+	CounterNone CounterMode = iota
+	// CounterNoCheck indicates the counter is available, but no replay
+	// checking is performed.
+	CounterNoCheck
+	// CounterHigher indicates the packet is only processed if the counter
+	// is higher than the value held by the receiving entity.
+	CounterHigher
+	// CounterOneHigher indicates the packet is only processed if the
+	// counter is one higher than the value held by the receiving entity.
+	CounterOneHigher
+)
+
+var counterModeNames = map[CounterMode]string{
+	CounterNone:      "none",
+	CounterNoCheck:   "noCheck",
+	CounterHigher:    "higher",
+	CounterOneHigher: "oneHigher",
+}
+
+func (c CounterMode) String() string {
+	if s, ok := counterModeNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("CounterMode(%d)", int(c))
+}
+
+// PoRMode identifies when a Proof of Receipt is to be returned.
+type PoRMode byte
+
+const (
+	// PoRNone indicates no PoR is required.
+	PoRNone PoRMode = iota
+	// PoRRequired indicates a PoR is required.
+	PoRRequired
+	// PoROnError indicates a PoR is only required if an error occurs.
+	PoROnError
+)
+
+var porModeNames = map[PoRMode]string{
+	PoRNone:     "none",
+	PoRRequired: "required",
+	PoROnError:  "onError",
+}
+
+func (p PoRMode) String() string {
+	if s, ok := porModeNames[p]; ok {
+		return s
+	}
+	return fmt.Sprintf("PoRMode(%d)", int(p))
+}
+
+// SPI is the Security Parameter Indicator of a command packet, which
+// identifies the security applied to the command packet and the response
+// packet returned for it.
+type SPI struct {
+	Integrity Integrity
+	Ciphered  bool
+	Counter   CounterMode
+
+	PoR          PoRMode
+	PoRIntegrity Integrity
+	PoRCiphered  bool
+
+	// PoRSubmit indicates the PoR is to be returned in an SMS-SUBMIT,
+	// rather than the SMS-DELIVER-REPORT.
+	PoRSubmit bool
+}
+
+// spiLength is the length of the SPI in a command packet.
+const spiLength = 2
+
+// MarshalBinary marshals the SPI into binary.
+func (s *SPI) MarshalBinary() ([]byte, error) {
+	if s.Integrity > IntegrityDS || s.Counter > CounterOneHigher ||
+		s.PoR > PoROnError || s.PoRIntegrity > IntegrityDS {
+		return nil, ErrInvalid
+	}
+	b := []byte{byte(s.Integrity) | byte(s.Counter)<<3,
+		byte(s.PoR) | byte(s.PoRIntegrity)<<2}
+	if s.Ciphered {
+		b[0] |= 0x04
+	}
+	if s.PoRCiphered {
+		b[1] |= 0x10
+	}
+	if s.PoRSubmit {
+		b[1] |= 0x20
+	}
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals the SPI from binary.
+func (s *SPI) UnmarshalBinary(src []byte) error {
+	if len(src) < spiLength {
+		return ErrUnderflow
+	}
+	if src[1]&0x03 == 0x03 {
+		return ErrInvalid
+	}
+	s.Integrity = Integrity(src[0] & 0x03)
+	s.Ciphered = src[0]&0x04 != 0
+	s.Counter = CounterMode((src[0] >> 3) & 0x03)
+	s.PoR = PoRMode(src[1] & 0x03)
+	s.PoRIntegrity = Integrity((src[1] >> 2) & 0x03)
+	s.PoRCiphered = src[1]&0x10 != 0
+	s.PoRSubmit = src[1]&0x20 != 0
+	return nil
+}
+
+// Security identifies the security applied to a command packet, and to the
+// response packet returned for it.
+type Security struct {
+	SPI SPI
+
+	// KIc identifies the key and algorithm used for ciphering.
+	KIc byte
+
+	// KID identifies the key and algorithm used for the RC/CC/DS.
+	KID byte
+}
+
+// Keys contains the keys identified by the KIc and KID of a Security.
+//
+// DES keys are 8 octets, two key 3DES keys are 16 octets, three key 3DES
+// keys are 24 octets, and AES keys are 16, 24 or 32 octets.
+// The keys are only required if the corresponding security is applied.
+type Keys struct {
+	// KIc is the ciphering key.
+	KIc []byte
+
+	// KID is the key for the cryptographic checksum.
+	// It is not required for a redundancy check.
+	KID []byte
+}
+
+var (
+	// ErrChecksum indicates the RC/CC of a packet does not match the value
+	// computed from the packet.
+	ErrChecksum = errors.New("ota: checksum mismatch")
+
+	// ErrInvalid indicates a value is invalid.
+	ErrInvalid = errors.New("ota: invalid")
+
+	// ErrInvalidKey indicates a key is missing or is the wrong length for
+	// the algorithm.
+	ErrInvalidKey = errors.New("ota: invalid key")
+
+	// ErrNotSecured indicates the TPDU does not contain a secured packet.
+	ErrNotSecured = errors.New("ota: not a secured packet")
+
+	// ErrOverlength indicates the packet is too long.
+	ErrOverlength = errors.New("ota: overlength")
+
+	// ErrUnderflow indicates the binary provided is too short.
+	ErrUnderflow = errors.New("ota: underflow")
+
+	// ErrUnsupportedAlgorithm indicates the algorithm identified by the KIc
+	// or KID is not supported.
+	ErrUnsupportedAlgorithm = errors.New("ota: unsupported algorithm")
+)
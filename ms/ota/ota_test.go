@@ -0,0 +1,125 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/ms/ota"
+)
+
+func TestSPIMarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   ota.SPI
+		out  []byte
+		err  error
+	}{
+		{"none", ota.SPI{}, []byte{0x00, 0x00}, nil},
+		{"cc ciphered counter", ota.SPI{
+			Integrity: ota.IntegrityCC,
+			Ciphered:  true,
+			Counter:   ota.CounterHigher},
+			[]byte{0x16, 0x00}, nil},
+		{"por", ota.SPI{
+			PoR:          ota.PoRRequired,
+			PoRIntegrity: ota.IntegrityCC,
+			PoRCiphered:  true,
+			PoRSubmit:    true},
+			[]byte{0x00, 0x39}, nil},
+		{"invalid integrity", ota.SPI{Integrity: 4}, nil, ota.ErrInvalid},
+		{"invalid counter", ota.SPI{Counter: 4}, nil, ota.ErrInvalid},
+		{"invalid por", ota.SPI{PoR: 3}, nil, ota.ErrInvalid},
+		{"invalid por integrity", ota.SPI{PoRIntegrity: 4}, nil, ota.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSPIUnmarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		out  ota.SPI
+		err  error
+	}{
+		{"none", []byte{0x00, 0x00}, ota.SPI{}, nil},
+		{"cc ciphered counter", []byte{0x16, 0x00}, ota.SPI{
+			Integrity: ota.IntegrityCC,
+			Ciphered:  true,
+			Counter:   ota.CounterHigher}, nil},
+		{"por", []byte{0x00, 0x39}, ota.SPI{
+			PoR:          ota.PoRRequired,
+			PoRIntegrity: ota.IntegrityCC,
+			PoRCiphered:  true,
+			PoRSubmit:    true}, nil},
+		{"underflow", []byte{0x00}, ota.SPI{}, ota.ErrUnderflow},
+		{"invalid por", []byte{0x00, 0x03}, ota.SPI{}, ota.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			s := ota.SPI{}
+			err := s.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, s)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestIntegrityString(t *testing.T) {
+	patterns := []struct {
+		in  ota.Integrity
+		out string
+	}{
+		{ota.IntegrityNone, "none"},
+		{ota.IntegrityRC, "rc"},
+		{ota.IntegrityCC, "cc"},
+		{ota.IntegrityDS, "ds"},
+		{4, "Integrity(4)"},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, p.out, p.in.String())
+	}
+}
+
+func TestCounterModeString(t *testing.T) {
+	patterns := []struct {
+		in  ota.CounterMode
+		out string
+	}{
+		{ota.CounterNone, "none"},
+		{ota.CounterNoCheck, "noCheck"},
+		{ota.CounterHigher, "higher"},
+		{ota.CounterOneHigher, "oneHigher"},
+		{4, "CounterMode(4)"},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, p.out, p.in.String())
+	}
+}
+
+func TestPoRModeString(t *testing.T) {
+	patterns := []struct {
+		in  ota.PoRMode
+		out string
+	}{
+		{ota.PoRNone, "none"},
+		{ota.PoRRequired, "required"},
+		{ota.PoROnError, "onError"},
+		{3, "PoRMode(3)"},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, p.out, p.in.String())
+	}
+}
@@ -0,0 +1,458 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+const (
+	// MaxCounter is the maximum value of the 5 octet counter.
+	MaxCounter = 1<<40 - 1
+
+	// tarLength is the length of the TAR.
+	tarLength = 3
+
+	// counterLength is the length of the CNTR.
+	counterLength = 5
+
+	// command packet field offsets.
+	cplOffset     = 0
+	chlOffset     = 2
+	spiOffset     = 3
+	kicOffset     = 5
+	kidOffset     = 6
+	cmdTAROffset  = 7
+	cmdCNTROffset = 10
+	cmdPCNTR      = 15
+	cmdCSOffset   = 16
+
+	// minCHL is the length of the command header, excluding the RC/CC/DS.
+	minCHL = cmdCSOffset - spiOffset
+
+	// response packet field offsets.
+	rplOffset     = 0
+	rhlOffset     = 2
+	rspTAROffset  = 3
+	rspCNTROffset = 6
+	rspPCNTR      = 11
+	rspStatus     = 12
+	rspCSOffset   = 13
+
+	// minRHL is the length of the response header, excluding the RC/CC/DS.
+	minRHL = rspCSOffset - rspTAROffset
+)
+
+// commandUDH is the UDH of an SMS carrying a command packet, being the
+// UDHL, IEIa and IEIDLa, which is included in the RC/CC/DS of the command
+// packet as per 3GPP TS 31.115 Section 4.2.
+var commandUDH = []byte{0x02, tpdu.IEICommandPacket, 0x00}
+
+// responseUDH is the UDH of an SMS carrying a response packet, being the
+// UDHL, IEIa and IEIDLa, which is included in the RC/CC/DS of the response
+// packet as per 3GPP TS 31.115 Section 4.3.
+var responseUDH = []byte{0x02, tpdu.IEIResponsePacket, 0x00}
+
+// CommandPacket is a secured command packet sent to a (U)SIM, as defined in
+// ETSI TS 102 225 Section 5.1.
+type CommandPacket struct {
+	Security
+
+	// TAR is the Toolkit Application Reference, which identifies the
+	// application on the (U)SIM the command is addressed to.
+	TAR [tarLength]byte
+
+	// Counter is the replay detection counter, CNTR.
+	Counter uint64
+
+	// Checksum is the RC/CC/DS.
+	// It is populated by Unmarshal, and is only used by Marshal for a DS,
+	// as the RC and CC are computed.
+	Checksum []byte
+
+	// Data is the secured data, excluding any padding.
+	Data []byte
+}
+
+// Marshal marshals the CommandPacket into binary, applying the security
+// identified by the SPI, KIc and KID using the keys.
+//
+// The RC or CC is computed over the packet, and the packet is ciphered if
+// required.  A DS cannot be computed, so must be provided in the Checksum.
+//
+// The RC/CC is computed for a command packet carried in SMS, so it covers
+// the UDH containing the Command Packet Identifier, which precedes the
+// command packet.
+func (p *CommandPacket) Marshal(k Keys) ([]byte, error) {
+	if p.Counter > MaxCounter {
+		return nil, ErrInvalid
+	}
+	spi, err := p.SPI.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSealer(p.SPI.Integrity, p.SPI.Ciphered, p.Security, k, p.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	s.prefix = commandUDH
+	pad := s.padLength(cmdCSOffset - cmdCNTROffset + len(p.Data))
+	chl := minCHL + s.csLength
+	cpl := 1 + chl + len(p.Data) + pad
+	if cpl > 0xffff {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, cmdCSOffset+s.csLength, 2+cpl)
+	binary.BigEndian.PutUint16(b[cplOffset:], uint16(cpl))
+	b[chlOffset] = byte(chl)
+	copy(b[spiOffset:], spi)
+	b[kicOffset] = p.KIc
+	b[kidOffset] = p.KID
+	copy(b[cmdTAROffset:], p.TAR[:])
+	putCounter(b[cmdCNTROffset:], p.Counter)
+	b[cmdPCNTR] = byte(pad)
+	b = append(b, p.Data...)
+	b = append(b, make([]byte, pad)...)
+	s.seal(b, cmdCSOffset, cmdCNTROffset)
+	return b, nil
+}
+
+// Unmarshal unmarshals the CommandPacket from binary, deciphering the
+// packet and verifying the RC or CC, as identified by the SPI, KIc and KID,
+// using the keys.
+//
+// As per Marshal, the RC or CC is verified for a command packet carried in
+// SMS.
+// A DS is not verified.
+// If the RC or CC does not match then the packet is unmarshalled and
+// ErrChecksum is returned.
+func (p *CommandPacket) Unmarshal(src []byte, k Keys) error {
+	if len(src) < cmdCSOffset {
+		return ErrUnderflow
+	}
+	if err := checkLength(src); err != nil {
+		return err
+	}
+	chl := int(src[chlOffset])
+	if chl < minCHL || spiOffset+chl > len(src) {
+		return ErrInvalid
+	}
+	cp := CommandPacket{}
+	if err := cp.SPI.UnmarshalBinary(src[spiOffset:]); err != nil {
+		return err
+	}
+	cp.KIc = src[kicOffset]
+	cp.KID = src[kidOffset]
+	copy(cp.TAR[:], src[cmdTAROffset:])
+	cs := chl - minCHL
+	s, err := newSealer(cp.SPI.Integrity, cp.SPI.Ciphered, cp.Security, k, nil)
+	if err != nil {
+		return err
+	}
+	s.prefix = commandUDH
+	b, ok, err := s.open(src, cmdCSOffset, cs, cmdCNTROffset)
+	if err != nil {
+		return err
+	}
+	cp.Counter = getCounter(b[cmdCNTROffset:])
+	cp.Checksum = b[cmdCSOffset : cmdCSOffset+cs]
+	data := b[cmdCSOffset+cs:]
+	pad := int(b[cmdPCNTR])
+	if pad > len(data) {
+		return ErrInvalid
+	}
+	cp.Data = data[:len(data)-pad]
+	*p = cp
+	if !ok {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// ResponseStatus is the status code of a response packet, as defined in
+// ETSI TS 102 225 Section 5.2.
+type ResponseStatus byte
+
+const (
+	// StatusOK indicates the command was processed.
+	StatusOK ResponseStatus = iota
+	// StatusChecksumFailed indicates the RC/CC/DS failed.
+	StatusChecksumFailed
+	// StatusCounterLow indicates the counter was too low.
+	StatusCounterLow
+	// StatusCounterHigh indicates the counter was too high.
+	StatusCounterHigh
+	// StatusCounterBlocked indicates the counter is blocked.
+	StatusCounterBlocked
+	// StatusCipheringError indicates a ciphering error.
+	StatusCipheringError
+	// StatusSecurityError indicates an unidentified security error.
+	StatusSecurityError
+	// StatusInsufficientMemory indicates insufficient memory to process
+	// the command.
+	StatusInsufficientMemory
+	// StatusMoreTime indicates more time is required to process the
+	// command.
+	StatusMoreTime
+	// StatusTARUnknown indicates the TAR is unknown.
+	StatusTARUnknown
+	// StatusInsufficientSecurity indicates the security level of the
+	// command was insufficient.
+	StatusInsufficientSecurity
+	// StatusResponseInSubmit indicates the response data is returned in an
+	// SMS-SUBMIT.
+	StatusResponseInSubmit
+)
+
+var responseStatusNames = map[ResponseStatus]string{
+	StatusOK:                   "ok",
+	StatusChecksumFailed:       "checksumFailed",
+	StatusCounterLow:           "counterLow",
+	StatusCounterHigh:          "counterHigh",
+	StatusCounterBlocked:       "counterBlocked",
+	StatusCipheringError:       "cipheringError",
+	StatusSecurityError:        "securityError",
+	StatusInsufficientMemory:   "insufficientMemory",
+	StatusMoreTime:             "moreTime",
+	StatusTARUnknown:           "tarUnknown",
+	StatusInsufficientSecurity: "insufficientSecurity",
+	StatusResponseInSubmit:     "responseInSubmit",
+}
+
+func (s ResponseStatus) String() string {
+	if n, ok := responseStatusNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("ResponseStatus(%d)", int(s))
+}
+
+// ResponsePacket is a secured response packet, or Proof of Receipt,
+// returned by a (U)SIM, as defined in ETSI TS 102 225 Section 5.2.
+type ResponsePacket struct {
+	// TAR is the Toolkit Application Reference from the command packet.
+	TAR [tarLength]byte
+
+	// Counter is the counter from the command packet.
+	Counter uint64
+
+	Status ResponseStatus
+
+	// Checksum is the RC/CC/DS.
+	// It is populated by Unmarshal, and is only used by Marshal for a DS,
+	// as the RC and CC are computed.
+	Checksum []byte
+
+	// Data is the additional response data, excluding any padding.
+	Data []byte
+}
+
+// Marshal marshals the ResponsePacket into binary, applying the PoR
+// security identified by the Security of the command packet, using the
+// keys.
+//
+// The RC/CC/DS is computed for a response packet carried in SMS, so it
+// covers the UDH containing the Response Packet Identifier, which precedes
+// the response packet.
+func (r *ResponsePacket) Marshal(sec Security, k Keys) ([]byte, error) {
+	if r.Counter > MaxCounter {
+		return nil, ErrInvalid
+	}
+	s, err := newSealer(sec.SPI.PoRIntegrity, sec.SPI.PoRCiphered, sec, k, r.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	s.prefix = responseUDH
+	pad := s.padLength(rspCSOffset - rspCNTROffset + len(r.Data))
+	rhl := minRHL + s.csLength
+	rpl := 1 + rhl + len(r.Data) + pad
+	if rpl > 0xffff {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, rspCSOffset+s.csLength, 2+rpl)
+	binary.BigEndian.PutUint16(b[rplOffset:], uint16(rpl))
+	b[rhlOffset] = byte(rhl)
+	copy(b[rspTAROffset:], r.TAR[:])
+	putCounter(b[rspCNTROffset:], r.Counter)
+	b[rspPCNTR] = byte(pad)
+	b[rspStatus] = byte(r.Status)
+	b = append(b, r.Data...)
+	b = append(b, make([]byte, pad)...)
+	s.seal(b, rspCSOffset, rspCNTROffset)
+	return b, nil
+}
+
+// Unmarshal unmarshals the ResponsePacket from binary, deciphering the
+// packet and verifying the RC or CC, as identified by the PoR security of
+// the Security of the command packet, using the keys.
+//
+// As per Marshal, the RC or CC is verified for a response packet carried in
+// SMS.
+// A DS is not verified.
+// If the RC or CC does not match then the packet is unmarshalled and
+// ErrChecksum is returned.
+func (r *ResponsePacket) Unmarshal(src []byte, sec Security, k Keys) error {
+	if len(src) < rspCSOffset {
+		return ErrUnderflow
+	}
+	if err := checkLength(src); err != nil {
+		return err
+	}
+	rhl := int(src[rhlOffset])
+	if rhl < minRHL || rspTAROffset+rhl > len(src) {
+		return ErrInvalid
+	}
+	rp := ResponsePacket{}
+	copy(rp.TAR[:], src[rspTAROffset:])
+	cs := rhl - minRHL
+	s, err := newSealer(sec.SPI.PoRIntegrity, sec.SPI.PoRCiphered, sec, k, nil)
+	if err != nil {
+		return err
+	}
+	s.prefix = responseUDH
+	b, ok, err := s.open(src, rspCSOffset, cs, rspCNTROffset)
+	if err != nil {
+		return err
+	}
+	rp.Counter = getCounter(b[rspCNTROffset:])
+	rp.Status = ResponseStatus(b[rspStatus])
+	rp.Checksum = b[rspCSOffset : rspCSOffset+cs]
+	data := b[rspCSOffset+cs:]
+	pad := int(b[rspPCNTR])
+	if pad > len(data) {
+		return ErrInvalid
+	}
+	rp.Data = data[:len(data)-pad]
+	*r = rp
+	if !ok {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// checkLength checks the packet length, in the first two octets, is
+// consistent with the length of the packet.
+func checkLength(src []byte) error {
+	l := 2 + int(binary.BigEndian.Uint16(src))
+	switch {
+	case len(src) < l:
+		return ErrUnderflow
+	case len(src) > l:
+		return ErrOverlength
+	}
+	return nil
+}
+
+// putCounter writes the counter into the 5 octet CNTR field.
+func putCounter(b []byte, c uint64) {
+	for i := counterLength - 1; i >= 0; i-- {
+		b[i] = byte(c)
+		c >>= 8
+	}
+}
+
+// getCounter reads the counter from the 5 octet CNTR field.
+func getCounter(b []byte) uint64 {
+	var c uint64
+	for _, v := range b[:counterLength] {
+		c = c<<8 | uint64(v)
+	}
+	return c
+}
+
+// sealer applies, and removes, the integrity and ciphering of packets.
+type sealer struct {
+	integrity Integrity
+	cs        *checksummer
+	ds        []byte
+	e         *encrypter
+
+	// csLength is the length of the RC/CC/DS.
+	csLength int
+
+	// prefix is included in the RC/CC ahead of the packet.
+	prefix []byte
+}
+
+// newSealer creates a sealer for the integrity and ciphering.
+// The ds is the DS to be applied by seal, if the integrity is IntegrityDS.
+func newSealer(i Integrity, ciphered bool, sec Security, k Keys, ds []byte) (*sealer, error) {
+	s := sealer{integrity: i}
+	switch i {
+	case IntegrityRC, IntegrityCC:
+		cs, err := newChecksummer(i, sec.KID, k.KID)
+		if err != nil {
+			return nil, err
+		}
+		s.cs = cs
+		s.csLength = cs.length
+	case IntegrityDS:
+		s.ds = ds
+		s.csLength = len(ds)
+	}
+	if ciphered {
+		e, err := newEncrypter(sec.KIc, k.KIc)
+		if err != nil {
+			return nil, err
+		}
+		s.e = e
+	}
+	return &s, nil
+}
+
+// padLength returns the length of padding required for the ciphered portion
+// of the packet, which has length l excluding the RC/CC/DS.
+func (s *sealer) padLength(l int) int {
+	if s.e == nil {
+		return 0
+	}
+	return padLength(l+s.csLength, s.e.blockSize())
+}
+
+// seal computes and inserts the RC/CC/DS at offset cso, then ciphers the
+// packet from offset co.
+func (s *sealer) seal(b []byte, cso, co int) {
+	switch {
+	case s.cs != nil:
+		copy(b[cso:], s.cs.sum(s.excise(b, cso, s.csLength)))
+	case s.integrity == IntegrityDS:
+		copy(b[cso:], s.ds)
+	}
+	if s.e != nil {
+		s.e.encrypt(b[co:])
+	}
+}
+
+// open deciphers a copy of the packet from offset co, then verifies the
+// RC/CC, of length csl at offset cso.
+// Returns the deciphered packet, and false if the RC/CC does not match.
+func (s *sealer) open(src []byte, cso, csl, co int) ([]byte, bool, error) {
+	b := append([]byte(nil), src...)
+	if s.e != nil {
+		if (len(b)-co)%s.e.blockSize() != 0 {
+			return nil, false, ErrInvalid
+		}
+		s.e.decrypt(b[co:])
+	}
+	if s.cs == nil {
+		return b, true, nil
+	}
+	if csl != s.cs.length {
+		return nil, false, ErrInvalid
+	}
+	return b, s.cs.verify(s.excise(b, cso, csl), b[cso:cso+csl]), nil
+}
+
+// excise returns a copy of the packet, preceded by the prefix, with the l
+// octets at offset o removed.
+func (s *sealer) excise(b []byte, o, l int) []byte {
+	d := make([]byte, 0, len(s.prefix)+len(b)-l)
+	d = append(d, s.prefix...)
+	d = append(d, b[:o]...)
+	return append(d, b[o+l:]...)
+}
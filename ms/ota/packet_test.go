@@ -0,0 +1,376 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/ms/ota"
+)
+
+var (
+	des8    = bytes.Repeat([]byte{0x11}, 8)
+	des16   = append(bytes.Repeat([]byte{0x11}, 8), bytes.Repeat([]byte{0x22}, 8)...)
+	des24   = append(des16, bytes.Repeat([]byte{0x33}, 8)...)
+	aes16   = bytes.Repeat([]byte{0x44}, 16)
+	aes32   = bytes.Repeat([]byte{0x55}, 32)
+	apdu, _ = hex.DecodeString("a0a40000023f00")
+)
+
+func TestCommandPacketMarshal(t *testing.T) {
+	p := ota.CommandPacket{
+		TAR:     [3]byte{0xb0, 0x00, 0x00},
+		Counter: 1,
+		Data:    apdu,
+	}
+	b, err := p.Marshal(ota.Keys{})
+	require.Nil(t, err)
+	assert.Equal(t, "00150d00000000b000000000000001"+"00"+"a0a40000023f00",
+		hex.EncodeToString(b))
+}
+
+func TestCommandPacketKnownAnswer(t *testing.T) {
+	patterns := []struct {
+		name string
+		sec  ota.Security
+		keys ota.Keys
+		out  string
+	}{
+		{"cc des", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC},
+			KID: 0x01},
+			ota.Keys{KID: des8},
+			"001d1502000001b00000000000000100" + "0dc2971a494c4508" + "a0a40000023f00"},
+		{"cc ciphered 3des", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC, Ciphered: true},
+			KIc: 0x05, KID: 0x05},
+			ota.Keys{KIc: des16, KID: des16},
+			"00201506000505b00000" + "045371fcc3425998feb47b484af6a6f631a53faa37258951"},
+		// CRC-32 over 027000 and the packet, rather than 1429a8ed over the
+		// packet alone.
+		{"rc crc32", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityRC},
+			KID: 0x05},
+			ota.Keys{},
+			"00191101000005b00000000000000100" + "8f3b8bbf" + "a0a40000023f00"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in := ota.CommandPacket{
+				Security: p.sec,
+				TAR:      [3]byte{0xb0, 0x00, 0x00},
+				Counter:  1,
+				Data:     apdu,
+			}
+			b, err := in.Marshal(p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, p.out, hex.EncodeToString(b))
+			out := ota.CommandPacket{}
+			err = out.Unmarshal(b, p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, in.Data, out.Data)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCommandPacketRoundTrip(t *testing.T) {
+	patterns := []struct {
+		name string
+		sec  ota.Security
+		keys ota.Keys
+		l    int
+	}{
+		{"none", ota.Security{}, ota.Keys{}, 23},
+		{"rc crc16", ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityRC}, KID: 0x01},
+			ota.Keys{}, 25},
+		{"rc crc32", ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityRC}, KID: 0x05},
+			ota.Keys{}, 27},
+		{"cc des", ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityCC}, KID: 0x01},
+			ota.Keys{KID: des8}, 31},
+		{"cc 3des", ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityCC}, KID: 0x05},
+			ota.Keys{KID: des16}, 31},
+		{"cc aes", ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityCC}, KID: 0x02},
+			ota.Keys{KID: aes16}, 31},
+		{"ciphered des", ota.Security{SPI: ota.SPI{Ciphered: true}, KIc: 0x01},
+			ota.Keys{KIc: des8}, 26},
+		{"ciphered des ecb", ota.Security{SPI: ota.SPI{Ciphered: true}, KIc: 0x0d},
+			ota.Keys{KIc: des8}, 26},
+		{"ciphered 3des 3key", ota.Security{SPI: ota.SPI{Ciphered: true}, KIc: 0x09},
+			ota.Keys{KIc: des24}, 26},
+		{"ciphered aes", ota.Security{SPI: ota.SPI{Ciphered: true}, KIc: 0x02},
+			ota.Keys{KIc: aes32}, 26},
+		{"cc ciphered 3des", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC, Ciphered: true, Counter: ota.CounterHigher},
+			KIc: 0x15, KID: 0x15},
+			ota.Keys{KIc: des16, KID: des16}, 34},
+		{"cc ciphered aes", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC, Ciphered: true, Counter: ota.CounterHigher},
+			KIc: 0x12, KID: 0x12},
+			ota.Keys{KIc: aes16, KID: aes16}, 42},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in := ota.CommandPacket{
+				Security: p.sec,
+				TAR:      [3]byte{0xb0, 0x00, 0x01},
+				Counter:  0x0102030405,
+				Data:     apdu,
+			}
+			b, err := in.Marshal(p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, p.l, len(b))
+			if p.sec.SPI.Ciphered {
+				assert.False(t, bytes.Contains(b, apdu))
+			}
+			out := ota.CommandPacket{}
+			err = out.Unmarshal(b, p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, in.Security, out.Security)
+			assert.Equal(t, in.TAR, out.TAR)
+			assert.Equal(t, in.Counter, out.Counter)
+			assert.Equal(t, in.Data, out.Data)
+			switch p.sec.SPI.Integrity {
+			case ota.IntegrityRC, ota.IntegrityCC:
+				assert.NotEmpty(t, out.Checksum)
+				// corrupt the last octet of the secured data
+				b[len(b)-1] ^= 0x01
+				err = out.Unmarshal(b, p.keys)
+				assert.Equal(t, ota.ErrChecksum, err)
+				assert.Equal(t, in.TAR, out.TAR)
+			default:
+				assert.Empty(t, out.Checksum)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCommandPacketDS(t *testing.T) {
+	ds := []byte{1, 2, 3, 4, 5, 6}
+	in := ota.CommandPacket{
+		Security: ota.Security{SPI: ota.SPI{Integrity: ota.IntegrityDS}},
+		Checksum: ds,
+		Data:     apdu,
+	}
+	b, err := in.Marshal(ota.Keys{})
+	require.Nil(t, err)
+	out := ota.CommandPacket{}
+	err = out.Unmarshal(b, ota.Keys{})
+	require.Nil(t, err)
+	assert.Equal(t, ds, out.Checksum)
+	assert.Equal(t, apdu, out.Data)
+}
+
+func TestCommandPacketMarshalError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   ota.CommandPacket
+		keys ota.Keys
+		err  error
+	}{
+		{"counter", ota.CommandPacket{Counter: ota.MaxCounter + 1}, ota.Keys{}, ota.ErrInvalid},
+		{"spi", ota.CommandPacket{Security: ota.Security{SPI: ota.SPI{PoR: 3}}},
+			ota.Keys{}, ota.ErrInvalid},
+		{"kid key", ota.CommandPacket{Security: ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC}, KID: 0x01}},
+			ota.Keys{KID: des16}, ota.ErrInvalidKey},
+		{"kic key", ota.CommandPacket{Security: ota.Security{
+			SPI: ota.SPI{Ciphered: true}, KIc: 0x02}},
+			ota.Keys{}, ota.ErrInvalidKey},
+		{"rc algorithm", ota.CommandPacket{Security: ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityRC}, KID: 0x02}},
+			ota.Keys{}, ota.ErrUnsupportedAlgorithm},
+		{"cc algorithm", ota.CommandPacket{Security: ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC}, KID: 0x0d}},
+			ota.Keys{KID: des8}, ota.ErrUnsupportedAlgorithm},
+		{"overlength", ota.CommandPacket{Data: make([]byte, 0x10000)},
+			ota.Keys{}, ota.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.Marshal(p.keys)
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestCommandPacketUnmarshalError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		keys ota.Keys
+		err  error
+	}{
+		{"underflow", "00150d00000000b0000000000000", ota.Keys{}, ota.ErrUnderflow},
+		{"cpl underflow", "00160d00000000b00000000000000100a0a40000023f00",
+			ota.Keys{}, ota.ErrUnderflow},
+		{"cpl overlength", "00140d00000000b00000000000000100a0a40000023f00",
+			ota.Keys{}, ota.ErrOverlength},
+		{"chl short", "00150c00000000b00000000000000100a0a40000023f00",
+			ota.Keys{}, ota.ErrInvalid},
+		{"chl long", "000e1800000000b00000000000000100", ota.Keys{}, ota.ErrInvalid},
+		{"spi", "00150d00030000b00000000000000100a0a40000023f00",
+			ota.Keys{}, ota.ErrInvalid},
+		{"pcntr", "00150d00000000b00000000000000108a0a40000023f00",
+			ota.Keys{}, ota.ErrInvalid},
+		{"cc length", "00150d02000001b00000000000000100a0a40000023f00",
+			ota.Keys{KID: des8}, ota.ErrInvalid},
+		{"cipher block", "00150d04000100b00000000000000100a0a40000023f00",
+			ota.Keys{KIc: des8}, ota.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			out := ota.CommandPacket{}
+			err := out.Unmarshal(in, p.keys)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, ota.CommandPacket{}, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestResponsePacketMarshal(t *testing.T) {
+	r := ota.ResponsePacket{
+		TAR:     [3]byte{0xb0, 0x00, 0x00},
+		Counter: 1,
+		Status:  ota.StatusOK,
+		Data:    []byte{0x90, 0x00},
+	}
+	b, err := r.Marshal(ota.Security{}, ota.Keys{})
+	require.Nil(t, err)
+	assert.Equal(t, "000d0ab000000000000001"+"0000"+"9000", hex.EncodeToString(b))
+}
+
+func TestResponsePacketKnownAnswer(t *testing.T) {
+	patterns := []struct {
+		name string
+		sec  ota.Security
+		keys ota.Keys
+		out  string
+	}{
+		{"cc des", ota.Security{
+			SPI: ota.SPI{PoRIntegrity: ota.IntegrityCC},
+			KID: 0x01},
+			ota.Keys{KID: des8},
+			"001512b0000000000000010000" + "410159bca8a553fe" + "9000"},
+		{"cc ciphered 3des", ota.Security{
+			SPI: ota.SPI{PoRIntegrity: ota.IntegrityCC, PoRCiphered: true},
+			KIc: 0x05, KID: 0x05},
+			ota.Keys{KIc: des16, KID: des16},
+			"001c12b00000" + "bfb11f80cc9262e6d2dd7ee8b793315c191da5413b4bffb1"},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in := ota.ResponsePacket{
+				TAR:     [3]byte{0xb0, 0x00, 0x00},
+				Counter: 1,
+				Status:  ota.StatusOK,
+				Data:    []byte{0x90, 0x00},
+			}
+			b, err := in.Marshal(p.sec, p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, p.out, hex.EncodeToString(b))
+			out := ota.ResponsePacket{}
+			err = out.Unmarshal(b, p.sec, p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, in.Data, out.Data)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestResponsePacketRoundTrip(t *testing.T) {
+	patterns := []struct {
+		name string
+		sec  ota.Security
+		keys ota.Keys
+	}{
+		{"none", ota.Security{}, ota.Keys{}},
+		{"rc", ota.Security{SPI: ota.SPI{PoRIntegrity: ota.IntegrityRC}, KID: 0x01},
+			ota.Keys{}},
+		{"cc ciphered des", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC, PoRIntegrity: ota.IntegrityCC,
+				PoRCiphered: true},
+			KIc: 0x01, KID: 0x01},
+			ota.Keys{KIc: des8, KID: des8}},
+		{"cc ciphered aes", ota.Security{
+			SPI: ota.SPI{Integrity: ota.IntegrityCC, PoRIntegrity: ota.IntegrityCC,
+				PoRCiphered: true},
+			KIc: 0x02, KID: 0x02},
+			ota.Keys{KIc: aes16, KID: aes16}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in := ota.ResponsePacket{
+				TAR:     [3]byte{0xb0, 0x00, 0x01},
+				Counter: 0x0102030405,
+				Status:  ota.StatusCounterLow,
+				Data:    []byte{0x90, 0x00},
+			}
+			b, err := in.Marshal(p.sec, p.keys)
+			require.Nil(t, err)
+			out := ota.ResponsePacket{}
+			err = out.Unmarshal(b, p.sec, p.keys)
+			require.Nil(t, err)
+			assert.Equal(t, in.TAR, out.TAR)
+			assert.Equal(t, in.Counter, out.Counter)
+			assert.Equal(t, in.Status, out.Status)
+			assert.Equal(t, in.Data, out.Data)
+			if p.sec.SPI.PoRIntegrity != ota.IntegrityNone {
+				b[len(b)-1] ^= 0x01
+				err = out.Unmarshal(b, p.sec, p.keys)
+				assert.Equal(t, ota.ErrChecksum, err)
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestResponsePacketUnmarshalError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		err  error
+	}{
+		{"underflow", "000d0ab0000000000000010000", ota.ErrUnderflow},
+		{"rpl", "000e0ab00000000000000100009000", ota.ErrUnderflow},
+		{"rhl short", "000d09b00000000000000100009000", ota.ErrInvalid},
+		{"pcntr", "000d0ab00000000000000103009000", ota.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			out := ota.ResponsePacket{}
+			err := out.Unmarshal(in, ota.Security{}, ota.Keys{})
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, ota.ResponsePacket{}, out)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestResponseStatusString(t *testing.T) {
+	patterns := []struct {
+		in  ota.ResponseStatus
+		out string
+	}{
+		{ota.StatusOK, "ok"},
+		{ota.StatusTARUnknown, "tarUnknown"},
+		{ota.StatusResponseInSubmit, "responseInSubmit"},
+		{0x0c, "ResponseStatus(12)"},
+	}
+	for _, p := range patterns {
+		assert.Equal(t, p.out, p.in.String())
+	}
+}
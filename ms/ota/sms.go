@@ -0,0 +1,85 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota
+
+import (
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/sar"
+)
+
+const (
+	// PIDDataDownload is the PID of a (U)SIM data download.
+	PIDDataDownload byte = 0x7f
+
+	// DCSClass2 is the DCS of a class 2 (SIM specific) 8bit message.
+	DCSClass2 byte = 0xf6
+)
+
+// SubmitTemplate returns a template for the SMS-Submit TPDUs that carry a
+// command packet to the destination address.
+//
+// The template has the PID set to (U)SIM data download, the DCS set to class
+// 2 8bit, and the UDH containing the Command Packet Identifier.
+func SubmitTemplate(da tpdu.Address) *tpdu.Submit {
+	t := tpdu.NewSubmit()
+	t.DA = da
+	t.PID = PIDDataDownload
+	t.DCS = DCSClass2
+	t.SetUDH(tpdu.UserDataHeader{{ID: tpdu.IEICommandPacket}})
+	return t
+}
+
+// Segment returns the SMS-Submit TPDUs required to carry the command packet,
+// as generated by the Segmenter from the template.
+//
+// The template is typically created using SubmitTemplate.
+// If the packet is segmented then the Command Packet Identifier is only
+// retained in the first segment, as per 3GPP TS 31.115 Section 4.2.
+func Segment(s *sar.Segmenter, packet []byte, t *tpdu.Submit) []tpdu.Submit {
+	pdus := s.Segment(packet, t)
+	if len(pdus) < 2 {
+		return pdus
+	}
+	for i := 1; i < len(pdus); i++ {
+		var udh tpdu.UserDataHeader
+		for _, ie := range pdus[i].UDH {
+			if ie.ID != tpdu.IEICommandPacket {
+				udh = append(udh, ie)
+			}
+		}
+		pdus[i].SetUDH(udh)
+	}
+	return pdus
+}
+
+// Join returns the command packet carried in the set of SMS-Deliver TPDUs.
+//
+// The segments must be complete and in order, such as those returned by a
+// sar.Collector, and the first must contain the Command Packet Identifier.
+func Join(segments []*tpdu.Deliver) ([]byte, error) {
+	if len(segments) == 0 {
+		return nil, ErrUnderflow
+	}
+	if _, ok := segments[0].UDH.IE(tpdu.IEICommandPacket); !ok {
+		return nil, ErrNotSecured
+	}
+	var packet []byte
+	for _, s := range segments {
+		packet = append(packet, s.UD...)
+	}
+	return packet, nil
+}
+
+// ResponseData returns the response packet carried in the UD of a TPDU,
+// such as the SMS-Deliver-Report or SMS-Submit returned by the (U)SIM.
+//
+// The UDH must contain the Response Packet Identifier.
+func ResponseData(t *tpdu.TPDU) ([]byte, error) {
+	if _, ok := t.UDH.IE(tpdu.IEIResponsePacket); !ok {
+		return nil, ErrNotSecured
+	}
+	return t.UD, nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package ota_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/ota"
+	"github.com/warthog618/sms/ms/sar"
+)
+
+func TestSubmitTemplate(t *testing.T) {
+	da := tpdu.Address{TOA: 0x91, Addr: "61123456789"}
+	s := ota.SubmitTemplate(da)
+	assert.Equal(t, da, s.DA)
+	assert.Equal(t, byte(0x7f), s.PID)
+	assert.Equal(t, byte(0xf6), s.DCS)
+	assert.True(t, s.UDHI())
+	assert.Equal(t, tpdu.UserDataHeader{{ID: tpdu.IEICommandPacket}}, s.UDH)
+}
+
+func TestSegmentJoin(t *testing.T) {
+	patterns := []struct {
+		name     string
+		l        int
+		segments int
+	}{
+		{"single", 100, 1},
+		{"multi", 300, 3},
+	}
+	da := tpdu.Address{TOA: 0x91, Addr: "61123456789"}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			packet := bytes.Repeat([]byte{0xa5}, p.l)
+			pdus := ota.Segment(sar.NewSegmenter(), packet, ota.SubmitTemplate(da))
+			require.Equal(t, p.segments, len(pdus))
+			var segments []*tpdu.Deliver
+			for i, s := range pdus {
+				_, ok := s.UDH.IE(tpdu.IEICommandPacket)
+				assert.Equal(t, i == 0, ok)
+				if p.segments > 1 {
+					_, ok = s.UDH.IE(tpdu.IEIConcatenated8)
+					assert.True(t, ok)
+				}
+				d := tpdu.NewDeliver()
+				d.PID = s.PID
+				d.DCS = s.DCS
+				d.SetUDH(s.UDH)
+				d.UD = s.UD
+				segments = append(segments, d)
+			}
+			j, err := ota.Join(segments)
+			require.Nil(t, err)
+			assert.Equal(t, packet, j)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestJoinError(t *testing.T) {
+	_, err := ota.Join(nil)
+	assert.Equal(t, ota.ErrUnderflow, err)
+	d := tpdu.NewDeliver()
+	d.UD = []byte{1, 2, 3}
+	_, err = ota.Join([]*tpdu.Deliver{d})
+	assert.Equal(t, ota.ErrNotSecured, err)
+}
+
+func TestResponseData(t *testing.T) {
+	p := tpdu.TPDU{UD: []byte{1, 2, 3}}
+	d, err := ota.ResponseData(&p)
+	assert.Equal(t, ota.ErrNotSecured, err)
+	assert.Nil(t, d)
+	p.SetUDH(tpdu.UserDataHeader{{ID: tpdu.IEIResponsePacket}})
+	d, err = ota.ResponseData(&p)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, d)
+}
@@ -34,10 +34,13 @@ func (s *Segmenter) SetWide(w bool) {
 }
 
 // Segment returns the set of SMS-Submit TPDUs required to transmit the message
-// using the given alphabet.  A template for the SMS-Submit TPDUs is passed in,
+// using the given alphabet, or as octets if the DCS indicates the message is
+// compressed.  A template for the SMS-Submit TPDUs is passed in,
 // and provides all the fields in the resulting TPDUs, other than the UD, which
 // is populated using the message.  For multi-part messages, the UDH provided
 // in the template is extended with a concatenation IE.
+// Any other IEs in the template UDH, such as application port addressing,
+// are repeated in every segment.
 // The template UDH must not contain a concatenation IE (ID 0) or the resulting
 // TPDUs will be non-conformant.
 func (s *Segmenter) Segment(msg []byte, t *tpdu.Submit) []tpdu.Submit {
@@ -45,6 +48,10 @@ func (s *Segmenter) Segment(msg []byte, t *tpdu.Submit) []tpdu.Submit {
 		return nil
 	}
 	alpha, _ := t.Alphabet()
+	if tpdu.DCS(t.DCS).Compressed() {
+		// compressed UD is octets, irrespective of alphabet
+		alpha = tpdu.Alpha8Bit
+	}
 	udhl := t.UDH.UDHL()
 	bs := maxSML(t.MaxUDL(), udhl, alpha)
 	if len(msg) <= bs {
@@ -54,8 +61,17 @@ func (s *Segmenter) Segment(msg []byte, t *tpdu.Submit) []tpdu.Submit {
 		pdus[0].UD = msg
 		return pdus
 	}
+	s.mutex.Lock()
+	s.msgCount++
+	msgCount := s.msgCount
+	wide := s.wide
+	s.mutex.Unlock()
 	// allow for concat entry in UDH
-	bs = maxSML(t.MaxUDL(), udhl+5, alpha)
+	if wide {
+		bs = maxSML(t.MaxUDL(), udhl+6, alpha)
+	} else {
+		bs = maxSML(t.MaxUDL(), udhl+5, alpha)
+	}
 	// any point checking for bs==0?
 	var chunks [][]byte
 	switch alpha {
@@ -68,24 +84,22 @@ func (s *Segmenter) Segment(msg []byte, t *tpdu.Submit) []tpdu.Submit {
 	}
 	count := len(chunks)
 	pdus := make([]tpdu.Submit, count)
-	s.mutex.Lock()
-	s.msgCount++
-	msgCount := s.msgCount
-	wide := s.wide
-	s.mutex.Unlock()
 	for i := 0; i < count; i++ {
 		sg := &pdus[i]
 		*sg = *t
 		ie := tpdu.InformationElement{}
 		if wide {
-			ie.ID = 8
+			ie.ID = tpdu.IEIConcatenated16
 			ie.Data = []byte{0, 0, byte(count), byte(i + 1)}
 			binary.BigEndian.PutUint16(ie.Data, uint16(msgCount))
 		} else {
-			ie.ID = 0
+			ie.ID = tpdu.IEIConcatenated8
 			ie.Data = []byte{byte(msgCount), byte(count), byte(i + 1)}
 		}
-		sg.SetUDH(append(t.UDH, ie))
+		// copy the template UDH so segments don't share a backing array
+		udh := make(tpdu.UserDataHeader, 0, len(t.UDH)+1)
+		udh = append(udh, t.UDH...)
+		sg.SetUDH(append(udh, ie))
 		sg.UD = chunks[i]
 	}
 	return pdus
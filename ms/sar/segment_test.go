@@ -108,6 +108,15 @@ func TestSegment(t *testing.T) {
 				{8, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}, tpdu.InformationElement{ID: 0, Data: []byte{5, 2, 2}}},
 					[]byte("cters is more than you might think")}},
 		},
+		{"two segment compressed udh",
+			segmentInPattern{[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you might think"),
+				0x20, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}}},
+			[]segmentOutPattern{
+				{0x20, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}, tpdu.InformationElement{ID: 0, Data: []byte{6, 2, 1}}},
+					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 charac")},
+				{0x20, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}, tpdu.InformationElement{ID: 0, Data: []byte{6, 2, 2}}},
+					[]byte("ters is more than you might think")}},
+		},
 	}
 	s := sar.NewSegmenter()
 	for _, p := range patterns {
@@ -147,19 +156,19 @@ func TestSegmentWide(t *testing.T) {
 			segmentInPattern{[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you might think"), 0, nil},
 			[]segmentOutPattern{
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 8, Data: []byte{0, 1, 2, 1}}},
-					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you mi")},
+					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you m")},
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 8, Data: []byte{0, 1, 2, 2}}},
-					[]byte("ght think")}},
+					[]byte("ight think")}},
 		},
 		{"three segment 7bit",
 			segmentInPattern{[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you might think, but wait, then we also need a really really long message to trigger a three segment concatenation which requires even more characters than I care to count"), 0, nil},
 			[]segmentOutPattern{
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 8, Data: []byte{0, 2, 3, 1}}},
-					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you mi")},
+					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than you m")},
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 8, Data: []byte{0, 2, 3, 2}}},
-					[]byte("ght think, but wait, then we also need a really really long message to trigger a three segment concatenation which requires even more characters than I c")},
+					[]byte("ight think, but wait, then we also need a really really long message to trigger a three segment concatenation which requires even more characters than I")},
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 8, Data: []byte{0, 2, 3, 3}}},
-					[]byte("are to count")},
+					[]byte(" care to count")},
 			},
 		},
 		{"two segment 7bit udh",
@@ -167,9 +176,9 @@ func TestSegmentWide(t *testing.T) {
 				0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}}},
 			[]segmentOutPattern{
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}, tpdu.InformationElement{ID: 8, Data: []byte{0, 3, 2, 1}}},
-					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than ")},
+					[]byte("this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more to it as 160 characters is more than")},
 				{0, tpdu.UserDataHeader{tpdu.InformationElement{ID: 3, Data: []byte{1, 2, 3}}, tpdu.InformationElement{ID: 8, Data: []byte{0, 3, 2, 2}}},
-					[]byte("you might think")}},
+					[]byte(" you might think")}},
 		},
 	}
 	s := sar.NewSegmenter()
@@ -194,3 +203,24 @@ func TestSegmentWide(t *testing.T) {
 		t.Run(p.name, f)
 	}
 }
+
+func TestSegmentTemplateUDHCapacity(t *testing.T) {
+	// template UDH with spare capacity must not be shared between segments.
+	udh := make(tpdu.UserDataHeader, 1, 4)
+	udh[0] = tpdu.InformationElement{ID: 5, Data: []byte{0x0b, 0x84, 0x23, 0xf0}}
+	tmpl := tpdu.Submit{}
+	tmpl.DCS = byte(tpdu.Alpha8Bit << 2)
+	tmpl.SetUDH(udh)
+	s := sar.NewSegmenter()
+	out := s.Segment(make([]byte, 200), &tmpl)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(out))
+	}
+	for i, sg := range out {
+		assert.Equal(t, udh[0], sg.UDH[0])
+		_, seqno, _, ok := sg.UDH.ConcatInfo()
+		assert.True(t, ok)
+		assert.Equal(t, i+1, seqno)
+	}
+	assert.Equal(t, 1, len(tmpl.UDH))
+}
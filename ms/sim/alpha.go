@@ -0,0 +1,121 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim
+
+import (
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/ucs2"
+)
+
+const (
+	// alphaUCS2 is the tag of an alpha identifier coded as UCS2.
+	alphaUCS2 = 0x80
+	// alphaUCS2Base8 is the tag of an alpha identifier coded as GSM7 and
+	// UCS2 characters from a half page identified by an 8 bit base pointer.
+	alphaUCS2Base8 = 0x81
+	// alphaUCS2Base16 is the tag of an alpha identifier coded as GSM7 and
+	// UCS2 characters from a half page identified by a 16 bit base pointer.
+	alphaUCS2Base16 = 0x82
+)
+
+// EncodeAlphaID encodes the UTF-8 string into an alpha identifier, as
+// defined in 3GPP TS 31.102 Annex A.
+//
+// The string is encoded as unpacked GSM7, if possible, else as UCS2.
+// The encoded alpha identifier is not padded.
+func EncodeAlphaID(s string) []byte {
+	e := gsm7.NewEncoder()
+	if b, err := e.Encode([]byte(s)); err == nil {
+		return b
+	}
+	return append([]byte{alphaUCS2}, ucs2.Encode([]rune(s))...)
+}
+
+// DecodeAlphaID decodes an alpha identifier, as defined in
+// 3GPP TS 31.102 Annex A, into a UTF-8 string.
+//
+// All three UCS2 codings are supported, as is unpacked GSM7.
+// Any trailing 0xff padding is ignored.
+func DecodeAlphaID(src []byte) (string, error) {
+	if len(src) == 0 {
+		return "", nil
+	}
+	switch src[0] {
+	case alphaUCS2:
+		b := src[1:]
+		for len(b) > 1 && b[len(b)-1] == padding && b[len(b)-2] == padding {
+			b = b[:len(b)-2]
+		}
+		if len(b)&0x1 == 1 {
+			if b[len(b)-1] != padding {
+				return "", ErrInvalid
+			}
+			b = b[:len(b)-1]
+		}
+		r, err := ucs2.Decode(b)
+		if err != nil {
+			return "", err
+		}
+		return string(r), nil
+	case alphaUCS2Base8:
+		if len(src) < 3 {
+			return "", ErrUnderflow
+		}
+		return decodeAlphaBase(src[3:], int(src[1]), rune(src[2])<<7)
+	case alphaUCS2Base16:
+		if len(src) < 4 {
+			return "", ErrUnderflow
+		}
+		return decodeAlphaBase(src[4:], int(src[1]), rune(src[2])<<8|rune(src[3]))
+	}
+	b := src
+	for len(b) > 0 && b[len(b)-1] == padding {
+		b = b[:len(b)-1]
+	}
+	return decodeAlphaGSM7(b)
+}
+
+// decodeAlphaBase decodes n characters, being either GSM7 septets, or
+// offsets from the base UCS2 character.
+func decodeAlphaBase(src []byte, n int, base rune) (string, error) {
+	if len(src) < n {
+		return "", ErrUnderflow
+	}
+	var s []byte
+	start := 0
+	for i, c := range src[:n] {
+		if c&0x80 == 0 {
+			continue
+		}
+		t, err := decodeAlphaGSM7(src[start:i])
+		if err != nil {
+			return "", err
+		}
+		s = append(s, t...)
+		s = append(s, string(base+rune(c&0x7f))...)
+		start = i + 1
+	}
+	t, err := decodeAlphaGSM7(src[start:n])
+	if err != nil {
+		return "", err
+	}
+	return string(append(s, t...)), nil
+}
+
+// decodeAlphaGSM7 decodes unpacked GSM7 septets.
+func decodeAlphaGSM7(src []byte) (string, error) {
+	for _, c := range src {
+		if c&0x80 != 0 {
+			return "", ErrInvalid
+		}
+	}
+	d := gsm7.NewDecoder()
+	s, err := d.Decode(src)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
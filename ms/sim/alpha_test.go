@@ -0,0 +1,67 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/ms/sim"
+)
+
+func TestEncodeAlphaID(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		out  []byte
+	}{
+		{"empty", "", nil},
+		{"gsm7", "SMSC", []byte{0x53, 0x4d, 0x53, 0x43}},
+		{"escape", "a{", []byte{0x61, 0x1b, 0x28}},
+		{"ucs2", "Привет", []byte{0x80, 0x04, 0x1f, 0x04, 0x40, 0x04, 0x38, 0x04, 0x32,
+			0x04, 0x35, 0x04, 0x42}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b := sim.EncodeAlphaID(p.in)
+			assert.Equal(t, p.out, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestDecodeAlphaID(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		out  string
+		err  error
+	}{
+		{"empty", nil, "", nil},
+		{"padding", []byte{0xff, 0xff}, "", nil},
+		{"gsm7", []byte{0x53, 0x4d, 0x53, 0x43, 0xff, 0xff}, "SMSC", nil},
+		{"escape", []byte{0x61, 0x1b, 0x28, 0xff}, "a{", nil},
+		{"gsm7 invalid", []byte{0x53, 0xfe, 0x53}, "", sim.ErrInvalid},
+		{"ucs2", []byte{0x80, 0x04, 0x1f, 0x04, 0x40, 0xff, 0xff}, "Пр", nil},
+		{"ucs2 odd padding", []byte{0x80, 0x04, 0x1f, 0x04, 0x40, 0xff}, "Пр", nil},
+		{"ucs2 odd", []byte{0x80, 0x04, 0x1f, 0x04}, "", sim.ErrInvalid},
+		{"base8", []byte{0x81, 0x04, 0x13, 0x53, 0x95, 0xa6, 0xa6, 0xff, 0xff},
+			"Sকদদ", nil},
+		{"base16", []byte{0x82, 0x05, 0x04, 0x00, 0x2d, 0x92, 0xb3, 0x2d, 0x31},
+			"-Вг-1", nil},
+		{"base8 underflow", []byte{0x81, 0x05}, "", sim.ErrUnderflow},
+		{"base8 short", []byte{0x81, 0x05, 0x13, 0x53}, "", sim.ErrUnderflow},
+		{"base16 underflow", []byte{0x82, 0x05, 0x05}, "", sim.ErrUnderflow},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			s, err := sim.DecodeAlphaID(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, s)
+		}
+		t.Run(p.name, f)
+	}
+}
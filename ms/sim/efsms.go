@@ -0,0 +1,237 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim
+
+import (
+	"fmt"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/sar"
+)
+
+// SMSRecordLength is the length of a record in EF_SMS.
+const SMSRecordLength = 176
+
+// Status is the status of an EF_SMS record, as defined in
+// 3GPP TS 31.102 Section 4.2.25.
+type Status byte
+
+const (
+	// StatusFree indicates the record is unused.
+	StatusFree Status = 0x00
+	// StatusRead indicates a received message that has been read.
+	StatusRead Status = 0x01
+	// StatusUnread indicates a received message that has not been read.
+	StatusUnread Status = 0x03
+	// StatusSent indicates a sent message, for which no status report was
+	// requested.
+	StatusSent Status = 0x05
+	// StatusUnsent indicates a message to be sent.
+	StatusUnsent Status = 0x07
+	// StatusSRRequested indicates a sent message, for which a status report
+	// was requested but has not been received.
+	StatusSRRequested Status = 0x0d
+	// StatusSRReceived indicates a sent message, for which a status report
+	// was requested and received, but not stored in EF_SMSR.
+	StatusSRReceived Status = 0x15
+	// StatusSRStored indicates a sent message, for which a status report
+	// was requested, received and stored in EF_SMSR.
+	StatusSRStored Status = 0x1d
+)
+
+var statusNames = map[Status]string{
+	StatusFree:        "free",
+	StatusRead:        "read",
+	StatusUnread:      "unread",
+	StatusSent:        "sent",
+	StatusUnsent:      "unsent",
+	StatusSRRequested: "srRequested",
+	StatusSRReceived:  "srReceived",
+	StatusSRStored:    "srStored",
+}
+
+func (s Status) String() string {
+	if n, ok := statusNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("Status(0x%02x)", byte(s))
+}
+
+// Free returns true if the status indicates the record is unused.
+func (s Status) Free() bool {
+	return s&0x01 == 0
+}
+
+// Direction returns the direction of the TPDU contained in a record with
+// the status, being MT for received messages and MO for sent or unsent
+// messages.
+func (s Status) Direction() tpdu.Direction {
+	if s&0x04 == 0 {
+		return tpdu.MT
+	}
+	return tpdu.MO
+}
+
+// SMSRecord is a record of EF_SMS, containing a short message stored on the
+// SIM, as defined in 3GPP TS 31.102 Section 4.2.25.
+type SMSRecord struct {
+	Status Status
+
+	// SMSC is the address of the SMSC that delivered, or is to deliver, the
+	// message.
+	SMSC pdumode.SMSCAddress
+
+	// TPDU is the stored message, being a *tpdu.Deliver for received
+	// messages, and a *tpdu.Submit for sent or unsent messages.
+	// Nil for free records.
+	TPDU tpdu.PDU
+}
+
+// MarshalBinary marshals the SMSRecord into binary, padded to
+// SMSRecordLength.
+func (r *SMSRecord) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 1, SMSRecordLength)
+	b[0] = byte(r.Status)
+	if !r.Status.Free() {
+		if r.TPDU == nil || r.TPDU.Direction() != r.Status.Direction() {
+			return nil, ErrInvalid
+		}
+		smsc, err := r.SMSC.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		t, err := r.TPDU.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, smsc...)
+		b = append(b, t...)
+		if len(b) > SMSRecordLength {
+			return nil, ErrOverlength
+		}
+	}
+	for len(b) < SMSRecordLength {
+		b = append(b, padding)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals the SMSRecord from binary.
+//
+// The TPDU is decoded as a *tpdu.Deliver or *tpdu.Submit, as determined
+// by the Status.  The padding following the TPDU is ignored.
+func (r *SMSRecord) UnmarshalBinary(src []byte) error {
+	switch {
+	case len(src) < SMSRecordLength:
+		return ErrUnderflow
+	case len(src) > SMSRecordLength:
+		return ErrOverlength
+	}
+	rec := SMSRecord{Status: Status(src[0])}
+	if !rec.Status.Free() {
+		n, err := rec.SMSC.UnmarshalBinary(src[1:])
+		if err != nil {
+			return err
+		}
+		rec.TPDU, err = decodeTPDU(src[1+n:], rec.Status.Direction())
+		if err != nil {
+			return err
+		}
+	}
+	*r = rec
+	return nil
+}
+
+// decodeTPDU decodes the TPDU, which may be followed by padding, as either
+// a Deliver or Submit, as determined by the direction.
+func decodeTPDU(src []byte, drn tpdu.Direction) (tpdu.PDU, error) {
+	if len(src) < 1 {
+		return nil, tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnderflow)
+	}
+	var pdu tpdu.PDU
+	if drn == tpdu.MT {
+		pdu = tpdu.NewDeliver()
+	} else {
+		pdu = tpdu.NewSubmit()
+	}
+	if mt := tpdu.MessageType(src[0] & 0x03); mt != pdu.MTI() {
+		return nil, tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(mt))
+	}
+	fields, err := tpdu.Dissect(src, drn)
+	if l := len(fields); l > 0 && fields[l-1].Name == "trailing" {
+		// the padding is expected, so only errors preceding it are returned
+		t := fields[l-1]
+		if err == tpdu.DecodeError(t.Name, t.Offset, t.Err) {
+			err = nil
+		}
+		src = src[:t.Offset]
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := pdu.UnmarshalBinary(src); err != nil {
+		return nil, err
+	}
+	return pdu, nil
+}
+
+// UnmarshalSMSFile unmarshals a dump of EF_SMS into its records.
+// The records are returned in file order, including free records, so the
+// index of a record in the returned slice is its record number less one.
+func UnmarshalSMSFile(src []byte) ([]*SMSRecord, error) {
+	if len(src)%SMSRecordLength != 0 {
+		return nil, ErrUnderflow
+	}
+	records := make([]*SMSRecord, len(src)/SMSRecordLength)
+	for i := range records {
+		r := SMSRecord{}
+		err := r.UnmarshalBinary(src[i*SMSRecordLength : (i+1)*SMSRecordLength])
+		if err != nil {
+			return nil, ErrRecord{i + 1, err}
+		}
+		records[i] = &r
+	}
+	return records, nil
+}
+
+// Reassemble reassembles the received messages contained in the records
+// using a sar.Collector.
+//
+// The messages, each being the set of Deliver TPDUs comprising the message
+// and ordered by sequence number, are returned in the order they are
+// completed.  Free records and sent or unsent messages are ignored.
+//
+// Any TPDUs that cannot be reassembled into a complete message, such as
+// segments whose companions have been deleted from the SIM or duplicated
+// segments, are returned in record order as the incomplete TPDUs.
+func Reassemble(records []*SMSRecord) (msgs [][]*tpdu.Deliver, incomplete []*tpdu.Deliver) {
+	c := sar.NewCollector(sarTimeout, func(error) {})
+	defer c.Close()
+	var pending []*tpdu.Deliver
+	complete := map[*tpdu.Deliver]bool{}
+	for _, r := range records {
+		d, ok := r.TPDU.(*tpdu.Deliver)
+		if r.Status.Free() || !ok {
+			continue
+		}
+		pending = append(pending, d)
+		segs, err := c.Collect(d)
+		if err != nil || segs == nil {
+			continue
+		}
+		for _, s := range segs {
+			complete[s] = true
+		}
+		msgs = append(msgs, segs)
+	}
+	for _, d := range pending {
+		if !complete[d] {
+			incomplete = append(incomplete, d)
+		}
+	}
+	return
+}
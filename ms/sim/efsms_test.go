@@ -0,0 +1,242 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/sim"
+)
+
+var smsc = pdumode.SMSCAddress{Addr: "639170000293", TOA: 0x91}
+
+// smscBinary is the binary form of smsc.
+var smscBinary = []byte{0x07, 0x91, 0x36, 0x19, 0x07, 0x00, 0x20, 0x39}
+
+// deliver creates a Deliver in the form returned by UnmarshalBinary.
+func deliver(t *testing.T, seqno byte, text string) (*tpdu.Deliver, []byte) {
+	t.Helper()
+	d := tpdu.NewDeliver()
+	d.OA = tpdu.Address{Addr: "1234", TOA: 0x91}
+	if seqno != 0 {
+		d.SetUDH(tpdu.UserDataHeader{tpdu.InformationElement{ID: 0, Data: []byte{7, 2, seqno}}})
+	}
+	d.UD = []byte(text)
+	b, err := d.MarshalBinary()
+	require.Nil(t, err)
+	d = tpdu.NewDeliver()
+	require.Nil(t, d.UnmarshalBinary(b))
+	return d, b
+}
+
+// submit creates a Submit in the form returned by UnmarshalBinary.
+func submit(t *testing.T, text string) (*tpdu.Submit, []byte) {
+	t.Helper()
+	s := tpdu.NewSubmit()
+	s.DA = tpdu.Address{Addr: "1234", TOA: 0x91}
+	s.UD = []byte(text)
+	b, err := s.MarshalBinary()
+	require.Nil(t, err)
+	s = tpdu.NewSubmit()
+	require.Nil(t, s.UnmarshalBinary(b))
+	return s, b
+}
+
+// record creates the binary form of a record.
+func record(status byte, body ...[]byte) []byte {
+	b := []byte{status}
+	for _, p := range body {
+		b = append(b, p...)
+	}
+	return append(b, bytes.Repeat([]byte{0xff}, sim.SMSRecordLength-len(b))...)
+}
+
+func TestStatus(t *testing.T) {
+	patterns := []struct {
+		in   sim.Status
+		name string
+		free bool
+		drn  tpdu.Direction
+	}{
+		{sim.StatusFree, "free", true, tpdu.MT},
+		{sim.StatusRead, "read", false, tpdu.MT},
+		{sim.StatusUnread, "unread", false, tpdu.MT},
+		{sim.StatusSent, "sent", false, tpdu.MO},
+		{sim.StatusUnsent, "unsent", false, tpdu.MO},
+		{sim.StatusSRRequested, "srRequested", false, tpdu.MO},
+		{sim.StatusSRReceived, "srReceived", false, tpdu.MO},
+		{sim.StatusSRStored, "srStored", false, tpdu.MO},
+		{sim.Status(0xe1), "Status(0xe1)", false, tpdu.MT},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.name, p.in.String())
+			assert.Equal(t, p.free, p.in.Free())
+			if !p.free {
+				assert.Equal(t, p.drn, p.in.Direction())
+			}
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSRecord(t *testing.T) {
+	d, db := deliver(t, 0, "hello")
+	s, sb := submit(t, "goodbye")
+	patterns := []struct {
+		name string
+		in   []byte
+		out  sim.SMSRecord
+	}{
+		{"free", record(0x00), sim.SMSRecord{}},
+		{"read", record(0x01, smscBinary, db),
+			sim.SMSRecord{Status: sim.StatusRead, SMSC: smsc, TPDU: d}},
+		{"unread", record(0x03, smscBinary, db),
+			sim.SMSRecord{Status: sim.StatusUnread, SMSC: smsc, TPDU: d}},
+		{"no smsc", record(0x03, []byte{0}, db),
+			sim.SMSRecord{Status: sim.StatusUnread, TPDU: d}},
+		{"sent", record(0x05, smscBinary, sb),
+			sim.SMSRecord{Status: sim.StatusSent, SMSC: smsc, TPDU: s}},
+		{"unsent", record(0x07, smscBinary, sb),
+			sim.SMSRecord{Status: sim.StatusUnsent, SMSC: smsc, TPDU: s}},
+		{"sr stored", record(0x1d, smscBinary, sb),
+			sim.SMSRecord{Status: sim.StatusSRStored, SMSC: smsc, TPDU: s}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			r := sim.SMSRecord{}
+			err := r.UnmarshalBinary(p.in)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, r)
+			b, err := r.MarshalBinary()
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSRecordUnmarshalBinaryError(t *testing.T) {
+	_, db := deliver(t, 0, "hello")
+	_, sb := submit(t, "goodbye")
+	patterns := []struct {
+		name string
+		in   []byte
+		err  error
+	}{
+		{"underflow", record(0x00)[1:], sim.ErrUnderflow},
+		{"overlength", append(record(0x00), 0xff), sim.ErrOverlength},
+		{"smsc", record(0x01), tpdu.DecodeError("addr", 2, tpdu.ErrUnderflow)},
+		{"deliver as submit", record(0x05, smscBinary, db),
+			tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(tpdu.MtDeliver))},
+		{"submit as deliver", record(0x01, smscBinary, sb),
+			tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(tpdu.MtSubmit))},
+		{"missing tpdu", record(0x01, smscBinary),
+			tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(3))},
+		{"invalid udh", record(0x01, []byte{0},
+			[]byte{0x40, 0x04, 0x91, 0x21, 0x43, 0x00, 0x04,
+				0x51, 0x50, 0x71, 0x32, 0x20, 0x05, 0x23,
+				0x03, 0x05, 0x00, 0x03}),
+			tpdu.DecodeError("ud.udh.ie", 16, tpdu.ErrUnderflow)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			r := sim.SMSRecord{Status: sim.StatusUnread}
+			err := r.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, sim.SMSRecord{Status: sim.StatusUnread}, r)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSRecordMarshalBinaryError(t *testing.T) {
+	d, _ := deliver(t, 0, "hello")
+	s, _ := submit(t, "goodbye")
+	long := tpdu.NewDeliver()
+	long.DCS = 0x04
+	long.UD = make([]byte, 170)
+	patterns := []struct {
+		name string
+		in   sim.SMSRecord
+		err  error
+	}{
+		{"missing tpdu", sim.SMSRecord{Status: sim.StatusRead}, sim.ErrInvalid},
+		{"deliver as sent", sim.SMSRecord{Status: sim.StatusSent, TPDU: d}, sim.ErrInvalid},
+		{"submit as read", sim.SMSRecord{Status: sim.StatusRead, TPDU: s}, sim.ErrInvalid},
+		{"smsc", sim.SMSRecord{Status: sim.StatusRead, SMSC: pdumode.SMSCAddress{Addr: "12x"},
+			TPDU: d}, tpdu.EncodeError("addr", semioctet.ErrInvalidDigit('x'))},
+		{"overlength", sim.SMSRecord{Status: sim.StatusRead,
+			SMSC: pdumode.SMSCAddress{Addr: "6391700002931234567890", TOA: 0x91},
+			TPDU: long}, sim.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestUnmarshalSMSFile(t *testing.T) {
+	d, db := deliver(t, 0, "hello")
+	s, sb := submit(t, "goodbye")
+	file := append(record(0x01, smscBinary, db), record(0x00)...)
+	file = append(file, record(0x07, smscBinary, sb)...)
+	records, err := sim.UnmarshalSMSFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []*sim.SMSRecord{
+		{Status: sim.StatusRead, SMSC: smsc, TPDU: d},
+		{},
+		{Status: sim.StatusUnsent, SMSC: smsc, TPDU: s},
+	}, records)
+
+	records, err = sim.UnmarshalSMSFile(file[:len(file)-1])
+	assert.Equal(t, sim.ErrUnderflow, err)
+	assert.Nil(t, records)
+
+	file = append(file, record(0x01, smscBinary, sb)...)
+	records, err = sim.UnmarshalSMSFile(file)
+	assert.Equal(t, sim.ErrRecord{Record: 4,
+		Err: tpdu.DecodeError("firstOctet", 0, tpdu.ErrUnsupportedMTI(tpdu.MtSubmit))}, err)
+	assert.Nil(t, records)
+	assert.Equal(t,
+		"sim: error decoding record 4: tpdu: error decoding firstOctet at octet 0: unsupported MTI: 0x1",
+		err.Error())
+}
+
+func TestReassemble(t *testing.T) {
+	single, sdb := deliver(t, 0, "hello")
+	seg1, s1b := deliver(t, 1, "part one")
+	seg2, s2b := deliver(t, 2, "part two")
+	dup, _ := deliver(t, 1, "part one")
+	_, sb := submit(t, "goodbye")
+	file := bytes.Join([][]byte{
+		record(0x03, smscBinary, s2b),
+		record(0x00),
+		record(0x07, smscBinary, sb),
+		record(0x01, smscBinary, sdb),
+		record(0x01, smscBinary, s1b),
+		record(0x01, smscBinary, s1b),
+	}, nil)
+	records, err := sim.UnmarshalSMSFile(file)
+	require.Nil(t, err)
+	msgs, incomplete := sim.Reassemble(records)
+	assert.Equal(t, [][]*tpdu.Deliver{{single}, {seg1, seg2}}, msgs)
+	assert.Equal(t, []*tpdu.Deliver{dup}, incomplete)
+
+	msgs, incomplete = sim.Reassemble(records[:1])
+	assert.Nil(t, msgs)
+	assert.Equal(t, []*tpdu.Deliver{seg2}, incomplete)
+}
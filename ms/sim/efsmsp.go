@@ -0,0 +1,233 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim
+
+import (
+	"time"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+)
+
+const (
+	// SMSPParamsLength is the length of the parameters that follow the
+	// alpha identifier in an EF_SMSP record.
+	SMSPParamsLength = 28
+
+	// smspAddressLength is the length of the address fields in an EF_SMSP
+	// record.
+	smspAddressLength = 12
+
+	// offsets of the fields within the parameters.
+	smspDAOffset   = 1
+	smspSMSCOffset = smspDAOffset + smspAddressLength
+	smspPIDOffset  = smspSMSCOffset + smspAddressLength
+	smspDCSOffset  = smspPIDOffset + 1
+	smspVPOffset   = smspDCSOffset + 1
+)
+
+// SMSPParams identifies the optional parameters present in an EF_SMSP
+// record.
+type SMSPParams byte
+
+const (
+	// ParamDA indicates the TP-Destination Address is present.
+	ParamDA SMSPParams = 1 << iota
+	// ParamSMSC indicates the TS-Service Centre Address is present.
+	ParamSMSC
+	// ParamPID indicates the TP-Protocol Identifier is present.
+	ParamPID
+	// ParamDCS indicates the TP-Data Coding Scheme is present.
+	ParamDCS
+	// ParamVP indicates the TP-Validity Period is present.
+	ParamVP
+
+	// paramsMask covers the defined parameter indicators.
+	paramsMask = ParamDA | ParamSMSC | ParamPID | ParamDCS | ParamVP
+)
+
+// SMSPRecord is a record of EF_SMSP, containing the default parameters for
+// sending short messages, as defined in 3GPP TS 31.102 Section 4.2.27.
+type SMSPRecord struct {
+	// AlphaID is the alpha identifier of the record, which may be empty.
+	AlphaID string
+
+	// Params indicates which of the following fields are present.
+	Params SMSPParams
+
+	DA   tpdu.Address
+	SMSC pdumode.SMSCAddress
+	PID  byte
+	DCS  byte
+
+	// VP is the validity period, in relative format.
+	VP time.Duration
+}
+
+// NewSMSPRecord creates an SMSPRecord from an SMSC address and a template
+// Submit TPDU.
+//
+// The DA and SMSC are present if non-empty, and the PID and DCS are always
+// present.  The VP is present if the template has a relative VP, and the
+// template cannot have a VP in any other format.
+func NewSMSPRecord(alphaID string, smsc pdumode.SMSCAddress, t *tpdu.Submit) (*SMSPRecord, error) {
+	r := SMSPRecord{
+		AlphaID: alphaID,
+		Params:  ParamPID | ParamDCS,
+		PID:     t.PID,
+		DCS:     t.DCS,
+	}
+	if t.DA.Addr != "" {
+		r.Params |= ParamDA
+		r.DA = t.DA
+	}
+	if smsc.Addr != "" {
+		r.Params |= ParamSMSC
+		r.SMSC = smsc
+	}
+	switch t.VP.Format {
+	case tpdu.VpfNotPresent:
+	case tpdu.VpfRelative:
+		r.Params |= ParamVP
+		r.VP = t.VP.Duration
+	default:
+		return nil, ErrInvalid
+	}
+	return &r, nil
+}
+
+// Template returns the SMSC address and a template Submit TPDU populated
+// from the parameters present in the record.
+//
+// The template is suitable for use with message.Encoder.SetT.
+func (r *SMSPRecord) Template() (pdumode.SMSCAddress, *tpdu.Submit) {
+	var smsc pdumode.SMSCAddress
+	t := tpdu.NewSubmit()
+	if r.Params&ParamDA != 0 {
+		t.DA = r.DA
+	}
+	if r.Params&ParamSMSC != 0 {
+		smsc = r.SMSC
+	}
+	if r.Params&ParamPID != 0 {
+		t.PID = r.PID
+	}
+	if r.Params&ParamDCS != 0 {
+		t.DCS = r.DCS
+	}
+	if r.Params&ParamVP != 0 {
+		t.VP.SetRelative(r.VP)
+		// TP-VPF is bits 3 and 4 of the first octet.
+		t.FirstOctet |= byte(tpdu.VpfRelative) << 3
+	}
+	return smsc, t
+}
+
+// MarshalBinary marshals the SMSPRecord into binary, with the alpha
+// identifier occupying only the octets required to encode it.
+func (r *SMSPRecord) MarshalBinary() ([]byte, error) {
+	return r.MarshalRecord(0)
+}
+
+// MarshalRecord marshals the SMSPRecord into a record of length l, with
+// the alpha identifier padded to fill the record.
+// If l is zero the alpha identifier is not padded.
+func (r *SMSPRecord) MarshalRecord(l int) ([]byte, error) {
+	alpha := EncodeAlphaID(r.AlphaID)
+	if l == 0 {
+		l = len(alpha) + SMSPParamsLength
+	}
+	if len(alpha)+SMSPParamsLength > l {
+		return nil, ErrOverlength
+	}
+	b := make([]byte, l)
+	for i := range b {
+		b[i] = padding
+	}
+	copy(b, alpha)
+	p := b[l-SMSPParamsLength:]
+	p[0] = ^byte(r.Params & paramsMask)
+	if r.Params&ParamDA != 0 {
+		da, err := r.DA.MarshalBinary()
+		if err != nil {
+			return nil, tpdu.EncodeError("da", err)
+		}
+		if len(da) > smspAddressLength {
+			return nil, ErrOverlength
+		}
+		copy(p[smspDAOffset:], da)
+	}
+	if r.Params&ParamSMSC != 0 {
+		smsc, err := r.SMSC.MarshalBinary()
+		if err != nil {
+			return nil, tpdu.EncodeError("smsc", err)
+		}
+		if len(smsc) > smspAddressLength {
+			return nil, ErrOverlength
+		}
+		copy(p[smspSMSCOffset:], smsc)
+	}
+	if r.Params&ParamPID != 0 {
+		p[smspPIDOffset] = r.PID
+	}
+	if r.Params&ParamDCS != 0 {
+		p[smspDCSOffset] = r.DCS
+	}
+	if r.Params&ParamVP != 0 {
+		vp := tpdu.ValidityPeriod{}
+		vp.SetRelative(r.VP)
+		v, err := vp.MarshalBinary()
+		if err != nil {
+			return nil, tpdu.EncodeError("vp", err)
+		}
+		p[smspVPOffset] = v[0]
+	}
+	return b, nil
+}
+
+// UnmarshalBinary unmarshals the SMSPRecord from binary.
+// The length of the alpha identifier is determined from the length of the
+// record.
+// Fields not indicated as present are ignored, so are left zeroed.
+func (r *SMSPRecord) UnmarshalBinary(src []byte) error {
+	if len(src) < SMSPParamsLength {
+		return ErrUnderflow
+	}
+	ai := len(src) - SMSPParamsLength
+	alpha, err := DecodeAlphaID(src[:ai])
+	if err != nil {
+		return err
+	}
+	p := src[ai:]
+	rec := SMSPRecord{AlphaID: alpha, Params: SMSPParams(^p[0]) & paramsMask}
+	if rec.Params&ParamDA != 0 {
+		o := smspDAOffset
+		if _, err := rec.DA.UnmarshalBinary(p[o : o+smspAddressLength]); err != nil {
+			return tpdu.DecodeError("da", ai+o, err)
+		}
+	}
+	if rec.Params&ParamSMSC != 0 {
+		o := smspSMSCOffset
+		if _, err := rec.SMSC.UnmarshalBinary(p[o : o+smspAddressLength]); err != nil {
+			return tpdu.DecodeError("smsc", ai+o, err)
+		}
+	}
+	if rec.Params&ParamPID != 0 {
+		rec.PID = p[smspPIDOffset]
+	}
+	if rec.Params&ParamDCS != 0 {
+		rec.DCS = p[smspDCSOffset]
+	}
+	if rec.Params&ParamVP != 0 {
+		vp := tpdu.ValidityPeriod{}
+		if _, err := vp.UnmarshalBinary(p[smspVPOffset:], tpdu.VpfRelative); err != nil {
+			return tpdu.DecodeError("vp", ai+smspVPOffset, err)
+		}
+		rec.VP = vp.Duration
+	}
+	*r = rec
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sim_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/sim"
+)
+
+// ff returns n octets of padding.
+func ff(n int) []byte {
+	return bytes.Repeat([]byte{0xff}, n)
+}
+
+// join concatenates the slices.
+func join(b ...[]byte) []byte {
+	return bytes.Join(b, nil)
+}
+
+func TestSMSPRecord(t *testing.T) {
+	da := tpdu.Address{Addr: "1234", TOA: 0x91}
+	daBinary := []byte{0x04, 0x91, 0x21, 0x43}
+	patterns := []struct {
+		name string
+		in   []byte
+		out  sim.SMSPRecord
+	}{
+		{"smsc only",
+			join([]byte{0xfd}, ff(12), smscBinary, ff(4), ff(3)),
+			sim.SMSPRecord{Params: sim.ParamSMSC, SMSC: smsc}},
+		{"none", join([]byte{0xff}, ff(27)), sim.SMSPRecord{}},
+		{"full",
+			join([]byte{0x53, 0x4d, 0x53, 0x50, 0xe0}, daBinary, ff(8), smscBinary, ff(4),
+				[]byte{0x7f, 0x08, 0xa7}),
+			sim.SMSPRecord{AlphaID: "SMSP",
+				Params: sim.ParamDA | sim.ParamSMSC | sim.ParamPID | sim.ParamDCS | sim.ParamVP,
+				DA:     da, SMSC: smsc, PID: 0x7f, DCS: 0x08, VP: 24 * time.Hour}},
+		{"ucs2 alpha",
+			join([]byte{0x80, 0x04, 0x1f, 0x04, 0x40, 0xe3}, ff(24), []byte{0x00, 0x00, 0x01}),
+			sim.SMSPRecord{AlphaID: "Пр", Params: sim.ParamPID | sim.ParamDCS | sim.ParamVP,
+				VP: 10 * time.Minute}},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			r := sim.SMSPRecord{}
+			err := r.UnmarshalBinary(p.in)
+			assert.Nil(t, err)
+			assert.Equal(t, p.out, r)
+			b, err := r.MarshalBinary()
+			assert.Nil(t, err)
+			assert.Equal(t, p.in, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSPRecordMarshalRecord(t *testing.T) {
+	r := sim.SMSPRecord{AlphaID: "SMSP", Params: sim.ParamSMSC, SMSC: smsc}
+	b, err := r.MarshalRecord(40)
+	assert.Nil(t, err)
+	assert.Equal(t, join([]byte{0x53, 0x4d, 0x53, 0x50}, ff(8), []byte{0xfd}, ff(12),
+		smscBinary, ff(7)), b)
+
+	u := sim.SMSPRecord{}
+	err = u.UnmarshalBinary(b)
+	assert.Nil(t, err)
+	assert.Equal(t, r, u)
+
+	b, err = r.MarshalRecord(31)
+	assert.Equal(t, sim.ErrOverlength, err)
+	assert.Nil(t, b)
+}
+
+func TestSMSPRecordMarshalBinaryError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   sim.SMSPRecord
+		err  error
+	}{
+		{"da", sim.SMSPRecord{Params: sim.ParamDA, DA: tpdu.Address{Addr: "12x"}},
+			tpdu.EncodeError("da.addr", semioctet.ErrInvalidDigit('x'))},
+		{"smsc", sim.SMSPRecord{Params: sim.ParamSMSC, SMSC: pdumode.SMSCAddress{Addr: "12x"}},
+			tpdu.EncodeError("smsc.addr", semioctet.ErrInvalidDigit('x'))},
+		{"smsc overlength", sim.SMSPRecord{Params: sim.ParamSMSC,
+			SMSC: pdumode.SMSCAddress{Addr: "1234567890123456789012"}}, sim.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Nil(t, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSPRecordUnmarshalBinaryError(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		err  error
+	}{
+		{"underflow", ff(27), sim.ErrUnderflow},
+		{"alpha", join([]byte{0x53, 0xfe}, ff(28)), sim.ErrInvalid},
+		{"da", join([]byte{0x53, 0xfe}, ff(27)),
+			tpdu.DecodeError("da.addr", 4, tpdu.ErrUnderflow)},
+		{"smsc", join([]byte{0xfd}, ff(12), []byte{0x0c}, ff(14)),
+			tpdu.DecodeError("smsc.addr", 15, tpdu.ErrUnderflow)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			r := sim.SMSPRecord{PID: 1}
+			err := r.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, sim.SMSPRecord{PID: 1}, r)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSPRecordTemplate(t *testing.T) {
+	r := sim.SMSPRecord{
+		Params: sim.ParamDA | sim.ParamSMSC | sim.ParamPID | sim.ParamDCS | sim.ParamVP,
+		DA:     tpdu.Address{Addr: "1234", TOA: 0x91},
+		SMSC:   smsc,
+		PID:    0x7f,
+		DCS:    0x08,
+		VP:     24 * time.Hour,
+	}
+	s, tmpl := r.Template()
+	assert.Equal(t, smsc, s)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, tpdu.MtSubmit, tmpl.MTI())
+	assert.Equal(t, r.DA, tmpl.DA)
+	assert.Equal(t, byte(0x7f), tmpl.PID)
+	assert.Equal(t, byte(0x08), tmpl.DCS)
+	assert.Equal(t, tpdu.VpfRelative, tmpl.VP.Format)
+	assert.Equal(t, 24*time.Hour, tmpl.VP.Duration)
+
+	// the template must marshal with the VP recognised on decode
+	b, err := tmpl.MarshalBinary()
+	require.Nil(t, err)
+	d := tpdu.NewSubmit()
+	err = d.UnmarshalBinary(b)
+	assert.Nil(t, err)
+	assert.Equal(t, tmpl.VP, d.VP)
+
+	n, err := sim.NewSMSPRecord("", s, tmpl)
+	assert.Nil(t, err)
+	assert.Equal(t, &r, n)
+
+	// absent fields remain zeroed
+	r = sim.SMSPRecord{Params: sim.ParamDCS, DA: tpdu.Address{Addr: "1234"}, DCS: 0x04, PID: 1}
+	s, tmpl = r.Template()
+	assert.Equal(t, pdumode.SMSCAddress{}, s)
+	assert.Equal(t, &tpdu.Submit{TPDU: tpdu.TPDU{FirstOctet: 0x01, DCS: 0x04}}, tmpl)
+
+	n, err = sim.NewSMSPRecord("alpha", s, tmpl)
+	assert.Nil(t, err)
+	assert.Equal(t, &sim.SMSPRecord{AlphaID: "alpha", Params: sim.ParamPID | sim.ParamDCS,
+		DCS: 0x04}, n)
+
+	tmpl.VP.SetAbsolute(tpdu.Timestamp{})
+	n, err = sim.NewSMSPRecord("", s, tmpl)
+	assert.Equal(t, sim.ErrInvalid, err)
+	assert.Nil(t, n)
+}
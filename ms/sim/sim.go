@@ -0,0 +1,48 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package sim provides encoding and decoding of the SMS related elementary
+// files stored on a SIM or USIM, as defined in 3GPP TS 31.102 and
+// 3GPP TS 51.011.
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// padding is the value of unused octets in a record.
+	padding = 0xff
+
+	// sarTimeout is the reassembly timeout for messages read from a file,
+	// which only needs to cover the time taken to process the file.
+	sarTimeout = time.Minute
+)
+
+// ErrRecord indicates a record of a file could not be decoded.
+type ErrRecord struct {
+	// Record is the record number, starting at 1.
+	Record int
+
+	// Err is the error detected while decoding the record.
+	Err error
+}
+
+func (e ErrRecord) Error() string {
+	return fmt.Sprintf("sim: error decoding record %d: %v", e.Record, e.Err)
+}
+
+var (
+	// ErrInvalid indicates a value is invalid.
+	ErrInvalid = errors.New("sim: invalid")
+
+	// ErrOverlength indicates the binary provided is too long.
+	ErrOverlength = errors.New("sim: overlength")
+
+	// ErrUnderflow indicates the binary provided is too short.
+	ErrUnderflow = errors.New("sim: underflow")
+)
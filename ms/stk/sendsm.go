@@ -0,0 +1,163 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk
+
+import (
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/sim"
+)
+
+const (
+	// CmdSendShortMessage is the type of the SEND SHORT MESSAGE proactive
+	// command.
+	CmdSendShortMessage byte = 0x13
+
+	// qualifierPacking is the command qualifier bit indicating packing is
+	// required.
+	qualifierPacking byte = 0x01
+
+	// commandDetailsLength is the length of the command details value.
+	commandDetailsLength = 3
+)
+
+// SendShortMessage is the SEND SHORT MESSAGE proactive command issued by
+// the (U)SIM to the ME, as defined in 3GPP TS 31.111 Section 6.6.9.
+type SendShortMessage struct {
+	// CommandNumber identifies the command within the proactive session.
+	CommandNumber byte
+
+	// PackingRequired indicates the ME must pack the UD of the TPDU, which
+	// is provided as unpacked GSM7 with an 8bit DCS, before sending it.
+	PackingRequired bool
+
+	// AlphaID is the text to display to the user while sending.
+	// It is omitted from the command if empty.
+	AlphaID string
+
+	// SMSC is the address of the SMSC to send the TPDU to.
+	// It is omitted from the command if empty, in which case the ME uses
+	// its default SMSC.
+	SMSC pdumode.SMSCAddress
+
+	TPDU *tpdu.Submit
+}
+
+// MarshalBinary marshals the SendShortMessage command into binary.
+func (c *SendShortMessage) MarshalBinary() ([]byte, error) {
+	if c.TPDU == nil {
+		return nil, ErrMissing
+	}
+	var q byte
+	if c.PackingRequired {
+		q = qualifierPacking
+	}
+	tlvs := []TLV{
+		{Tag: TagCommandDetails, CR: true, Value: []byte{c.CommandNumber, CmdSendShortMessage, q}},
+		{Tag: TagDeviceIdentities, CR: true, Value: []byte{DeviceUICC, DeviceNetwork}},
+	}
+	if c.AlphaID != "" {
+		tlvs = append(tlvs, TLV{Tag: TagAlphaIdentifier, Value: sim.EncodeAlphaID(c.AlphaID)})
+	}
+	if c.SMSC.Addr != "" {
+		a, err := marshalAddress(c.SMSC)
+		if err != nil {
+			return nil, err
+		}
+		tlvs = append(tlvs, TLV{Tag: TagAddress, Value: a})
+	}
+	s, err := c.TPDU.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	tlvs = append(tlvs, TLV{Tag: TagSMSTPDU, CR: true, Value: s})
+	return Marshal(TagProactiveCommand, tlvs)
+}
+
+// UnmarshalBinary unmarshals the SendShortMessage command from binary.
+//
+// Returns ErrUnsupportedCommand if the proactive command is not a SEND
+// SHORT MESSAGE.
+func (c *SendShortMessage) UnmarshalBinary(src []byte) error {
+	tag, tlvs, err := Unmarshal(src)
+	if err != nil {
+		return err
+	}
+	if tag != TagProactiveCommand {
+		return ErrUnexpectedTag
+	}
+	cd, ok := find(tlvs, TagCommandDetails)
+	if !ok {
+		return ErrMissing
+	}
+	if len(cd.Value) != commandDetailsLength {
+		return ErrInvalid
+	}
+	if cd.Value[1] != CmdSendShortMessage {
+		return ErrUnsupportedCommand
+	}
+	if _, ok = find(tlvs, TagDeviceIdentities); !ok {
+		return ErrMissing
+	}
+	cmd := SendShortMessage{
+		CommandNumber:   cd.Value[0],
+		PackingRequired: cd.Value[2]&qualifierPacking != 0,
+	}
+	if a, ok := find(tlvs, TagAlphaIdentifier); ok {
+		cmd.AlphaID, err = sim.DecodeAlphaID(a.Value)
+		if err != nil {
+			return err
+		}
+	}
+	if a, ok := find(tlvs, TagAddress); ok {
+		cmd.SMSC, err = unmarshalAddress(a.Value)
+		if err != nil {
+			return err
+		}
+	}
+	t, ok := find(tlvs, TagSMSTPDU)
+	if !ok {
+		return ErrMissing
+	}
+	s := tpdu.NewSubmit()
+	if err = s.UnmarshalBinary(t.Value); err != nil {
+		return err
+	}
+	cmd.TPDU = s
+	*c = cmd
+	return nil
+}
+
+// Packed returns the TPDU to be sent by the ME.
+//
+// If packing is required, the DCS of the returned TPDU is changed to GSM7,
+// so the UD is packed when the TPDU is marshalled.  Otherwise the TPDU is
+// returned unchanged.
+func (c *SendShortMessage) Packed() (*tpdu.Submit, error) {
+	if c.TPDU == nil {
+		return nil, ErrMissing
+	}
+	if !c.PackingRequired {
+		return c.TPDU, nil
+	}
+	dcs := tpdu.DCS(c.TPDU.DCS)
+	if a, err := dcs.Alphabet(); err != nil || a != tpdu.Alpha8Bit {
+		return nil, ErrInvalid
+	}
+	for _, u := range c.TPDU.UD {
+		if u > 0x7f {
+			return nil, gsm7.ErrInvalidSeptet(u)
+		}
+	}
+	dcs, err := dcs.WithAlphabet(tpdu.Alpha7Bit)
+	if err != nil {
+		return nil, err
+	}
+	s := *c.TPDU
+	s.DCS = byte(dcs)
+	return &s, nil
+}
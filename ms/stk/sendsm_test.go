@@ -0,0 +1,156 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/gsm7"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/sim"
+	"github.com/warthog618/sms/ms/stk"
+)
+
+const (
+	// "Hello world" to 12345, as unpacked 8bit and as packed GSM7.
+	unpackedHex = "010005912143f500040b48656c6c6f20776f726c64"
+	packedHex   = "010005912143f500000bc8329bfd06dddf723619"
+)
+
+func submit(t *testing.T, s string) *tpdu.Submit {
+	b, _ := hex.DecodeString(s)
+	st := tpdu.NewSubmit()
+	err := st.UnmarshalBinary(b)
+	require.Nil(t, err)
+	return st
+}
+
+func TestSendShortMessageMarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   stk.SendShortMessage
+		out  string
+		err  error
+	}{
+		{"packing", stk.SendShortMessage{
+			CommandNumber:   1,
+			PackingRequired: true,
+			AlphaID:         "Send",
+			TPDU:            submit(t, unpackedHex)},
+			"d026" + "8103011301" + "82028183" + "050453656e64" + "8b15" + unpackedHex, nil},
+		{"smsc", stk.SendShortMessage{
+			CommandNumber: 2,
+			SMSC:          pdumode.SMSCAddress{TOA: 0x91, Addr: "61412290191"},
+			TPDU:          submit(t, packedHex)},
+			"d028" + "8103021300" + "82028183" + "0607911614220991f1" + "8b14" + packedHex, nil},
+		{"no tpdu", stk.SendShortMessage{}, "", stk.ErrMissing},
+		{"bad smsc", stk.SendShortMessage{
+			SMSC: pdumode.SMSCAddress{Addr: "1x"},
+			TPDU: submit(t, packedHex)},
+			"", tpdu.EncodeError("addr", semioctet.ErrInvalidDigit('x'))},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, hex.EncodeToString(b))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSendShortMessageUnmarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		out  stk.SendShortMessage
+		err  error
+	}{
+		{"packing", "d026" + "8103011301" + "82028183" + "050453656e64" + "8b15" + unpackedHex,
+			stk.SendShortMessage{
+				CommandNumber:   1,
+				PackingRequired: true,
+				AlphaID:         "Send",
+				TPDU:            submit(t, unpackedHex)},
+			nil},
+		{"smsc", "d028" + "8103021300" + "82028183" + "0607911614220991f1" + "8b14" + packedHex,
+			stk.SendShortMessage{
+				CommandNumber: 2,
+				SMSC:          pdumode.SMSCAddress{TOA: 0x91, Addr: "61412290191"},
+				TPDU:          submit(t, packedHex)},
+			nil},
+		{"ucs2 alpha", "d026" + "0103011300" + "02028183" + "85058000410042" + "0b14" + packedHex,
+			stk.SendShortMessage{
+				CommandNumber: 1,
+				AlphaID:       "AB",
+				TPDU:          submit(t, packedHex)},
+			nil},
+		{"underflow", "d0", stk.SendShortMessage{}, stk.ErrUnderflow},
+		{"tag", "d11f" + "8103011300" + "82028183" + "8b14" + packedHex,
+			stk.SendShortMessage{}, stk.ErrUnexpectedTag},
+		{"no command details", "d01a" + "82028183" + "8b14" + packedHex,
+			stk.SendShortMessage{}, stk.ErrMissing},
+		{"command details length", "d01e" + "81020113" + "82028183" + "8b14" + packedHex,
+			stk.SendShortMessage{}, stk.ErrInvalid},
+		{"command type", "d01f" + "8103011400" + "82028183" + "8b14" + packedHex,
+			stk.SendShortMessage{}, stk.ErrUnsupportedCommand},
+		{"no device identities", "d01b" + "8103011300" + "8b14" + packedHex,
+			stk.SendShortMessage{}, stk.ErrMissing},
+		{"bad alpha", "d025" + "8103011300" + "82028183" + "85048000410" + "08b14" + packedHex,
+			stk.SendShortMessage{}, sim.ErrInvalid},
+		{"no tpdu", "d009" + "8103011300" + "82028183",
+			stk.SendShortMessage{}, stk.ErrMissing},
+		{"bad tpdu", "d01e" + "8103011300" + "82028183" + "8b13" + packedHex[:len(packedHex)-2],
+			stk.SendShortMessage{}, tpdu.DecodeError("ud.sm", 10, tpdu.ErrUnderflow)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			c := stk.SendShortMessage{}
+			err := c.UnmarshalBinary(in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, c)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSendShortMessagePacked(t *testing.T) {
+	c := stk.SendShortMessage{PackingRequired: true, TPDU: submit(t, unpackedHex)}
+	s, err := c.Packed()
+	require.Nil(t, err)
+	b, err := s.MarshalBinary()
+	require.Nil(t, err)
+	assert.Equal(t, packedHex, hex.EncodeToString(b))
+	// original is unchanged
+	assert.Equal(t, byte(0x04), c.TPDU.DCS)
+
+	c = stk.SendShortMessage{TPDU: submit(t, packedHex)}
+	s, err = c.Packed()
+	assert.Nil(t, err)
+	assert.Equal(t, c.TPDU, s)
+
+	c = stk.SendShortMessage{PackingRequired: true}
+	s, err = c.Packed()
+	assert.Equal(t, stk.ErrMissing, err)
+	assert.Nil(t, s)
+
+	c = stk.SendShortMessage{PackingRequired: true, TPDU: submit(t, packedHex)}
+	s, err = c.Packed()
+	assert.Equal(t, stk.ErrInvalid, err)
+	assert.Nil(t, s)
+
+	c = stk.SendShortMessage{PackingRequired: true, TPDU: submit(t, unpackedHex)}
+	c.TPDU.UD = []byte{0x48, 0x80}
+	s, err = c.Packed()
+	assert.Equal(t, gsm7.ErrInvalidSeptet(0x80), err)
+	assert.Nil(t, s)
+}
@@ -0,0 +1,99 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk
+
+import (
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+)
+
+// SMSPPDownload is the SMS-PP DATA DOWNLOAD envelope passed from the ME to
+// the (U)SIM, as defined in 3GPP TS 31.111 Section 7.1.1.
+type SMSPPDownload struct {
+	// SMSC is the address of the SMSC that delivered the TPDU.
+	// It is omitted from the envelope if empty.
+	SMSC pdumode.SMSCAddress
+
+	TPDU *tpdu.Deliver
+}
+
+// MarshalBinary marshals the SMSPPDownload envelope into binary.
+func (e *SMSPPDownload) MarshalBinary() ([]byte, error) {
+	if e.TPDU == nil {
+		return nil, ErrMissing
+	}
+	tlvs := []TLV{
+		{Tag: TagDeviceIdentities, CR: true, Value: []byte{DeviceNetwork, DeviceUICC}},
+	}
+	if e.SMSC.Addr != "" {
+		a, err := marshalAddress(e.SMSC)
+		if err != nil {
+			return nil, err
+		}
+		tlvs = append(tlvs, TLV{Tag: TagAddress, Value: a})
+	}
+	d, err := e.TPDU.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	tlvs = append(tlvs, TLV{Tag: TagSMSTPDU, CR: true, Value: d})
+	return Marshal(TagSMSPPDownload, tlvs)
+}
+
+// UnmarshalBinary unmarshals the SMSPPDownload envelope from binary.
+func (e *SMSPPDownload) UnmarshalBinary(src []byte) error {
+	tag, tlvs, err := Unmarshal(src)
+	if err != nil {
+		return err
+	}
+	if tag != TagSMSPPDownload {
+		return ErrUnexpectedTag
+	}
+	if _, ok := find(tlvs, TagDeviceIdentities); !ok {
+		return ErrMissing
+	}
+	env := SMSPPDownload{}
+	if a, ok := find(tlvs, TagAddress); ok {
+		env.SMSC, err = unmarshalAddress(a.Value)
+		if err != nil {
+			return err
+		}
+	}
+	t, ok := find(tlvs, TagSMSTPDU)
+	if !ok {
+		return ErrMissing
+	}
+	d := tpdu.NewDeliver()
+	if err = d.UnmarshalBinary(t.Value); err != nil {
+		return err
+	}
+	env.TPDU = d
+	*e = env
+	return nil
+}
+
+// marshalAddress marshals the value of an address TLV, which is the
+// SMSC address without the leading length.
+func marshalAddress(a pdumode.SMSCAddress) ([]byte, error) {
+	b, err := a.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return b[1:], nil
+}
+
+// unmarshalAddress unmarshals the value of an address TLV.
+func unmarshalAddress(v []byte) (pdumode.SMSCAddress, error) {
+	a := pdumode.SMSCAddress{}
+	if len(v) == 0 {
+		return a, nil
+	}
+	if len(v) > maxLength-1 {
+		return a, ErrOverlength
+	}
+	_, err := a.UnmarshalBinary(append([]byte{byte(len(v))}, v...))
+	return a, err
+}
@@ -0,0 +1,95 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/semioctet"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/pdumode"
+	"github.com/warthog618/sms/ms/stk"
+)
+
+const (
+	deliverHex = "040b911605935713f200008140806113912304d7f79b0e"
+	smscHex    = "911614220991f1"
+)
+
+func deliver(t *testing.T) *tpdu.Deliver {
+	b, _ := hex.DecodeString(deliverHex)
+	d := tpdu.NewDeliver()
+	err := d.UnmarshalBinary(b)
+	require.Nil(t, err)
+	return d
+}
+
+func TestSMSPPDownloadMarshalBinary(t *testing.T) {
+	smsc := pdumode.SMSCAddress{TOA: 0x91, Addr: "61412290191"}
+	patterns := []struct {
+		name string
+		in   stk.SMSPPDownload
+		out  string
+		err  error
+	}{
+		{"smsc", stk.SMSPPDownload{SMSC: smsc, TPDU: deliver(t)},
+			"d1268202838106" + "07" + smscHex + "8b17" + deliverHex, nil},
+		{"no smsc", stk.SMSPPDownload{TPDU: deliver(t)},
+			"d11d82028381" + "8b17" + deliverHex, nil},
+		{"no tpdu", stk.SMSPPDownload{}, "", stk.ErrMissing},
+		{"bad smsc", stk.SMSPPDownload{
+			SMSC: pdumode.SMSCAddress{Addr: "1x"}, TPDU: deliver(t)},
+			"", tpdu.EncodeError("addr", semioctet.ErrInvalidDigit('x'))},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, hex.EncodeToString(b))
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestSMSPPDownloadUnmarshalBinary(t *testing.T) {
+	smsc := pdumode.SMSCAddress{TOA: 0x91, Addr: "61412290191"}
+	patterns := []struct {
+		name string
+		in   string
+		out  stk.SMSPPDownload
+		err  error
+	}{
+		{"smsc", "d1268202838106" + "07" + smscHex + "8b17" + deliverHex,
+			stk.SMSPPDownload{SMSC: smsc, TPDU: deliver(t)}, nil},
+		{"no smsc", "d11d82028381" + "8b17" + deliverHex,
+			stk.SMSPPDownload{TPDU: deliver(t)}, nil},
+		{"empty smsc", "d11f820283810600" + "8b17" + deliverHex,
+			stk.SMSPPDownload{TPDU: deliver(t)}, nil},
+		{"underflow", "d1", stk.SMSPPDownload{}, stk.ErrUnderflow},
+		{"tag", "d01d82028381" + "8b17" + deliverHex,
+			stk.SMSPPDownload{}, stk.ErrUnexpectedTag},
+		{"no device identities", "d1198b17" + deliverHex,
+			stk.SMSPPDownload{}, stk.ErrMissing},
+		{"no tpdu", "d10482028381", stk.SMSPPDownload{}, stk.ErrMissing},
+		{"bad smsc", "d1208202838106" + "0191" + "8b17" + deliverHex[:len(deliverHex)-2],
+			stk.SMSPPDownload{}, stk.ErrUnderflow},
+		{"bad tpdu", "d11c82028381" + "8b16" + deliverHex[:len(deliverHex)-2],
+			stk.SMSPPDownload{}, tpdu.DecodeError("ud.sm", 19, tpdu.ErrUnderflow)},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			e := stk.SMSPPDownload{}
+			err := e.UnmarshalBinary(in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, e)
+		}
+		t.Run(p.name, f)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package stk provides encoding and decoding of the SIM Toolkit BER-TLV
+// objects exchanged between the ME and the (U)SIM that carry SMS TPDUs, as
+// described in 3GPP TS 31.111 and ETSI TS 102 223.
+//
+// The SMS-PP DATA DOWNLOAD envelope passes an SMS-Deliver from the ME to
+// the (U)SIM, and the SEND SHORT MESSAGE proactive command passes an
+// SMS-Submit from the (U)SIM to the ME.
+package stk
+
+import "errors"
+
+// BER-TLV tags, as defined in ETSI TS 101 220 Section 7.2.
+const (
+	// TagProactiveCommand identifies a proactive command.
+	TagProactiveCommand byte = 0xd0
+	// TagSMSPPDownload identifies an SMS-PP data download envelope.
+	TagSMSPPDownload byte = 0xd1
+)
+
+// COMPREHENSION-TLV tags, as defined in ETSI TS 101 220 Section 7.3.
+//
+// The tags are encoded with the comprehension required flag clear.
+const (
+	// TagCommandDetails identifies the command details.
+	TagCommandDetails byte = 0x01
+	// TagDeviceIdentities identifies the source and destination devices.
+	TagDeviceIdentities byte = 0x02
+	// TagAlphaIdentifier identifies an alpha identifier.
+	TagAlphaIdentifier byte = 0x05
+	// TagAddress identifies an address, such as the SMSC address.
+	TagAddress byte = 0x06
+	// TagSMSTPDU identifies an SMS TPDU.
+	TagSMSTPDU byte = 0x0b
+)
+
+// Device identities, as defined in ETSI TS 102 223 Section 8.7.
+const (
+	// DeviceUICC identifies the (U)SIM.
+	DeviceUICC byte = 0x81
+	// DeviceTerminal identifies the ME.
+	DeviceTerminal byte = 0x82
+	// DeviceNetwork identifies the network.
+	DeviceNetwork byte = 0x83
+)
+
+var (
+	// ErrInvalid indicates a value is invalid.
+	ErrInvalid = errors.New("stk: invalid")
+
+	// ErrMissing indicates a mandatory TLV is missing.
+	ErrMissing = errors.New("stk: missing mandatory TLV")
+
+	// ErrOverlength indicates a value is too long to be encoded.
+	ErrOverlength = errors.New("stk: overlength")
+
+	// ErrUnderflow indicates the binary provided is too short.
+	ErrUnderflow = errors.New("stk: underflow")
+
+	// ErrUnexpectedTag indicates the BER-TLV tag is not the tag expected.
+	ErrUnexpectedTag = errors.New("stk: unexpected tag")
+
+	// ErrUnsupportedCommand indicates the proactive command is not the
+	// command expected.
+	ErrUnsupportedCommand = errors.New("stk: unsupported command")
+)
@@ -0,0 +1,166 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk
+
+const (
+	// crMask is the comprehension required flag of a COMPREHENSION-TLV tag.
+	crMask byte = 0x80
+
+	// tagThreeOctet indicates a three octet COMPREHENSION-TLV tag, which is
+	// not supported.
+	tagThreeOctet byte = 0x7f
+
+	// maxLength is the maximum length of a TLV value.
+	maxLength = 0xff
+)
+
+// TLV is a COMPREHENSION-TLV data object, as defined in ETSI TS 101 220
+// Section 7.1.1.
+type TLV struct {
+	// Tag is the tag, excluding the comprehension required flag.
+	Tag byte
+
+	// CR is the comprehension required flag.
+	CR bool
+
+	Value []byte
+}
+
+// MarshalBinary marshals the TLV into binary.
+func (t *TLV) MarshalBinary() ([]byte, error) {
+	if t.Tag&crMask != 0 || t.Tag == tagThreeOctet {
+		return nil, ErrInvalid
+	}
+	if len(t.Value) > maxLength {
+		return nil, ErrOverlength
+	}
+	tag := t.Tag
+	if t.CR {
+		tag |= crMask
+	}
+	b := append([]byte{tag}, encodeLength(len(t.Value))...)
+	return append(b, t.Value...), nil
+}
+
+// UnmarshalBinary unmarshals a TLV from the start of src.
+// It returns the number of bytes read from the source, and any error
+// detected while decoding.
+func (t *TLV) UnmarshalBinary(src []byte) (int, error) {
+	if len(src) < 1 {
+		return 0, ErrUnderflow
+	}
+	tag := src[0]
+	if tag&^crMask == tagThreeOctet || tag == 0x00 || tag == 0xff {
+		return 0, ErrInvalid
+	}
+	l, n, err := decodeLength(src[1:])
+	if err != nil {
+		return 0, err
+	}
+	ri := 1 + n
+	if len(src) < ri+l {
+		return 0, ErrUnderflow
+	}
+	t.Tag = tag &^ crMask
+	t.CR = tag&crMask != 0
+	t.Value = src[ri : ri+l]
+	return ri + l, nil
+}
+
+// Marshal marshals the TLVs into a BER-TLV data object with the tag.
+func Marshal(tag byte, tlvs []TLV) ([]byte, error) {
+	var v []byte
+	for i := range tlvs {
+		b, err := tlvs[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, b...)
+	}
+	if len(v) > maxLength {
+		return nil, ErrOverlength
+	}
+	b := append([]byte{tag}, encodeLength(len(v))...)
+	return append(b, v...), nil
+}
+
+// Unmarshal unmarshals a BER-TLV data object into its tag and the
+// COMPREHENSION-TLVs it contains.
+//
+// The src must contain only the BER-TLV data object, though any trailing
+// 0xff padding is ignored.
+func Unmarshal(src []byte) (byte, []TLV, error) {
+	if len(src) < 1 {
+		return 0, nil, ErrUnderflow
+	}
+	tag := src[0]
+	l, n, err := decodeLength(src[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	ri := 1 + n
+	if len(src) < ri+l {
+		return 0, nil, ErrUnderflow
+	}
+	for _, p := range src[ri+l:] {
+		if p != 0xff {
+			return 0, nil, ErrOverlength
+		}
+	}
+	v := src[ri : ri+l]
+	var tlvs []TLV
+	for len(v) > 0 {
+		t := TLV{}
+		n, err = t.UnmarshalBinary(v)
+		if err != nil {
+			return 0, nil, err
+		}
+		tlvs = append(tlvs, t)
+		v = v[n:]
+	}
+	return tag, tlvs, nil
+}
+
+// find returns the first TLV with the tag.
+func find(tlvs []TLV, tag byte) (TLV, bool) {
+	for _, t := range tlvs {
+		if t.Tag == tag {
+			return t, true
+		}
+	}
+	return TLV{}, false
+}
+
+// encodeLength encodes the length of a value, as defined in ETSI TS 101 220
+// Section 7.1.2.
+func encodeLength(l int) []byte {
+	if l < 0x80 {
+		return []byte{byte(l)}
+	}
+	return []byte{0x81, byte(l)}
+}
+
+// decodeLength decodes the length of a value from the start of src.
+// Returns the length, and the number of octets used to encode it.
+func decodeLength(src []byte) (int, int, error) {
+	if len(src) < 1 {
+		return 0, 0, ErrUnderflow
+	}
+	l := src[0]
+	if l < 0x80 {
+		return int(l), 1, nil
+	}
+	if l != 0x81 {
+		return 0, 0, ErrInvalid
+	}
+	if len(src) < 2 {
+		return 0, 0, ErrUnderflow
+	}
+	if src[1] < 0x80 {
+		return 0, 0, ErrInvalid
+	}
+	return int(src[1]), 2, nil
+}
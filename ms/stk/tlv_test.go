@@ -0,0 +1,136 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package stk_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/warthog618/sms/ms/stk"
+)
+
+func TestTLVMarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   stk.TLV
+		out  []byte
+		err  error
+	}{
+		{"empty", stk.TLV{Tag: 0x05}, []byte{0x05, 0x00}, nil},
+		{"cr", stk.TLV{Tag: 0x0b, CR: true, Value: []byte{1, 2}},
+			[]byte{0x8b, 0x02, 0x01, 0x02}, nil},
+		{"long", stk.TLV{Tag: 0x0b, Value: make([]byte, 0x80)},
+			append([]byte{0x0b, 0x81, 0x80}, make([]byte, 0x80)...), nil},
+		{"tag cr", stk.TLV{Tag: 0x85}, nil, stk.ErrInvalid},
+		{"tag three octet", stk.TLV{Tag: 0x7f}, nil, stk.ErrInvalid},
+		{"overlength", stk.TLV{Tag: 0x0b, Value: make([]byte, 0x100)}, nil, stk.ErrOverlength},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			b, err := p.in.MarshalBinary()
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.out, b)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestTLVUnmarshalBinary(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   []byte
+		out  stk.TLV
+		n    int
+		err  error
+	}{
+		{"empty", []byte{0x05, 0x00}, stk.TLV{Tag: 0x05, Value: []byte{}}, 2, nil},
+		{"cr", []byte{0x8b, 0x02, 0x01, 0x02, 0x03}, stk.TLV{Tag: 0x0b, CR: true,
+			Value: []byte{1, 2}}, 4, nil},
+		{"long", append([]byte{0x0b, 0x81, 0x80}, make([]byte, 0x80)...),
+			stk.TLV{Tag: 0x0b, Value: make([]byte, 0x80)}, 0x83, nil},
+		{"underflow", nil, stk.TLV{}, 0, stk.ErrUnderflow},
+		{"length underflow", []byte{0x05}, stk.TLV{}, 0, stk.ErrUnderflow},
+		{"value underflow", []byte{0x05, 0x02, 0x01}, stk.TLV{}, 0, stk.ErrUnderflow},
+		{"long length underflow", []byte{0x05, 0x81}, stk.TLV{}, 0, stk.ErrUnderflow},
+		{"long length short", []byte{0x05, 0x81, 0x7f}, stk.TLV{}, 0, stk.ErrInvalid},
+		{"length format", []byte{0x05, 0x82, 0x00, 0x80}, stk.TLV{}, 0, stk.ErrInvalid},
+		{"tag three octet", []byte{0x7f, 0x00}, stk.TLV{}, 0, stk.ErrInvalid},
+		{"tag zero", []byte{0x00, 0x00}, stk.TLV{}, 0, stk.ErrInvalid},
+		{"tag ff", []byte{0xff, 0x00}, stk.TLV{}, 0, stk.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			tlv := stk.TLV{}
+			n, err := tlv.UnmarshalBinary(p.in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.n, n)
+			assert.Equal(t, p.out, tlv)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := stk.Marshal(0xd0, []stk.TLV{
+		{Tag: 0x01, CR: true, Value: []byte{1, 0x13, 0}},
+		{Tag: 0x02, CR: true, Value: []byte{0x81, 0x83}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "d009810301130082028183", hex.EncodeToString(b))
+
+	b, err = stk.Marshal(0xd0, []stk.TLV{{Tag: 0x85}})
+	assert.Equal(t, stk.ErrInvalid, err)
+	assert.Nil(t, b)
+
+	b, err = stk.Marshal(0xd0, []stk.TLV{
+		{Tag: 0x0b, Value: make([]byte, 0xfd)},
+		{Tag: 0x05}})
+	assert.Equal(t, stk.ErrOverlength, err)
+	assert.Nil(t, b)
+}
+
+func TestUnmarshal(t *testing.T) {
+	patterns := []struct {
+		name string
+		in   string
+		tag  byte
+		out  []stk.TLV
+		err  error
+	}{
+		{"command", "d009810301130082028183", 0xd0, []stk.TLV{
+			{Tag: 0x01, CR: true, Value: []byte{1, 0x13, 0}},
+			{Tag: 0x02, CR: true, Value: []byte{0x81, 0x83}}}, nil},
+		{"padded", "d0048202818" + "3ffff", 0xd0, []stk.TLV{
+			{Tag: 0x02, CR: true, Value: []byte{0x81, 0x83}}}, nil},
+		{"empty", "d000", 0xd0, nil, nil},
+		{"underflow", "", 0, nil, stk.ErrUnderflow},
+		{"length underflow", "d0", 0, nil, stk.ErrUnderflow},
+		{"value underflow", "d00582028183", 0, nil, stk.ErrUnderflow},
+		{"trailing", "d004820281830000", 0, nil, stk.ErrOverlength},
+		{"tlv underflow", "d003820281", 0, nil, stk.ErrUnderflow},
+		{"tlv invalid", "d0027f00", 0, nil, stk.ErrInvalid},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			in, _ := hex.DecodeString(p.in)
+			tag, tlvs, err := stk.Unmarshal(in)
+			assert.Equal(t, p.err, err)
+			assert.Equal(t, p.tag, tag)
+			assert.Equal(t, p.out, tlvs)
+		}
+		t.Run(p.name, f)
+	}
+}
+
+func TestUnmarshalLong(t *testing.T) {
+	v := bytes.Repeat([]byte{0xa5}, 0x80)
+	in := append([]byte{0xd1, 0x81, 0x83, 0x8b, 0x81, 0x80}, v...)
+	tag, tlvs, err := stk.Unmarshal(in)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0xd1), tag)
+	assert.Equal(t, []stk.TLV{{Tag: 0x0b, CR: true, Value: v}}, tlvs)
+}
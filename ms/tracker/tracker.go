@@ -0,0 +1,314 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+// Package tracker provides tracking of the delivery status of sent messages
+// by matching received Status Report TPDUs to the Submit TPDUs that
+// requested them.
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/warthog618/sms/encoding/tpdu"
+)
+
+// Category is the broad classification of the delivery status of a Submit
+// TPDU, as determined by the TP-ST field of the corresponding Status
+// Report, as defined in 3GPP TS 23.040 Section 9.2.3.15.
+type Category int
+
+const (
+	// Pending indicates no Status Report has been received.
+	Pending Category = iota
+	// Delivered indicates the short message transaction completed.
+	Delivered
+	// TemporaryErrorTrying indicates a temporary error, with the SC still
+	// trying to transfer the short message.
+	TemporaryErrorTrying
+	// PermanentError indicates a permanent error, with the SC no longer
+	// making any attempt to transfer the short message.
+	PermanentError
+	// TemporaryErrorGivenUp indicates a temporary error, with the SC no
+	// longer making any attempt to transfer the short message.
+	TemporaryErrorGivenUp
+)
+
+var categoryNames = map[Category]string{
+	Pending:               "pending",
+	Delivered:             "delivered",
+	TemporaryErrorTrying:  "temporary error, SC still trying",
+	PermanentError:        "permanent error",
+	TemporaryErrorGivenUp: "temporary error, SC not trying",
+}
+
+func (c Category) String() string {
+	if s, ok := categoryNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("Category(%d)", int(c))
+}
+
+// Final returns true if the Category indicates the SC has finished
+// attempting to transfer the short message.
+func (c Category) Final() bool {
+	return c == Delivered || c == PermanentError || c == TemporaryErrorGivenUp
+}
+
+// Categorize returns the Category of the TP-ST value.
+// Bit 7 is reserved and is ignored.
+func Categorize(st byte) Category {
+	s := tpdu.Status(st)
+	switch {
+	case s.Completed():
+		return Delivered
+	case s.Transient():
+		return TemporaryErrorTrying
+	case s.Permanent():
+		return PermanentError
+	default:
+		return TemporaryErrorGivenUp
+	}
+}
+
+// Segment is the delivery status of one Submit TPDU of a message.
+type Segment struct {
+	// MR is the message reference of the Submit TPDU.
+	MR byte
+
+	// ST is the TP-ST from the most recent Status Report, if any.
+	ST tpdu.Status
+
+	// Category is the Category of the ST, or Pending if no Status Report
+	// has been received.
+	Category Category
+
+	// SCTS is the SC timestamp from the most recent Status Report, if any.
+	SCTS tpdu.Timestamp
+
+	// DT is the discharge time from the most recent Status Report, if any.
+	DT tpdu.Timestamp
+}
+
+// Delivery is the delivery status of a message, being the set of Submit
+// TPDUs tracked together.
+type Delivery struct {
+	// ID identifies the message within the Tracker.
+	ID int
+
+	// DA is the destination address of the message.
+	DA tpdu.Address
+
+	// State is the aggregate delivery state of the message.
+	// The message is Delivered only once all segments are Delivered.
+	// Otherwise the State is the most severe of the segment Categories,
+	// with PermanentError the most severe, followed by
+	// TemporaryErrorGivenUp, TemporaryErrorTrying and Pending.
+	State Category
+
+	// Segments contains the status of each of the Submit TPDUs, in the
+	// order they were tracked.
+	Segments []Segment
+}
+
+// severity orders the Categories for determining the aggregate State.
+var severity = map[Category]int{
+	Delivered:             0,
+	Pending:               1,
+	TemporaryErrorTrying:  2,
+	TemporaryErrorGivenUp: 3,
+	PermanentError:        4,
+}
+
+// Tracker records sent Submit TPDUs and matches received Status Reports to
+// them.
+type Tracker struct {
+	mutex    sync.Mutex // covers nextID, msgs and refs
+	nextID   int
+	msgs     map[int]*Delivery
+	refs     map[string]ref
+	callback func(Delivery)
+}
+
+// ref identifies a segment of a tracked message.
+type ref struct {
+	id  int
+	idx int
+}
+
+// New creates a Tracker.
+// The callback, if not nil, is called with a copy of the Delivery whenever
+// the State of a tracked message changes.
+// The callback is called from the goroutine calling Match, and must not
+// block.
+func New(callback func(Delivery)) *Tracker {
+	return &Tracker{
+		msgs:     make(map[int]*Delivery),
+		refs:     make(map[string]ref),
+		callback: callback,
+	}
+}
+
+// Track records the set of Submit TPDUs comprising a message, such as the
+// segments returned by sar.Segmenter, and returns the ID assigned to the
+// message.
+//
+// The Submit TPDUs must contain the MR used when they were sent, and
+// should request a Status Report.
+// If a Submit has the same MR and destination as a Submit already tracked
+// then the MR has wrapped, and the message containing the older Submit can
+// no longer be completed, so it is no longer tracked.
+//
+// Returns ErrEmpty if there are no Submit TPDUs.
+func (t *Tracker) Track(segments []tpdu.Submit) (int, error) {
+	if len(segments) == 0 {
+		return 0, ErrEmpty
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.nextID++
+	d := &Delivery{
+		ID:       t.nextID,
+		DA:       segments[0].DA,
+		Segments: make([]Segment, len(segments)),
+	}
+	for i, s := range segments {
+		d.Segments[i].MR = s.MR
+		k := key(s.DA, s.MR)
+		if r, ok := t.refs[k]; ok && r.id != d.ID {
+			t.forget(r.id)
+		}
+		t.refs[k] = ref{d.ID, i}
+	}
+	t.msgs[d.ID] = d
+	return d.ID, nil
+}
+
+// Match updates the status of the tracked Submit TPDU corresponding to the
+// Status Report, matched by MR and recipient address, and returns the
+// updated Delivery.
+//
+// The recipient address is matched on the address digits only, as the SC
+// may report the address using a different type of number than was used
+// in the Submit.
+//
+// Once all segments of a message have reached a Final Category the message
+// is no longer tracked.
+//
+// If the Status Report does not match a tracked Submit, including where the
+// Status Report is the result of a Command, then ErrNotFound is returned.
+func (t *Tracker) Match(sr *tpdu.StatusReport) (Delivery, error) {
+	if sr.SRQ() {
+		return Delivery{}, ErrNotFound
+	}
+	t.mutex.Lock()
+	k := key(sr.RA, sr.MR)
+	r, ok := t.refs[k]
+	if !ok {
+		t.mutex.Unlock()
+		return Delivery{}, ErrNotFound
+	}
+	d := t.msgs[r.id]
+	s := &d.Segments[r.idx]
+	s.ST = sr.Status()
+	s.Category = Categorize(sr.ST)
+	s.SCTS = sr.SCTS
+	s.DT = sr.DT
+	if s.Category.Final() {
+		delete(t.refs, k)
+	}
+	prev := d.State
+	d.State = aggregate(d.Segments)
+	if complete(d.Segments) {
+		delete(t.msgs, d.ID)
+	}
+	dc := d.copy()
+	t.mutex.Unlock()
+	if prev != dc.State && t.callback != nil {
+		t.callback(dc)
+	}
+	return dc, nil
+}
+
+// Delivery returns a copy of the Delivery for the tracked message.
+// Returns false if the message is not tracked.
+func (t *Tracker) Delivery(id int) (Delivery, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	d, ok := t.msgs[id]
+	if !ok {
+		return Delivery{}, false
+	}
+	return d.copy(), true
+}
+
+// Forget stops tracking the message.
+// Returns false if the message was not tracked.
+func (t *Tracker) Forget(id int) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.forget(id)
+}
+
+// forget stops tracking the message.
+// The mutex must be held by the caller.
+func (t *Tracker) forget(id int) bool {
+	d, ok := t.msgs[id]
+	if !ok {
+		return false
+	}
+	for i, s := range d.Segments {
+		k := key(d.DA, s.MR)
+		if r, ok := t.refs[k]; ok && r == (ref{id, i}) {
+			delete(t.refs, k)
+		}
+	}
+	delete(t.msgs, id)
+	return true
+}
+
+func (d *Delivery) copy() Delivery {
+	dc := *d
+	dc.Segments = make([]Segment, len(d.Segments))
+	copy(dc.Segments, d.Segments)
+	return dc
+}
+
+// aggregate returns the aggregate State of the segments.
+func aggregate(segments []Segment) Category {
+	state := Delivered
+	for _, s := range segments {
+		if severity[s.Category] > severity[state] {
+			state = s.Category
+		}
+	}
+	return state
+}
+
+// complete returns true if all the segments have reached a Final Category.
+func complete(segments []Segment) bool {
+	for _, s := range segments {
+		if !s.Category.Final() {
+			return false
+		}
+	}
+	return true
+}
+
+// key returns the key used to match Status Reports to Submits.
+func key(a tpdu.Address, mr byte) string {
+	return fmt.Sprintf("%s:%d", strings.TrimPrefix(a.Addr, "+"), mr)
+}
+
+var (
+	// ErrNotFound indicates the Status Report does not match a tracked
+	// Submit.
+	ErrNotFound = errors.New("not found")
+
+	// ErrEmpty indicates there are no Submit TPDUs to track.
+	ErrEmpty = errors.New("no segments")
+)
@@ -0,0 +1,212 @@
+// Copyright © 2018 Kent Gibson <warthog618@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package tracker_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/warthog618/sms/encoding/tpdu"
+	"github.com/warthog618/sms/ms/sar"
+	"github.com/warthog618/sms/ms/tracker"
+)
+
+func TestCategorize(t *testing.T) {
+	patterns := []struct {
+		st  byte
+		out tracker.Category
+	}{
+		{0x00, tracker.Delivered},
+		{0x02, tracker.Delivered},
+		{0x1f, tracker.Delivered},
+		{0x20, tracker.TemporaryErrorTrying},
+		{0x3f, tracker.TemporaryErrorTrying},
+		{0x40, tracker.PermanentError},
+		{0x5f, tracker.PermanentError},
+		{0x60, tracker.TemporaryErrorGivenUp},
+		{0x7f, tracker.TemporaryErrorGivenUp},
+		{0xc0, tracker.PermanentError},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, tracker.Categorize(p.st))
+		}
+		t.Run(fmt.Sprintf("%02x", p.st), f)
+	}
+}
+
+func TestCategoryFinal(t *testing.T) {
+	patterns := []struct {
+		c   tracker.Category
+		out bool
+	}{
+		{tracker.Pending, false},
+		{tracker.Delivered, true},
+		{tracker.TemporaryErrorTrying, false},
+		{tracker.PermanentError, true},
+		{tracker.TemporaryErrorGivenUp, true},
+	}
+	for _, p := range patterns {
+		f := func(t *testing.T) {
+			assert.Equal(t, p.out, p.c.Final())
+		}
+		t.Run(p.c.String(), f)
+	}
+}
+
+func TestCategoryString(t *testing.T) {
+	assert.Equal(t, "delivered", tracker.Delivered.String())
+	assert.Equal(t, "Category(42)", tracker.Category(42).String())
+}
+
+func newSegments(da string, mr byte, msg string) []tpdu.Submit {
+	s := tpdu.NewSubmit()
+	s.DA = tpdu.Address{TOA: 0x91, Addr: da}
+	segments := sar.NewSegmenter().Segment([]byte(msg), s)
+	for i := range segments {
+		segments[i].MR = mr + byte(i)
+	}
+	return segments
+}
+
+func newReport(ra string, mr, st byte) *tpdu.StatusReport {
+	sr := tpdu.NewStatusReport()
+	sr.RA = tpdu.Address{TOA: 0x81, Addr: ra}
+	sr.MR = mr
+	sr.ST = st
+	return sr
+}
+
+func TestTrackerSingle(t *testing.T) {
+	var states []tracker.Category
+	tr := tracker.New(func(d tracker.Delivery) {
+		states = append(states, d.State)
+	})
+	id, err := tr.Track(newSegments("61409123456", 42, "hello"))
+	require.Nil(t, err)
+	d, ok := tr.Delivery(id)
+	require.True(t, ok)
+	assert.Equal(t, tracker.Pending, d.State)
+	assert.Equal(t, []tracker.Segment{{MR: 42}}, d.Segments)
+
+	// wrong MR
+	_, err = tr.Match(newReport("61409123456", 43, 0x00))
+	assert.Equal(t, tracker.ErrNotFound, err)
+	// wrong recipient
+	_, err = tr.Match(newReport("61409123457", 42, 0x00))
+	assert.Equal(t, tracker.ErrNotFound, err)
+	// result of a command
+	sr := newReport("61409123456", 42, 0x00)
+	sr.FirstOctet |= 0x20
+	_, err = tr.Match(sr)
+	assert.Equal(t, tracker.ErrNotFound, err)
+
+	d, err = tr.Match(newReport("+61409123456", 42, 0x30))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.TemporaryErrorTrying, d.State)
+	assert.Equal(t, id, d.ID)
+	assert.Equal(t, tpdu.Status(0x30), d.Segments[0].ST)
+
+	d, err = tr.Match(newReport("61409123456", 42, 0x00))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.Delivered, d.State)
+	assert.Equal(t, []tracker.Category{tracker.TemporaryErrorTrying, tracker.Delivered}, states)
+
+	// no longer tracked
+	_, ok = tr.Delivery(id)
+	assert.False(t, ok)
+	_, err = tr.Match(newReport("61409123456", 42, 0x00))
+	assert.Equal(t, tracker.ErrNotFound, err)
+}
+
+func TestTrackerConcatenated(t *testing.T) {
+	var deliveries []tracker.Delivery
+	tr := tracker.New(func(d tracker.Delivery) {
+		deliveries = append(deliveries, d)
+	})
+	msg := "this is a very long message that does not fit in a single SMS message, at least it will if I keep adding more stuff to the end of it, so here is some more, and then some more again"
+	segments := newSegments("61409123456", 10, msg)
+	require.Equal(t, 2, len(segments))
+	id, err := tr.Track(segments)
+	require.Nil(t, err)
+
+	d, err := tr.Match(newReport("61409123456", 10, 0x00))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.Pending, d.State)
+	assert.Empty(t, deliveries)
+
+	d, err = tr.Match(newReport("61409123456", 11, 0x41))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.PermanentError, d.State)
+	assert.Equal(t, tracker.Delivered, d.Segments[0].Category)
+	assert.Equal(t, tracker.PermanentError, d.Segments[1].Category)
+	require.Equal(t, 1, len(deliveries))
+	assert.Equal(t, d, deliveries[0])
+
+	_, ok := tr.Delivery(id)
+	assert.False(t, ok)
+}
+
+func TestTrackerAggregate(t *testing.T) {
+	tr := tracker.New(nil)
+	_, err := tr.Track([]tpdu.Submit{
+		{MR: 1, DA: tpdu.Address{Addr: "1234"}},
+		{MR: 2, DA: tpdu.Address{Addr: "1234"}},
+		{MR: 3, DA: tpdu.Address{Addr: "1234"}},
+	})
+	require.Nil(t, err)
+	d, err := tr.Match(newReport("1234", 1, 0x20))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.TemporaryErrorTrying, d.State)
+	d, err = tr.Match(newReport("1234", 2, 0x60))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.TemporaryErrorGivenUp, d.State)
+	d, err = tr.Match(newReport("1234", 3, 0x40))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.PermanentError, d.State)
+	d, err = tr.Match(newReport("1234", 1, 0x00))
+	require.Nil(t, err)
+	assert.Equal(t, tracker.PermanentError, d.State)
+}
+
+func TestTrackerMRWrap(t *testing.T) {
+	tr := tracker.New(nil)
+	old, err := tr.Track([]tpdu.Submit{
+		{MR: 6, DA: tpdu.Address{Addr: "1234"}},
+		{MR: 7, DA: tpdu.Address{Addr: "1234"}},
+	})
+	require.Nil(t, err)
+	id, err := tr.Track(newSegments("1234", 7, "new"))
+	require.Nil(t, err)
+	d, err := tr.Match(newReport("1234", 7, 0x00))
+	require.Nil(t, err)
+	assert.Equal(t, id, d.ID)
+
+	// the older message can no longer complete, so is no longer tracked
+	_, ok := tr.Delivery(old)
+	assert.False(t, ok)
+	_, err = tr.Match(newReport("1234", 6, 0x00))
+	assert.Equal(t, tracker.ErrNotFound, err)
+}
+
+func TestTrackerEmpty(t *testing.T) {
+	tr := tracker.New(nil)
+	id, err := tr.Track(nil)
+	assert.Equal(t, tracker.ErrEmpty, err)
+	assert.Equal(t, 0, id)
+}
+
+func TestTrackerForget(t *testing.T) {
+	tr := tracker.New(nil)
+	id, err := tr.Track(newSegments("1234", 7, "hello"))
+	require.Nil(t, err)
+	assert.True(t, tr.Forget(id))
+	assert.False(t, tr.Forget(id))
+	_, err = tr.Match(newReport("1234", 7, 0x00))
+	assert.Equal(t, tracker.ErrNotFound, err)
+}